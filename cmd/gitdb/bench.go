@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb"
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb/bench"
+)
+
+func runBench(client *gitdb.Client, args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	cfg := bench.Config{}
+	flags.StringVar(&cfg.Collection, "collection", "bench", "collection to write benchmark documents to")
+	flags.IntVar(&cfg.Concurrency, "concurrency", 8, "number of concurrent workers")
+	flags.DurationVar(&cfg.Duration, "duration", 30*time.Second, "how long to run")
+	flags.IntVar(&cfg.Requests, "requests", 0, "stop after this many requests (0 = no limit)")
+	flags.IntVar(&cfg.DocumentSize, "size", 256, "document payload size in bytes")
+	flags.IntVar(&cfg.Seed, "seed", 100, "documents inserted before measuring")
+	mix := flags.String("mix", "insert=10,find-by-id=60,find=15,update=15", "operation weights")
+	flags.Parse(args)
+
+	var err error
+	if cfg.Mix, err = bench.ParseMix(*mix); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "running %d workers against %s/%s...\n", cfg.Concurrency, client.BaseURL, cfg.Collection)
+	report, err := bench.Run(ctx, client, cfg)
+	if err != nil {
+		return err
+	}
+	report.Print(os.Stdout)
+	return nil
+}
@@ -0,0 +1,443 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb"
+)
+
+// browser is a line-based command prompt for browsing collections, built
+// on the client API
+type browser struct {
+	client   *gitdb.Client
+	in       *bufio.Scanner
+	out      io.Writer
+	pageSize int
+
+	collection string
+	// total counts the documents of the open collection; only the page on
+	// screen is held in documents
+	total     int
+	page      int
+	documents []gitdb.Document
+}
+
+const browseHelp = `commands:
+  ls                     list collections
+  use <collection>       open a collection
+  page [n]               show page n of the open collection
+  next, prev             move between pages
+  show <id|#>            show a document
+  history <id|#>         list the revisions of a document
+  diff <id|#> <a> <b>    diff two revisions (numbers from history, 0 = newest)
+  edit <id|#>            edit a document in $EDITOR
+  refresh                reload the current page
+  help                   show this help
+  quit                   leave the browser`
+
+func runBrowse(client *gitdb.Client, args []string) error {
+	flags := flag.NewFlagSet("browse", flag.ExitOnError)
+	pageSize := flags.Int("page-size", 20, "documents per page")
+	flags.Parse(args)
+
+	if *pageSize <= 0 {
+		fmt.Fprintln(flags.Output(), "-page-size must be positive")
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	b := &browser{
+		client:   client,
+		in:       bufio.NewScanner(os.Stdin),
+		out:      os.Stdout,
+		pageSize: *pageSize,
+	}
+	// Interrupting the browser ends it, so requests need no cancellation
+	ctx := context.Background()
+	if flags.NArg() > 0 {
+		if err := b.use(ctx, flags.Arg(0)); err != nil {
+			return err
+		}
+	} else if err := b.list(ctx); err != nil {
+		return err
+	}
+
+	return b.loop(ctx)
+}
+
+func (b *browser) loop(ctx context.Context) error {
+	for {
+		prompt := "gitdb"
+		if b.collection != "" {
+			prompt += ":" + b.collection
+		}
+		fmt.Fprintf(b.out, "%s> ", prompt)
+
+		if !b.in.Scan() {
+			fmt.Fprintln(b.out)
+			return b.in.Err()
+		}
+
+		fields := strings.Fields(b.in.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		cmd, args := fields[0], fields[1:]
+		if cmd == "quit" || cmd == "exit" || cmd == "q" {
+			return nil
+		}
+		if err := b.dispatch(ctx, cmd, args); err != nil {
+			fmt.Fprintf(b.out, "error: %v\n", err)
+		}
+	}
+}
+
+func (b *browser) dispatch(ctx context.Context, cmd string, args []string) error {
+	switch cmd {
+	case "help", "?":
+		fmt.Fprintln(b.out, browseHelp)
+		return nil
+	case "ls":
+		return b.list(ctx)
+	case "use":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: use <collection>")
+		}
+		return b.use(ctx, args[0])
+	case "refresh":
+		if b.collection == "" {
+			return fmt.Errorf("no collection open")
+		}
+		if err := b.count(ctx); err != nil {
+			return err
+		}
+		return b.showPage(ctx, min(b.page, max(b.pages()-1, 0)))
+	case "page":
+		page := b.page
+		if len(args) > 0 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("usage: page [n]")
+			}
+			page = n - 1
+		}
+		return b.showPage(ctx, page)
+	case "next", "n":
+		return b.showPage(ctx, b.page+1)
+	case "prev", "p":
+		return b.showPage(ctx, b.page-1)
+	case "show":
+		doc, err := b.document(ctx, args)
+		if err != nil {
+			return err
+		}
+		return b.printJSON(doc)
+	case "history":
+		return b.history(ctx, args)
+	case "diff":
+		return b.diff(ctx, args)
+	case "edit":
+		return b.edit(ctx, args)
+	}
+	return fmt.Errorf("unknown command %q, try help", cmd)
+}
+
+func (b *browser) list(ctx context.Context) error {
+	collections, err := b.client.ListCollections(ctx)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(collections, func(i, j int) bool { return collections[i].Name < collections[j].Name })
+	fmt.Fprintf(b.out, "%-30s %10s  %s\n", "COLLECTION", "DOCUMENTS", "CREATED")
+	for _, c := range collections {
+		fmt.Fprintf(b.out, "%-30s %10d  %s\n", c.Name, c.Count, c.Created)
+	}
+	return nil
+}
+
+func (b *browser) use(ctx context.Context, collection string) error {
+	total, err := b.client.Count(ctx, collection, gitdb.Query{})
+	if err != nil {
+		return err
+	}
+
+	b.collection = collection
+	b.total = total
+	b.documents = nil
+	return b.showPage(ctx, 0)
+}
+
+func (b *browser) count(ctx context.Context) error {
+	total, err := b.client.Count(ctx, b.collection, gitdb.Query{})
+	if err != nil {
+		return err
+	}
+	b.total = total
+	return nil
+}
+
+func (b *browser) pages() int {
+	return (b.total + b.pageSize - 1) / b.pageSize
+}
+
+// showPage fetches one page of the open collection, in _id order, and
+// lists it
+func (b *browser) showPage(ctx context.Context, page int) error {
+	if b.collection == "" {
+		return fmt.Errorf("no collection open")
+	}
+	if page < 0 || (page >= b.pages() && page > 0) {
+		return fmt.Errorf("no page %d", page+1)
+	}
+
+	documents, err := b.client.FindWithOptions(ctx, b.collection, gitdb.Query{}, gitdb.FindOptions{
+		Sort:  []gitdb.SortField{gitdb.Asc("_id")},
+		Skip:  page * b.pageSize,
+		Limit: b.pageSize,
+	})
+	if err != nil {
+		return err
+	}
+	b.page = page
+	b.documents = documents
+
+	start := page * b.pageSize
+	for i, doc := range documents {
+		fmt.Fprintf(b.out, "%4d  %s\n", start+i+1, summarize(doc, 100))
+	}
+	fmt.Fprintf(b.out, "-- page %d of %d, %d documents --\n", page+1, max(b.pages(), 1), b.total)
+	return nil
+}
+
+// document resolves a document reference: an ID or a #row number from the
+// page listing
+func (b *browser) document(ctx context.Context, args []string) (gitdb.Document, error) {
+	id, err := b.documentID(args)
+	if err != nil {
+		return nil, err
+	}
+	return b.client.FindByID(ctx, b.collection, id)
+}
+
+func (b *browser) documentID(args []string) (string, error) {
+	if b.collection == "" {
+		return "", fmt.Errorf("no collection open")
+	}
+	if len(args) == 0 {
+		return "", fmt.Errorf("missing document ID or #row")
+	}
+
+	ref := args[0]
+	if strings.HasPrefix(ref, "#") {
+		row, err := strconv.Atoi(ref[1:])
+		index := row - 1 - b.page*b.pageSize
+		if err != nil || index < 0 || index >= len(b.documents) {
+			return "", fmt.Errorf("no row %s on this page", ref)
+		}
+		id, ok := b.documents[index]["_id"].(string)
+		if !ok {
+			return "", fmt.Errorf("row %s has no _id", ref)
+		}
+		return id, nil
+	}
+	return ref, nil
+}
+
+func (b *browser) history(ctx context.Context, args []string) error {
+	id, err := b.documentID(args)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := b.client.DocumentHistory(ctx, b.collection, id)
+	if err != nil {
+		return err
+	}
+
+	for i, rev := range revisions {
+		state := ""
+		if rev.Deleted {
+			state = " (deleted)"
+		}
+		fmt.Fprintf(b.out, "%3d  %.10s  %s  %-20s %s%s\n",
+			i, rev.Commit, rev.Timestamp.Format("2006-01-02 15:04:05"), rev.Author, rev.Message, state)
+	}
+	return nil
+}
+
+func (b *browser) diff(ctx context.Context, args []string) error {
+	if len(args) != 3 {
+		return fmt.Errorf("usage: diff <id|#> <a> <b>")
+	}
+	id, err := b.documentID(args[:1])
+	if err != nil {
+		return err
+	}
+	from, errA := strconv.Atoi(args[1])
+	to, errB := strconv.Atoi(args[2])
+	if errA != nil || errB != nil {
+		return fmt.Errorf("revisions must be numbers from history")
+	}
+
+	revisions, err := b.client.DocumentHistory(ctx, b.collection, id)
+	if err != nil {
+		return err
+	}
+	if from < 0 || from >= len(revisions) || to < 0 || to >= len(revisions) {
+		return fmt.Errorf("document has %d revisions", len(revisions))
+	}
+
+	a, z := revisions[from].Document, revisions[to].Document
+	fmt.Fprintf(b.out, "--- %.10s\n+++ %.10s\n", revisions[from].Commit, revisions[to].Commit)
+	for _, key := range unionKeys(a, z) {
+		before, inA := a[key]
+		after, inZ := z[key]
+		switch {
+		case !inZ:
+			fmt.Fprintf(b.out, "- %s: %s\n", key, compact(before))
+		case !inA:
+			fmt.Fprintf(b.out, "+ %s: %s\n", key, compact(after))
+		case !reflect.DeepEqual(before, after):
+			fmt.Fprintf(b.out, "- %s: %s\n+ %s: %s\n", key, compact(before), key, compact(after))
+		}
+	}
+	return nil
+}
+
+func (b *browser) edit(ctx context.Context, args []string) error {
+	id, err := b.documentID(args)
+	if err != nil {
+		return err
+	}
+	original, err := b.client.FindByID(ctx, b.collection, id)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp("", "gitdb-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	data, _ := json.MarshalIndent(original, "", "  ")
+	file.Write(append(data, '\n'))
+	file.Close()
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", file.Name())
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor failed: %w", err)
+	}
+
+	data, err = os.ReadFile(file.Name())
+	if err != nil {
+		return err
+	}
+	var edited gitdb.Document
+	if err := json.Unmarshal(data, &edited); err != nil {
+		return fmt.Errorf("invalid JSON, changes discarded: %w", err)
+	}
+
+	set, unset := gitdb.Document{}, gitdb.Document{}
+	for _, key := range unionKeys(original, edited) {
+		if key == "_id" {
+			continue
+		}
+		after, ok := edited[key]
+		if !ok {
+			unset[key] = ""
+		} else if !reflect.DeepEqual(original[key], after) {
+			set[key] = after
+		}
+	}
+	if len(set) == 0 && len(unset) == 0 {
+		fmt.Fprintln(b.out, "no changes")
+		return nil
+	}
+
+	update := gitdb.Update{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if _, err := b.client.Update(ctx, b.collection, id, update); err != nil {
+		return err
+	}
+	fmt.Fprintf(b.out, "updated %s: %d fields set, %d removed\n", id, len(set), len(unset))
+	return nil
+}
+
+func (b *browser) printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(b.out, string(data))
+	return nil
+}
+
+// summarize renders a document on one line, truncated to width
+func summarize(doc gitdb.Document, width int) string {
+	line := compact(doc)
+	if len(line) > width {
+		line = line[:width-3] + "..."
+	}
+	return line
+}
+
+func compact(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func unionKeys(a, b gitdb.Document) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, doc := range []gitdb.Document{a, b} {
+		for key := range doc {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb"
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb/datagen"
+)
+
+func runDatagen(client *gitdb.Client, args []string) error {
+	flags := flag.NewFlagSet("datagen", flag.ExitOnError)
+	templateFile := flags.String("template", "", "JSON template file (required)")
+	count := flags.Int("n", 100, "number of documents to generate")
+	seed := flags.Int64("seed", 1, "random seed; the same seed reproduces the same data")
+	concurrency := flags.Int("concurrency", 4, "parallel inserts")
+	dryRun := flags.Bool("dry-run", false, "print the documents instead of loading them")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: gitdb datagen -template file.json [flags] <collection>\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *templateFile == "" || (flags.NArg() != 1 && !*dryRun) {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	data, err := os.ReadFile(*templateFile)
+	if err != nil {
+		return err
+	}
+	var tpl datagen.Template
+	if err := json.Unmarshal(data, &tpl); err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+
+	if *dryRun {
+		enc := json.NewEncoder(os.Stdout)
+		for _, doc := range datagen.New(*seed).Documents(tpl, *count) {
+			if err := enc.Encode(doc); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	collection := flags.Arg(0)
+	ids, err := datagen.Load(ctx, client, collection, tpl, *count, datagen.LoadOptions{
+		Seed:        *seed,
+		Concurrency: *concurrency,
+		Progress: func(loaded int) {
+			if loaded%100 == 0 {
+				fmt.Fprintf(os.Stderr, "\rloaded %d/%d", loaded, *count)
+			}
+		},
+	})
+	fmt.Fprintf(os.Stderr, "\rloaded %d/%d documents into %s\n", len(ids), *count, collection)
+	return err
+}
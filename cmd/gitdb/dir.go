@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb"
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb/gitdbsync"
+)
+
+func runPull(client *gitdb.Client, args []string) error {
+	flags := flag.NewFlagSet("pull", flag.ExitOnError)
+	dir := flags.String("dir", "", "directory holding the documents; defaults to the collection name")
+	collection := parseDirFlags(flags, args, dir)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report, err := gitdbsync.Pull(ctx, client, collection, *dir)
+	if report != nil {
+		printDirReport(report)
+	}
+	return err
+}
+
+func runPush(client *gitdb.Client, args []string) error {
+	flags := flag.NewFlagSet("push", flag.ExitOnError)
+	dir := flags.String("dir", "", "directory holding the documents; defaults to the collection name")
+	watch := flags.Bool("watch", false, "keep pushing local edits and pulling remote changes until interrupted")
+	interval := flags.Duration("interval", 5*time.Second, "time between remote checks with -watch")
+	collection := parseDirFlags(flags, args, dir)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if !*watch {
+		report, err := gitdbsync.Push(ctx, client, collection, *dir)
+		if report != nil {
+			printDirReport(report)
+		}
+		return err
+	}
+
+	fmt.Printf("watching %s for changes to %s, press Ctrl-C to stop\n", *dir, collection)
+	err := gitdbsync.Watch(ctx, client, collection, *dir, gitdbsync.WatchOptions{
+		RemoteInterval: *interval,
+		OnSync: func(report *gitdbsync.DirReport) {
+			fmt.Printf("%s  ", time.Now().Format("15:04:05"))
+			printDirReport(report)
+		},
+		OnError: func(err error) {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+		},
+	})
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// parseDirFlags parses the arguments of pull and push and returns the
+// collection, defaulting dir to its name
+func parseDirFlags(flags *flag.FlagSet, args []string, dir *string) string {
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: gitdb %s [flags] <collection>\n\n", flags.Name())
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *dir == "" {
+		*dir = flags.Arg(0)
+	}
+	return flags.Arg(0)
+}
+
+func printDirReport(report *gitdbsync.DirReport) {
+	fmt.Printf("%d written, %d removed locally; %d uploaded, %d deleted remotely\n",
+		report.Written, report.Removed, report.Uploaded, report.Deleted)
+	for _, id := range report.Conflicts {
+		fmt.Printf("conflict: %s changed locally and remotely, see %s.remote.json\n", id, id)
+	}
+}
@@ -0,0 +1,89 @@
+// Command gitdb is a command-line companion for GitDB servers.
+//
+// Usage:
+//
+//	gitdb [flags] <command> [arguments]
+//
+// The commands are:
+//
+//	browse    browse collections and documents from a prompt
+//	bench     run a read/write load test and report latency percentiles
+//	datagen   generate fake documents from a template and load them
+//	sync      copy collections to another GitDB instance incrementally
+//	pull      mirror a collection into a directory of JSON files
+//	push      upload the edits made in a mirrored directory
+//
+// Connection flags may also be set through the GITDB_URL, GITDB_TOKEN,
+// GITDB_OWNER and GITDB_REPO environment variables.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb"
+)
+
+// command is a gitdb subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(client *gitdb.Client, args []string) error
+}
+
+var commands = []command{
+	{"browse", "browse collections and documents from a prompt", runBrowse},
+	{"bench", "run a read/write load test and report latency percentiles", runBench},
+	{"datagen", "generate fake documents from a template and load them", runDatagen},
+	{"sync", "copy collections to another GitDB instance incrementally", runSync},
+	{"pull", "mirror a collection into a directory of JSON files", runPull},
+	{"push", "upload the edits made in a mirrored directory", runPush},
+}
+
+func main() {
+	flags := flag.NewFlagSet("gitdb", flag.ExitOnError)
+	baseURL := flags.String("url", envOr("GITDB_URL", "http://localhost:7896"), "GitDB server URL")
+	token := flags.String("token", os.Getenv("GITDB_TOKEN"), "GitHub token")
+	owner := flags.String("owner", os.Getenv("GITDB_OWNER"), "repository owner")
+	repo := flags.String("repo", os.Getenv("GITDB_REPO"), "repository name")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: gitdb [flags] <command> [arguments]\n\ncommands:\n")
+		for _, cmd := range commands {
+			fmt.Fprintf(flags.Output(), "  %-10s %s\n", cmd.name, cmd.summary)
+		}
+		fmt.Fprintf(flags.Output(), "\nflags:\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[1:])
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	client := gitdb.NewClient(*token, *owner, *repo)
+	client.SetBaseURL(*baseURL)
+
+	name := flags.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(client, flags.Args()[1:]); err != nil {
+				fmt.Fprintf(os.Stderr, "gitdb %s: %v\n", name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "gitdb: unknown command %q\n", name)
+	flags.Usage()
+	os.Exit(2)
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
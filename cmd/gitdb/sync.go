@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb"
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb/gitdbsync"
+)
+
+func runSync(client *gitdb.Client, args []string) error {
+	flags := flag.NewFlagSet("sync", flag.ExitOnError)
+	toURL := flags.String("to", "", "destination GitDB server URL (required)")
+	toToken := flags.String("to-token", os.Getenv("GITDB_TO_TOKEN"), "destination token; defaults to the source token")
+	toOwner := flags.String("to-owner", "", "destination repository owner; defaults to the source owner")
+	toRepo := flags.String("to-repo", "", "destination repository name; defaults to the source repository")
+	stateFile := flags.String("state", "", "file keeping watermarks between runs")
+	watch := flags.Bool("watch", false, "keep copying changes until interrupted")
+	interval := flags.Duration("interval", 10*time.Second, "time between passes with -watch")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: gitdb sync -to url [flags] [collection ...]\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *toURL == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	token, owner, repo := client.Token, client.Owner, client.Repo
+	if *toToken != "" {
+		token = *toToken
+	}
+	if *toOwner != "" {
+		owner = *toOwner
+	}
+	if *toRepo != "" {
+		repo = *toRepo
+	}
+	dst := gitdb.NewClient(token, owner, repo)
+	dst.SetBaseURL(*toURL)
+
+	watermarks, err := loadWatermarks(*stateFile)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var mu sync.Mutex
+	report, err := gitdbsync.Sync(ctx, client, dst, gitdbsync.Options{
+		Collections: flags.Args(),
+		Watermarks:  watermarks,
+		Continuous:  *watch,
+		Interval:    *interval,
+		OnCheckpoint: func(collection, commit string) {
+			mu.Lock()
+			defer mu.Unlock()
+			watermarks[collection] = commit
+			if err := saveWatermarks(*stateFile, watermarks); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to save state: %v\n", err)
+			}
+		},
+		OnError: func(collection string, err error) {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+		},
+	})
+
+	names := make([]string, 0, len(report.Collections))
+	for name := range report.Collections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		stats := report.Collections[name]
+		fmt.Printf("%-30s %6d upserted %6d deleted  at %s\n", name, stats.Upserted, stats.Deleted, stats.Watermark)
+	}
+
+	if *watch && errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// loadWatermarks reads the watermarks saved by a previous run
+func loadWatermarks(path string) (map[string]string, error) {
+	watermarks := make(map[string]string)
+	if path == "" {
+		return watermarks, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return watermarks, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &watermarks); err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %w", path, err)
+	}
+	return watermarks, nil
+}
+
+// saveWatermarks writes watermarks to path atomically
+func saveWatermarks(path string, watermarks map[string]string) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(watermarks, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
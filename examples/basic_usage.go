@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 
@@ -8,6 +9,8 @@ import (
 )
 
 func main() {
+	ctx := context.Background()
+
 	// Create a new GitDB client
 	client := gitdb.NewClient("your_github_token", "your_username", "your_repo")
 
@@ -15,20 +18,20 @@ func main() {
 	// client.SetBaseURL("http://localhost:7896")
 
 	// Health check
-	if err := client.Health(); err != nil {
+	if err := client.Health(ctx); err != nil {
 		log.Fatalf("Health check failed: %v", err)
 	}
 	fmt.Println("✅ GitDB server is healthy")
 
 	// Create a collection
 	collectionName := "users"
-	if err := client.CreateCollection(collectionName); err != nil {
+	if err := client.CreateCollection(ctx, collectionName); err != nil {
 		log.Fatalf("Failed to create collection: %v", err)
 	}
 	fmt.Printf("✅ Created collection: %s\n", collectionName)
 
 	// List collections
-	collections, err := client.ListCollections()
+	collections, err := client.ListCollections(ctx)
 	if err != nil {
 		log.Fatalf("Failed to list collections: %v", err)
 	}
@@ -48,7 +51,7 @@ func main() {
 		},
 	}
 
-	docID, err := client.Insert(collectionName, user)
+	docID, err := client.Insert(ctx, collectionName, user)
 	if err != nil {
 		log.Fatalf("Failed to insert document: %v", err)
 	}
@@ -68,14 +71,14 @@ func main() {
 		},
 	}
 
-	docID2, err := client.Insert(collectionName, user2)
+	docID2, err := client.Insert(ctx, collectionName, user2)
 	if err != nil {
 		log.Fatalf("Failed to insert document: %v", err)
 	}
 	fmt.Printf("✅ Inserted document with ID: %s\n", docID2)
 
 	// Find document by ID
-	doc, err := client.FindByID(collectionName, docID)
+	doc, err := client.FindByID(ctx, collectionName, docID)
 	if err != nil {
 		log.Fatalf("Failed to find document: %v", err)
 	}
@@ -89,21 +92,21 @@ func main() {
 		},
 	}
 
-	documents, err := client.Find(collectionName, query)
+	documents, err := client.Find(ctx, collectionName, query)
 	if err != nil {
 		log.Fatalf("Failed to find documents: %v", err)
 	}
 	fmt.Printf("🔍 Found %d active users aged 25+: %+v\n", len(documents), documents)
 
 	// Find one document
-	oneDoc, err := client.FindOne(collectionName, gitdb.Query{"email": "john@example.com"})
+	oneDoc, err := client.FindOne(ctx, collectionName, gitdb.Query{"email": "john@example.com"})
 	if err != nil {
 		log.Fatalf("Failed to find one document: %v", err)
 	}
 	fmt.Printf("🔍 Found one document: %+v\n", oneDoc)
 
 	// Count documents
-	count, err := client.Count(collectionName, gitdb.Query{"active": true})
+	count, err := client.Count(ctx, collectionName, gitdb.Query{"active": true})
 	if err != nil {
 		log.Fatalf("Failed to count documents: %v", err)
 	}
@@ -117,7 +120,7 @@ func main() {
 		},
 	}
 
-	if err := client.Update(collectionName, docID, update); err != nil {
+	if _, err := client.Update(ctx, collectionName, docID, update); err != nil {
 		log.Fatalf("Failed to update document: %v", err)
 	}
 	fmt.Printf("✅ Updated document: %s\n", docID)
@@ -129,20 +132,20 @@ func main() {
 		},
 	}
 
-	modifiedCount, err := client.UpdateMany(collectionName, gitdb.Query{"active": true}, updateMany)
+	updated, err := client.UpdateMany(ctx, collectionName, gitdb.Query{"active": true}, updateMany)
 	if err != nil {
 		log.Fatalf("Failed to update many documents: %v", err)
 	}
-	fmt.Printf("✅ Updated %d documents\n", modifiedCount)
+	fmt.Printf("✅ Updated %d of %d matching documents\n", updated.ModifiedCount, updated.MatchedCount)
 
 	// Delete a document
-	if err := client.Delete(collectionName, docID2); err != nil {
+	if err := client.Delete(ctx, collectionName, docID2); err != nil {
 		log.Fatalf("Failed to delete document: %v", err)
 	}
 	fmt.Printf("✅ Deleted document: %s\n", docID2)
 
 	// Delete many documents
-	deletedCount, err := client.DeleteMany(collectionName, gitdb.Query{"active": false})
+	deletedCount, err := client.DeleteMany(ctx, collectionName, gitdb.Query{"active": false})
 	if err != nil {
 		log.Fatalf("Failed to delete many documents: %v", err)
 	}
@@ -161,14 +164,14 @@ func main() {
 		}
 	`
 
-	response, err := client.GraphQL(graphqlQuery, nil)
+	response, err := client.GraphQL(ctx, graphqlQuery, nil)
 	if err != nil {
 		log.Fatalf("Failed to execute GraphQL query: %v", err)
 	}
 	fmt.Printf("🔮 GraphQL response: %+v\n", response.Data)
 
 	// Delete collection
-	if err := client.DeleteCollection(collectionName); err != nil {
+	if err := client.DeleteCollection(ctx, collectionName); err != nil {
 		log.Fatalf("Failed to delete collection: %v", err)
 	}
 	fmt.Printf("✅ Deleted collection: %s\n", collectionName)
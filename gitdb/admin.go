@@ -0,0 +1,234 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Access is a level of access to a collection. Each level includes the
+// ones below it.
+type Access string
+
+const (
+	AccessRead  Access = "read"
+	AccessWrite Access = "write"
+	AccessAdmin Access = "admin"
+)
+
+// Permission grants a level of access to a collection. The collection "*"
+// stands for every collection.
+type Permission struct {
+	Collection string `json:"collection"`
+	Access     Access `json:"access"`
+}
+
+// Role is a named set of permissions assigned to tokens on the server
+type Role struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// AdminClient manages server-side access control. Its operations affect
+// the whole repository; get one with Client.Admin.
+type AdminClient struct {
+	client *Client
+}
+
+// Admin returns the client's administrative API. Administrative calls need
+// a token with admin access and are refused on namespaced clients.
+func (c *Client) Admin() *AdminClient {
+	return &AdminClient{client: c}
+}
+
+// url builds an admin API URL, escaping string arguments as path segments
+// and refusing namespaced clients
+func (a *AdminClient) url(format string, args ...interface{}) (string, error) {
+	if a.client.namespace != "" {
+		return "", fmt.Errorf("admin operations are not available on namespaced clients")
+	}
+	for i, arg := range args {
+		if s, ok := arg.(string); ok {
+			args[i] = pathSegment(s)
+		}
+	}
+	return a.client.BaseURL + "/api/v1/admin" + fmt.Sprintf(format, args...), nil
+}
+
+// ListRoles lists the roles defined on the server
+func (a *AdminClient) ListRoles(ctx context.Context) ([]Role, error) {
+	url, err := a.url("/roles")
+	if err != nil {
+		return nil, err
+	}
+
+	var roles []Role
+	if err := a.client.doJSON(ctx, "GET", url, nil, &roles, http.StatusOK, "list roles"); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// GetRole returns the named role
+func (a *AdminClient) GetRole(ctx context.Context, name string) (*Role, error) {
+	if err := ValidateDocumentID(name); err != nil {
+		return nil, err
+	}
+	url, err := a.url("/roles/%s", name)
+	if err != nil {
+		return nil, err
+	}
+
+	var role Role
+	if err := a.client.doJSON(ctx, "GET", url, nil, &role, http.StatusOK, "get role"); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// CreateRole defines a new role
+func (a *AdminClient) CreateRole(ctx context.Context, role Role) error {
+	if err := validateRole(role); err != nil {
+		return err
+	}
+	url, err := a.url("/roles")
+	if err != nil {
+		return err
+	}
+	return a.client.doJSON(ctx, "POST", url, role, nil, http.StatusCreated, "create role")
+}
+
+// UpdateRole replaces the description and permissions of an existing role
+func (a *AdminClient) UpdateRole(ctx context.Context, role Role) error {
+	if err := validateRole(role); err != nil {
+		return err
+	}
+	url, err := a.url("/roles/%s", role.Name)
+	if err != nil {
+		return err
+	}
+	return a.client.doJSON(ctx, "PUT", url, role, nil, http.StatusOK, "update role")
+}
+
+// DeleteRole removes a role. Tokens holding it lose its permissions.
+func (a *AdminClient) DeleteRole(ctx context.Context, name string) error {
+	if err := ValidateDocumentID(name); err != nil {
+		return err
+	}
+	url, err := a.url("/roles/%s", name)
+	if err != nil {
+		return err
+	}
+	return a.client.doJSON(ctx, "DELETE", url, nil, nil, http.StatusOK, "delete role")
+}
+
+// Grant gives a role access to a collection, replacing any access it
+// already had to that collection. Collections are named as on the server,
+// so the names reserved by ValidateCollectionName, such as the namespaced
+// collections of tenants, can be granted too.
+func (a *AdminClient) Grant(ctx context.Context, role, collection string, access Access) error {
+	if err := ValidateDocumentID(role); err != nil {
+		return err
+	}
+	if err := validatePermission(Permission{Collection: collection, Access: access}); err != nil {
+		return err
+	}
+	url, err := a.url("/roles/%s/permissions/%s", role, collection)
+	if err != nil {
+		return err
+	}
+
+	data := map[string]Access{"access": access}
+	return a.client.doJSON(ctx, "PUT", url, data, nil, http.StatusOK, "grant permission")
+}
+
+// Revoke removes a role's access to a collection
+func (a *AdminClient) Revoke(ctx context.Context, role, collection string) error {
+	if err := ValidateDocumentID(role); err != nil {
+		return err
+	}
+	if collection != "*" {
+		if err := validateCollectionName(collection); err != nil {
+			return err
+		}
+	}
+	url, err := a.url("/roles/%s/permissions/%s", role, collection)
+	if err != nil {
+		return err
+	}
+	return a.client.doJSON(ctx, "DELETE", url, nil, nil, http.StatusOK, "revoke permission")
+}
+
+// validateRole checks a role's name and permissions before sending it
+func validateRole(role Role) error {
+	if err := ValidateDocumentID(role.Name); err != nil {
+		return err
+	}
+	for _, p := range role.Permissions {
+		if err := validatePermission(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validatePermission(p Permission) error {
+	if p.Collection != "*" {
+		if err := validateCollectionName(p.Collection); err != nil {
+			return err
+		}
+	}
+	switch p.Access {
+	case AccessRead, AccessWrite, AccessAdmin:
+		return nil
+	}
+	return fmt.Errorf("unknown access level %q for %s", p.Access, p.Collection)
+}
+
+// Principal describes the identity behind a token and what it may do
+type Principal struct {
+	// Subject identifies the token's owner
+	Subject string `json:"subject"`
+	// Roles are the roles assigned to the token
+	Roles []string `json:"roles"`
+	// Permissions are the effective permissions of all its roles combined
+	Permissions []Permission `json:"permissions"`
+}
+
+// Can reports whether the principal has at least access to collection
+func (p *Principal) Can(collection string, access Access) bool {
+	rank := map[Access]int{AccessRead: 1, AccessWrite: 2, AccessAdmin: 3}
+	for _, perm := range p.Permissions {
+		if (perm.Collection == collection || perm.Collection == "*") && rank[perm.Access] >= rank[access] {
+			return true
+		}
+	}
+	return false
+}
+
+// WhoAmI returns the identity and effective permissions of the client's
+// token. On namespaced clients only permissions on the namespace's
+// collections are reported, under their local names.
+func (c *Client) WhoAmI(ctx context.Context) (*Principal, error) {
+	url := fmt.Sprintf("%s/api/v1/whoami", c.BaseURL)
+
+	var principal Principal
+	if err := c.doJSON(ctx, "GET", url, nil, &principal, http.StatusOK, "get principal"); err != nil {
+		return nil, err
+	}
+
+	if c.namespace != "" {
+		permissions := principal.Permissions[:0]
+		for _, p := range principal.Permissions {
+			local := c.localCollectionName(p.Collection)
+			if p.Collection != "*" && local == p.Collection {
+				continue
+			}
+			p.Collection = local
+			permissions = append(permissions, p)
+		}
+		principal.Permissions = permissions
+	}
+	return &principal, nil
+}
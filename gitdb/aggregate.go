@@ -0,0 +1,248 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Pipeline is a sequence of aggregation stages such as $match, $group and
+// $sort
+type Pipeline []Document
+
+// FieldCount is the number of documents sharing one value of a field
+type FieldCount struct {
+	Value interface{} `json:"_id"`
+	Count int         `json:"count"`
+}
+
+// Aggregate runs an aggregation pipeline on a collection. Field aliases are
+// not applied inside pipeline stages; use stored field names. On namespaced
+// clients the collections named by $lookup, $graphLookup, $unionWith, $out
+// and $merge stages are resolved in the namespace too.
+func (c *Client) Aggregate(ctx context.Context, collection string, pipeline Pipeline) ([]Document, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return nil, err
+	}
+	if c.namespace != "" {
+		if pipeline, err = c.namespacePipeline(pipeline); err != nil {
+			return nil, err
+		}
+	}
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/documents/aggregate", c.BaseURL, name)
+
+	data := map[string]interface{}{"pipeline": pipeline}
+
+	var results []Document
+	if err := c.doJSON(ctx, "POST", url, data, &results, http.StatusOK, "aggregate documents"); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// namespacePipeline returns a copy of pipeline with the collections its
+// stages name resolved through collectionName
+func (c *Client) namespacePipeline(pipeline Pipeline) (Pipeline, error) {
+	stages := make([]interface{}, len(pipeline))
+	for i, stage := range pipeline {
+		stages[i] = stage
+	}
+	resolved, err := c.namespaceStages(stages)
+	if err != nil {
+		return nil, err
+	}
+	out := make(Pipeline, len(resolved))
+	for i, stage := range resolved {
+		out[i] = stage.(Document)
+	}
+	return out, nil
+}
+
+// namespaceStages resolves the collections named by each stage, recursing
+// into the sub-pipelines of $lookup, $unionWith and $facet
+func (c *Client) namespaceStages(stages []interface{}) ([]interface{}, error) {
+	out := make([]interface{}, len(stages))
+	for i, raw := range stages {
+		stage, ok := asMap(raw)
+		if !ok {
+			return nil, fmt.Errorf("invalid pipeline: stage %d is not a document", i)
+		}
+		resolved := make(Document, len(stage))
+		for op, arg := range stage {
+			var err error
+			switch op {
+			case "$lookup", "$graphLookup":
+				arg, err = c.namespaceStageArg(arg, "from")
+			case "$unionWith", "$out":
+				arg, err = c.namespaceStageArg(arg, "coll")
+			case "$merge":
+				arg, err = c.namespaceStageArg(arg, "into")
+			case "$facet":
+				arg, err = c.namespaceFacets(arg)
+			}
+			if err != nil {
+				return nil, err
+			}
+			resolved[op] = arg
+		}
+		out[i] = resolved
+	}
+	return out, nil
+}
+
+// namespaceStageArg resolves the collection of a stage whose argument is
+// either the collection name or a document naming it in field, along with
+// a sub-pipeline if it has one
+func (c *Client) namespaceStageArg(arg interface{}, field string) (interface{}, error) {
+	if name, ok := arg.(string); ok {
+		return c.collectionName(name)
+	}
+	spec, ok := asMap(arg)
+	if !ok {
+		return arg, nil
+	}
+	resolved := make(Document, len(spec))
+	for key, value := range spec {
+		resolved[key] = value
+	}
+	if name, ok := spec[field].(string); ok {
+		name, err := c.collectionName(name)
+		if err != nil {
+			return nil, err
+		}
+		resolved[field] = name
+	}
+	if sub, ok := asStages(spec["pipeline"]); ok {
+		stages, err := c.namespaceStages(sub)
+		if err != nil {
+			return nil, err
+		}
+		resolved["pipeline"] = stages
+	}
+	return resolved, nil
+}
+
+func (c *Client) namespaceFacets(arg interface{}) (interface{}, error) {
+	facets, ok := asMap(arg)
+	if !ok {
+		return arg, nil
+	}
+	resolved := make(Document, len(facets))
+	for name, value := range facets {
+		sub, ok := asStages(value)
+		if !ok {
+			resolved[name] = value
+			continue
+		}
+		stages, err := c.namespaceStages(sub)
+		if err != nil {
+			return nil, err
+		}
+		resolved[name] = stages
+	}
+	return resolved, nil
+}
+
+func asStages(v interface{}) ([]interface{}, bool) {
+	switch v := v.(type) {
+	case []interface{}:
+		return v, true
+	case Pipeline:
+		stages := make([]interface{}, len(v))
+		for i, stage := range v {
+			stages[i] = stage
+		}
+		return stages, true
+	case []Document:
+		stages := make([]interface{}, len(v))
+		for i, stage := range v {
+			stages[i] = stage
+		}
+		return stages, true
+	case []map[string]interface{}:
+		stages := make([]interface{}, len(v))
+		for i, stage := range v {
+			stages[i] = stage
+		}
+		return stages, true
+	}
+	return nil, false
+}
+
+// GroupCount counts the documents matching query for each distinct value of
+// field, most frequent first
+func (c *Client) GroupCount(ctx context.Context, collection, field string, query Query) ([]FieldCount, error) {
+	if err := ValidateFieldPath(field); err != nil {
+		return nil, err
+	}
+
+	pipeline := Pipeline{
+		{"$match": c.matchQuery(collection, query)},
+		{"$group": Document{"_id": "$" + c.storedField(collection, field), "count": Document{"$sum": 1}}},
+		{"$sort": Document{"count": -1}},
+	}
+
+	results, err := c.Aggregate(ctx, collection, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]FieldCount, 0, len(results))
+	for _, result := range results {
+		count, _ := result["count"].(float64)
+		counts = append(counts, FieldCount{Value: result["_id"], Count: int(count)})
+	}
+
+	return counts, nil
+}
+
+// SumField sums a numeric field over the documents matching query
+func (c *Client) SumField(ctx context.Context, collection, field string, query Query) (float64, error) {
+	return c.accumulate(ctx, collection, field, query, "$sum")
+}
+
+// AvgField averages a numeric field over the documents matching query. It
+// returns 0 when no document matches.
+func (c *Client) AvgField(ctx context.Context, collection, field string, query Query) (float64, error) {
+	return c.accumulate(ctx, collection, field, query, "$avg")
+}
+
+// accumulate runs a single-group pipeline applying accumulator to field
+func (c *Client) accumulate(ctx context.Context, collection, field string, query Query, accumulator string) (float64, error) {
+	if err := ValidateFieldPath(field); err != nil {
+		return 0, err
+	}
+
+	pipeline := Pipeline{
+		{"$match": c.matchQuery(collection, query)},
+		{"$group": Document{"_id": nil, "value": Document{accumulator: "$" + c.storedField(collection, field)}}},
+	}
+
+	results, err := c.Aggregate(ctx, collection, pipeline)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(results) == 0 {
+		return 0, nil
+	}
+
+	value, ok := results[0]["value"].(float64)
+	if !ok && results[0]["value"] != nil {
+		return 0, fmt.Errorf("unexpected %s result: %v", accumulator, results[0]["value"])
+	}
+
+	return value, nil
+}
+
+// matchQuery prepares a query for a $match stage, leaving out soft-deleted
+// documents
+func (c *Client) matchQuery(collection string, query Query) Query {
+	if query == nil {
+		query = Query{}
+	}
+	return c.encodeQuery(collection, c.liveQuery(collection, query))
+}
@@ -0,0 +1,221 @@
+package gitdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// FieldAliases maps field names as stored on the server to the names the
+// application uses, e.g. {"user_name": "userName"}. Aliases apply to
+// top-level fields and to the first segment of dotted paths.
+type FieldAliases map[string]string
+
+// SetFieldAliases registers the alias table for a collection. Documents read
+// from the collection have their stored field names replaced by the aliases,
+// and documents, updates and queries sent to it are translated back to the
+// stored names. This allows gradual schema renames without rewriting stored
+// data. Passing nil removes the table.
+func (c *Client) SetFieldAliases(collection string, aliases FieldAliases) {
+	r := c.registry()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if aliases == nil {
+		delete(r.aliases, collection)
+		return
+	}
+
+	table := make(FieldAliases, len(aliases))
+	for stored, alias := range aliases {
+		table[stored] = alias
+	}
+	r.aliases[collection] = table
+}
+
+// FieldAliases returns a copy of the alias table registered for a collection
+func (c *Client) FieldAliases(collection string) FieldAliases {
+	aliases := c.fieldAliases(collection)
+	if aliases == nil {
+		return nil
+	}
+
+	table := make(FieldAliases, len(aliases))
+	for stored, alias := range aliases {
+		table[stored] = alias
+	}
+	return table
+}
+
+// Decode decodes the document into v, honouring json struct tags
+func (d Document) Decode(v interface{}) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("failed to marshal document: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode document: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) fieldAliases(collection string) FieldAliases {
+	if c.collections == nil {
+		return nil
+	}
+	c.collections.mu.RLock()
+	defer c.collections.mu.RUnlock()
+	return c.collections.aliases[collection]
+}
+
+// encodeDocument drops a document's computed fields, passes it through the
+// collection's codec and translates its aliased field names to stored names
+func (c *Client) encodeDocument(collection string, doc Document) (Document, error) {
+	doc, err := c.encodeCodec(collection, c.stripComputed(collection, doc))
+	if err != nil {
+		return nil, err
+	}
+	aliases := c.fieldAliases(collection)
+	if len(aliases) == 0 || doc == nil {
+		return doc, nil
+	}
+	return Document(renameFields(doc, invertAliases(aliases))), nil
+}
+
+// decodeDocument translates a document's stored field names to their
+// aliases, passes it back through the collection's codec, upgrades it to
+// the latest schema version and sets its computed fields
+func (c *Client) decodeDocument(collection string, doc Document) (Document, error) {
+	doc, _, err := c.decodeStoredDocument(collection, doc)
+	return doc, err
+}
+
+// decodeStoredDocument is decodeDocument, also reporting whether a
+// migration upgraded the document
+func (c *Client) decodeStoredDocument(collection string, doc Document) (Document, bool, error) {
+	aliases := c.fieldAliases(collection)
+	if len(aliases) > 0 && doc != nil {
+		doc = Document(renameFields(doc, aliases))
+	}
+	doc, err := c.decodeCodec(collection, doc)
+	if err != nil {
+		return nil, false, err
+	}
+	doc, migrated := c.migrateDocument(collection, doc)
+	return c.computeFields(collection, doc), migrated, nil
+}
+
+// decodeDocuments applies decodeDocument to each document in place
+func (c *Client) decodeDocuments(collection string, docs []Document) error {
+	for i, doc := range docs {
+		decoded, err := c.decodeDocument(collection, doc)
+		if err != nil {
+			return err
+		}
+		docs[i] = decoded
+	}
+	return nil
+}
+
+// encodeQuery translates aliased field names in a query, descending into
+// logical operators
+func (c *Client) encodeQuery(collection string, query Query) Query {
+	aliases := c.fieldAliases(collection)
+	if len(aliases) == 0 || query == nil {
+		return query
+	}
+	return Query(renameQuery(query, invertAliases(aliases)))
+}
+
+// encodeUpdate translates aliased field names in an update, including the
+// fields named inside update operators such as $set, and drops computed
+// fields
+func (c *Client) encodeUpdate(collection string, update Update) Update {
+	aliases := c.fieldAliases(collection)
+	computed := c.computedFields(collection)
+	if (len(aliases) == 0 && len(computed) == 0) || update == nil {
+		return update
+	}
+
+	stored := invertAliases(aliases)
+	renamed := make(Update, len(update))
+	for key, value := range c.stripComputed(collection, update) {
+		if fields, ok := asMap(value); ok && strings.HasPrefix(key, "$") {
+			renamed[key] = renameFields(c.stripComputed(collection, fields), stored)
+			continue
+		}
+		renamed[renamePath(key, stored)] = value
+	}
+	return renamed
+}
+
+// storedField translates an aliased field path to its stored name
+func (c *Client) storedField(collection, field string) string {
+	aliases := c.fieldAliases(collection)
+	if len(aliases) == 0 {
+		return field
+	}
+	return renamePath(field, invertAliases(aliases))
+}
+
+func renameQuery(query map[string]interface{}, names map[string]string) map[string]interface{} {
+	renamed := make(map[string]interface{}, len(query))
+	for key, value := range query {
+		switch key {
+		case "$and", "$or", "$nor":
+			if clauses, ok := value.([]interface{}); ok {
+				out := make([]interface{}, len(clauses))
+				for i, clause := range clauses {
+					if m, ok := asMap(clause); ok {
+						out[i] = renameQuery(m, names)
+					} else {
+						out[i] = clause
+					}
+				}
+				renamed[key] = out
+				continue
+			}
+			if clauses, ok := value.([]map[string]interface{}); ok {
+				out := make([]map[string]interface{}, len(clauses))
+				for i, clause := range clauses {
+					out[i] = renameQuery(clause, names)
+				}
+				renamed[key] = out
+				continue
+			}
+			renamed[key] = value
+		default:
+			renamed[renamePath(key, names)] = value
+		}
+	}
+	return renamed
+}
+
+func renameFields(doc map[string]interface{}, names map[string]string) map[string]interface{} {
+	renamed := make(map[string]interface{}, len(doc))
+	for key, value := range doc {
+		renamed[renamePath(key, names)] = value
+	}
+	return renamed
+}
+
+// renamePath renames the first segment of a dotted field path
+func renamePath(path string, names map[string]string) string {
+	head, rest, dotted := strings.Cut(path, ".")
+	name, ok := names[head]
+	if !ok {
+		return path
+	}
+	if dotted {
+		return name + "." + rest
+	}
+	return name
+}
+
+func invertAliases(aliases FieldAliases) map[string]string {
+	inverted := make(map[string]string, len(aliases))
+	for stored, alias := range aliases {
+		inverted[alias] = stored
+	}
+	return inverted
+}
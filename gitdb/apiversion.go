@@ -0,0 +1,187 @@
+package gitdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// API versions
+const (
+	APIv1 = "v1"
+	APIv2 = "v2"
+)
+
+// apiNegotiation caches the API version negotiated with the server
+type apiNegotiation struct {
+	mu      sync.Mutex
+	version string
+}
+
+// WithAPINegotiation makes the client ask the server which API versions it
+// serves, on first use, and send the operations that have a /api/v2
+// endpoint there when it is available, so application code keeps working
+// unchanged while servers are upgraded. Servers without version discovery
+// are spoken to in v1.
+//
+// The v2 endpoints differ from v1 in two ways the client hides: responses
+// are wrapped in a {"data": ...} envelope, with errors as {"error":
+// {"code", "message"}}, and queries are sent as a "filter" field.
+func WithAPINegotiation() Option {
+	return func(o *options) {
+		o.apiNegotiation = true
+	}
+}
+
+// apiV2Operations lists the operations with a v2 endpoint, mapped to
+// whether their request body carries a query to be sent as "filter"
+var apiV2Operations = map[string]bool{
+	"list collections":  false,
+	"create collection": false,
+	"delete collection": false,
+	"insert document":   false,
+	"find document":     false,
+	"update document":   false,
+	"delete document":   false,
+	"find documents":    true,
+	"count documents":   true,
+	"update documents":  true,
+	"delete documents":  true,
+}
+
+// APIVersion returns the API version the client speaks for the operations
+// that have a v2 endpoint, negotiating it with the server if needed
+func (c *Client) APIVersion(ctx context.Context) (string, error) {
+	state := c.shared()
+	if state.api == nil {
+		return APIv1, nil
+	}
+
+	state.api.mu.Lock()
+	defer state.api.mu.Unlock()
+	if state.api.version != "" {
+		return state.api.version, nil
+	}
+
+	var result struct {
+		Versions []string `json:"versions"`
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", c.BaseURL+"/api/versions", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.do("get api versions", req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get api versions: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return "", fmt.Errorf("failed to decode api versions: %w", err)
+		}
+	case http.StatusNotFound:
+		// Predates version discovery
+	default:
+		return "", fmt.Errorf("failed to get api versions: %w", newAPIError(resp))
+	}
+
+	state.api.version = APIv1
+	for _, version := range result.Versions {
+		if version == APIv2 {
+			state.api.version = APIv2
+		}
+	}
+	return state.api.version, nil
+}
+
+// apiVersionRequest moves req to the v2 endpoint of op when the server
+// serves it, translating the body, and reports whether it did
+func (c *Client) apiVersionRequest(op string, req *http.Request) (bool, error) {
+	filter, ok := apiV2Operations[op]
+	if !ok || c.shared().api == nil {
+		return false, nil
+	}
+	version, err := c.APIVersion(req.Context())
+	if err != nil || version != APIv2 {
+		return false, err
+	}
+
+	req.URL.Path = strings.Replace(req.URL.Path, "/api/v1/", "/api/v2/", 1)
+	if !filter || req.Body == nil || req.GetBody == nil {
+		return true, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return false, err
+	}
+	data, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return false, err
+	}
+
+	var translated interface{}
+	if strings.HasSuffix(req.URL.Path, "/update-many") || strings.HasSuffix(req.URL.Path, "/documents/query") {
+		// Bodies with a query alongside other fields rename it
+		var fields map[string]interface{}
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return false, fmt.Errorf("failed to translate request: %w", err)
+		}
+		fields["filter"] = fields["query"]
+		delete(fields, "query")
+		translated = fields
+	} else {
+		translated = map[string]json.RawMessage{"filter": data}
+	}
+
+	data, err = CanonicalJSON(translated)
+	if err != nil {
+		return false, fmt.Errorf("failed to translate request: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.ContentLength = int64(len(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return true, nil
+}
+
+// apiVersionResponse unwraps a v2 response into its v1 shape
+func (c *Client) apiVersionResponse(resp *http.Response) (*http.Response, error) {
+	data, err := io.ReadAll(c.limitBody(resp.Body))
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope struct {
+		Data  json.RawMessage `json:"data"`
+		Error *struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			return nil, fmt.Errorf("failed to decode v2 response: %w", err)
+		}
+	}
+
+	switch {
+	case envelope.Error != nil:
+		data = []byte(envelope.Error.Message)
+	case envelope.Data != nil:
+		data = envelope.Data
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	resp.ContentLength = int64(len(data))
+	return resp, nil
+}
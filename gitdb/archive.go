@@ -0,0 +1,96 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ArchiveOptions selects where an archived collection is kept
+type ArchiveOptions struct {
+	// Branch is the cold-storage branch in the same repository,
+	// gitdb-archive by default
+	Branch string
+	// Repo moves the collection to another repository instead, as
+	// "owner/repo"; the token must be able to push to it
+	Repo string
+}
+
+// ArchivedCollection describes a collection held in cold storage
+type ArchivedCollection struct {
+	Name       string    `json:"name"`
+	Branch     string    `json:"branch"`
+	Repo       string    `json:"repo"`
+	Count      int       `json:"count"`
+	Size       int64     `json:"size"`
+	Compressed int64     `json:"compressed"`
+	ArchivedAt time.Time `json:"archivedAt"`
+}
+
+// ArchiveCollection moves a collection out of the working branch into
+// compressed cold storage, keeping the hot working set small. The
+// collection can no longer be queried until UnarchiveCollection brings it
+// back.
+func (c *Client) ArchiveCollection(ctx context.Context, name string, opts ArchiveOptions) (*ArchivedCollection, error) {
+	resolved, err := c.collectionName(name)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Repo != "" && strings.Count(opts.Repo, "/") != 1 {
+		return nil, fmt.Errorf("archive repo must be owner/repo, got %q", opts.Repo)
+	}
+
+	request := map[string]interface{}{}
+	if opts.Branch != "" {
+		request["branch"] = opts.Branch
+	}
+	if opts.Repo != "" {
+		request["repo"] = opts.Repo
+	}
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/archive", c.BaseURL, resolved)
+
+	var archived ArchivedCollection
+	if err := c.doJSON(ctx, "POST", url, request, &archived, http.StatusOK, "archive collection"); err != nil {
+		return nil, err
+	}
+	archived.Name = c.localCollectionName(archived.Name)
+	return &archived, nil
+}
+
+// UnarchiveCollection restores an archived collection to the working
+// branch
+func (c *Client) UnarchiveCollection(ctx context.Context, name string) error {
+	resolved, err := c.collectionName(name)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/api/v1/archives/%s/restore", c.BaseURL, resolved)
+	return c.doJSON(ctx, "POST", url, nil, nil, http.StatusOK, "unarchive collection")
+}
+
+// ListArchivedCollections lists the collections held in cold storage
+func (c *Client) ListArchivedCollections(ctx context.Context) ([]ArchivedCollection, error) {
+	url := fmt.Sprintf("%s/api/v1/archives", c.BaseURL)
+
+	var archived []ArchivedCollection
+	if err := c.doJSON(ctx, "GET", url, nil, &archived, http.StatusOK, "list archived collections"); err != nil {
+		return nil, err
+	}
+
+	if c.namespace != "" {
+		prefix := c.namespace + namespaceSeparator
+		filtered := archived[:0]
+		for _, a := range archived {
+			if strings.HasPrefix(a.Name, prefix) {
+				a.Name = c.localCollectionName(a.Name)
+				filtered = append(filtered, a)
+			}
+		}
+		archived = filtered
+	}
+	return archived, nil
+}
@@ -0,0 +1,123 @@
+package gitdb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// ErrReadOnly is returned for writes attempted through an anonymous client
+var ErrReadOnly = errors.New("anonymous client is read-only")
+
+// NewPublicClient creates a client without credentials for reading public
+// repositories. It sends no Authorization header and refuses every
+// operation that could modify data with ErrReadOnly, so open-data consumers
+// need no token.
+func NewPublicClient(owner, repo string, opts ...Option) *Client {
+	return NewClient("", owner, repo, opts...)
+}
+
+// Anonymous reports whether the client has no token and is read-only
+func (c *Client) Anonymous() bool {
+	return c.token() == ""
+}
+
+// SetToken replaces the client's token at runtime, for example after a
+// scheduled credential rotation. It is safe to call while requests are in
+// flight and applies to every client derived from this one; requests
+// already sent keep the token they were sent with.
+func (c *Client) SetToken(token string) {
+	state := c.shared()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.token = token
+	state.tokenSet = true
+}
+
+// OnAuthFailure registers a callback that supplies a fresh token when the
+// server rejects a request with 401 Unauthorized. The new token is installed
+// with SetToken and the failed request is retried once. Concurrent failures
+// share one call to fn. Passing nil removes the callback.
+func (c *Client) OnAuthFailure(fn func(ctx context.Context) (string, error)) {
+	state := c.shared()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.authRefresh = fn
+}
+
+// token returns the client's current token
+func (c *Client) token() string {
+	state := c.shared()
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+	if state.tokenSet {
+		return state.token
+	}
+	return c.Token
+}
+
+// authorize sets the Authorization header of req from the current token and
+// returns the token sent. Anonymous clients send no header and may only
+// read.
+func (c *Client) authorize(op string, req *http.Request) (string, error) {
+	token := c.token()
+	if token == "" {
+		if !readOperations[op] {
+			return "", ErrReadOnly
+		}
+		req.Header.Del("Authorization")
+		return "", nil
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return token, nil
+}
+
+// refreshToken obtains a fresh token after sent was rejected, unless
+// another request already replaced it. It reports false when no refresh
+// callback is registered or the callback failed.
+func (c *Client) refreshToken(ctx context.Context, sent string) (string, bool) {
+	state := c.shared()
+	state.mu.RLock()
+	refresh := state.authRefresh
+	state.mu.RUnlock()
+	if refresh == nil {
+		return "", false
+	}
+
+	state.refreshMu.Lock()
+	defer state.refreshMu.Unlock()
+
+	if current := c.token(); current != sent {
+		return current, true
+	}
+	token, err := refresh(ctx)
+	if err != nil || token == "" {
+		return "", false
+	}
+	c.SetToken(token)
+	return token, true
+}
+
+// retryUnauthorized sends req once more with a fresh token if resp is a 401
+// and a refresh callback supplies one, otherwise it returns resp unchanged
+func (c *Client) retryUnauthorized(op string, req *http.Request, sent string, resp *http.Response) (*http.Response, error) {
+	if resp.StatusCode != http.StatusUnauthorized || sent == "" {
+		return resp, nil
+	}
+
+	token, ok := c.refreshToken(req.Context(), sent)
+	if !ok {
+		return resp, nil
+	}
+	next, err := rewindRequest(req)
+	if err != nil {
+		return resp, nil
+	}
+
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	next.Header.Set("Authorization", "Bearer "+token)
+	return c.doAttempts(op, next)
+}
@@ -0,0 +1,367 @@
+// Package bench drives configurable read/write workloads against a GitDB
+// server and reports throughput and latency percentiles.
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb"
+)
+
+// Operation names used in a Mix and in reports
+const (
+	OpInsert   = "insert"
+	OpFindByID = "find-by-id"
+	OpFind     = "find"
+	OpUpdate   = "update"
+	OpCount    = "count"
+)
+
+// Mix weights the operations of a workload. Weights are relative, so
+// {OpInsert: 1, OpFindByID: 3} issues three reads per write.
+type Mix map[string]int
+
+// DefaultMix is a read-heavy workload
+var DefaultMix = Mix{OpInsert: 10, OpFindByID: 60, OpFind: 15, OpUpdate: 15}
+
+// ParseMix parses a mix such as "insert=1,find-by-id=3"
+func ParseMix(s string) (Mix, error) {
+	mix := make(Mix)
+	for _, part := range strings.Split(s, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid mix entry %q", part)
+		}
+		var w int
+		if _, err := fmt.Sscanf(weight, "%d", &w); err != nil || w < 0 {
+			return nil, fmt.Errorf("invalid weight for %s: %q", name, weight)
+		}
+		mix[name] = w
+	}
+	return mix, mix.validate()
+}
+
+func (m Mix) validate() error {
+	total := 0
+	for name, w := range m {
+		switch name {
+		case OpInsert, OpFindByID, OpFind, OpUpdate, OpCount:
+		default:
+			return fmt.Errorf("unknown operation %q", name)
+		}
+		total += w
+	}
+	if total == 0 {
+		return fmt.Errorf("mix has no operations")
+	}
+	return nil
+}
+
+// Config describes a workload
+type Config struct {
+	// Collection receives the benchmark documents. It is created if missing.
+	Collection string
+	// Concurrency is the number of workers issuing requests
+	Concurrency int
+	// Duration bounds the run; Requests, when set, stops it earlier
+	Duration time.Duration
+	Requests int
+	// DocumentSize is the approximate payload size of each document in bytes
+	DocumentSize int
+	// Seed is the number of documents inserted before measuring starts, so
+	// reads have something to hit
+	Seed int
+	Mix  Mix
+}
+
+func (cfg *Config) defaults() {
+	if cfg.Collection == "" {
+		cfg.Collection = "bench"
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 8
+	}
+	if cfg.Duration <= 0 && cfg.Requests <= 0 {
+		cfg.Duration = 30 * time.Second
+	}
+	if cfg.DocumentSize <= 0 {
+		cfg.DocumentSize = 256
+	}
+	if cfg.Seed <= 0 {
+		cfg.Seed = 100
+	}
+	if cfg.Mix == nil {
+		cfg.Mix = DefaultMix
+	}
+}
+
+// OperationReport summarizes one operation of a run
+type OperationReport struct {
+	Name   string
+	Count  int
+	Errors int
+	P50    time.Duration
+	P90    time.Duration
+	P99    time.Duration
+	Max    time.Duration
+}
+
+// Report is the outcome of a run
+type Report struct {
+	Elapsed    time.Duration
+	Total      int
+	Errors     int
+	Operations []OperationReport
+	// FirstError is the first failure seen, for diagnosis
+	FirstError error
+}
+
+// Throughput returns completed operations per second
+func (r *Report) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Total) / r.Elapsed.Seconds()
+}
+
+// Print writes a human readable summary of the report
+func (r *Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "%d operations in %s (%.1f ops/s), %d errors\n\n",
+		r.Total, r.Elapsed.Round(time.Millisecond), r.Throughput(), r.Errors)
+	fmt.Fprintf(w, "%-12s %8s %7s %10s %10s %10s %10s\n", "OPERATION", "COUNT", "ERRORS", "P50", "P90", "P99", "MAX")
+	for _, op := range r.Operations {
+		fmt.Fprintf(w, "%-12s %8d %7d %10s %10s %10s %10s\n", op.Name, op.Count, op.Errors,
+			round(op.P50), round(op.P90), round(op.P99), round(op.Max))
+	}
+	if r.FirstError != nil {
+		fmt.Fprintf(w, "\nfirst error: %v\n", r.FirstError)
+	}
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(10 * time.Microsecond)
+}
+
+// Run executes the workload described by cfg and returns its report. Run
+// stops when the duration elapses, the request count is reached or ctx is
+// cancelled.
+func Run(ctx context.Context, client *gitdb.Client, cfg Config) (*Report, error) {
+	cfg.defaults()
+	if err := cfg.Mix.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := client.CreateCollection(ctx, cfg.Collection); err != nil {
+		if _, listErr := client.Count(ctx, cfg.Collection, gitdb.Query{}); listErr != nil {
+			return nil, fmt.Errorf("failed to prepare collection: %w", err)
+		}
+	}
+
+	r := &runner{client: client, cfg: cfg, samples: make(map[string]*samples)}
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < cfg.Seed; i++ {
+		id, err := client.Insert(ctx, cfg.Collection, r.document(rng))
+		if err != nil {
+			return nil, fmt.Errorf("failed to seed documents: %w", err)
+		}
+		r.ids = append(r.ids, id)
+	}
+
+	if cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r.work(ctx, rand.New(rand.NewSource(seed)))
+		}(rng.Int63())
+	}
+	wg.Wait()
+
+	return r.report(time.Since(start)), nil
+}
+
+type samples struct {
+	latencies []time.Duration
+	errors    int
+}
+
+type runner struct {
+	client *gitdb.Client
+	cfg    Config
+
+	mu       sync.Mutex
+	ids      []string
+	issued   int
+	samples  map[string]*samples
+	firstErr error
+}
+
+func (r *runner) work(ctx context.Context, rng *rand.Rand) {
+	for ctx.Err() == nil {
+		if !r.claim() {
+			return
+		}
+		op := r.pick(rng)
+		start := time.Now()
+		err := r.execute(ctx, op, rng)
+		if ctx.Err() != nil {
+			// Cut short by the deadline; not a sample
+			return
+		}
+		r.record(op, time.Since(start), err)
+	}
+}
+
+// claim reserves one request against the configured request count
+func (r *runner) claim() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cfg.Requests > 0 && r.issued >= r.cfg.Requests {
+		return false
+	}
+	r.issued++
+	return true
+}
+
+func (r *runner) pick(rng *rand.Rand) string {
+	total := 0
+	for _, w := range r.cfg.Mix {
+		total += w
+	}
+	n := rng.Intn(total)
+
+	names := make([]string, 0, len(r.cfg.Mix))
+	for name := range r.cfg.Mix {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if n < r.cfg.Mix[name] {
+			return name
+		}
+		n -= r.cfg.Mix[name]
+	}
+	return names[len(names)-1]
+}
+
+func (r *runner) execute(ctx context.Context, op string, rng *rand.Rand) error {
+	collection := r.cfg.Collection
+	switch op {
+	case OpInsert:
+		id, err := r.client.Insert(ctx, collection, r.document(rng))
+		if err == nil {
+			r.mu.Lock()
+			r.ids = append(r.ids, id)
+			r.mu.Unlock()
+		}
+		return err
+	case OpFindByID:
+		_, err := r.client.FindByID(ctx, collection, r.randomID(rng))
+		return err
+	case OpFind:
+		_, err := r.client.Find(ctx, collection, gitdb.Query{"bucket": rng.Intn(100)})
+		return err
+	case OpUpdate:
+		_, err := r.client.Update(ctx, collection, r.randomID(rng), gitdb.Update{
+			"$set": map[string]interface{}{"bucket": rng.Intn(100), "updatedAt": time.Now().UnixMilli()},
+		})
+		return err
+	case OpCount:
+		_, err := r.client.Count(ctx, collection, gitdb.Query{"bucket": rng.Intn(100)})
+		return err
+	}
+	return fmt.Errorf("unknown operation %q", op)
+}
+
+func (r *runner) randomID(rng *rand.Rand) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ids[rng.Intn(len(r.ids))]
+}
+
+const payloadAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// document builds a document whose payload is roughly DocumentSize bytes
+func (r *runner) document(rng *rand.Rand) gitdb.Document {
+	payload := make([]byte, r.cfg.DocumentSize)
+	for i := range payload {
+		payload[i] = payloadAlphabet[rng.Intn(len(payloadAlphabet))]
+	}
+	return gitdb.Document{
+		"bucket":    rng.Intn(100),
+		"createdAt": time.Now().UnixMilli(),
+		"payload":   string(payload),
+	}
+}
+
+func (r *runner) record(op string, latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.samples[op]
+	if !ok {
+		s = &samples{}
+		r.samples[op] = s
+	}
+	s.latencies = append(s.latencies, latency)
+	if err != nil {
+		s.errors++
+		if r.firstErr == nil {
+			r.firstErr = fmt.Errorf("%s: %w", op, err)
+		}
+	}
+}
+
+func (r *runner) report(elapsed time.Duration) *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := &Report{Elapsed: elapsed, FirstError: r.firstErr}
+	for name, s := range r.samples {
+		sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+		report.Operations = append(report.Operations, OperationReport{
+			Name:   name,
+			Count:  len(s.latencies),
+			Errors: s.errors,
+			P50:    percentile(s.latencies, 0.50),
+			P90:    percentile(s.latencies, 0.90),
+			P99:    percentile(s.latencies, 0.99),
+			Max:    s.latencies[len(s.latencies)-1],
+		})
+		report.Total += len(s.latencies)
+		report.Errors += s.errors
+	}
+	sort.Slice(report.Operations, func(i, j int) bool {
+		return report.Operations[i].Name < report.Operations[j].Name
+	})
+	return report
+}
+
+// percentile returns the q-th percentile of sorted latencies
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(q*float64(len(sorted))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
@@ -0,0 +1,214 @@
+package gitdb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrObjectNotFound is returned by Bucket reads when an object is missing
+var ErrObjectNotFound = errors.New("object not found")
+
+// Object describes a blob stored in a Bucket
+type Object struct {
+	Name        string
+	Size        int64
+	ContentType string
+	Metadata    map[string]string
+	// ETag is the SHA-256 of the content, in hex
+	ETag     string
+	Modified time.Time
+}
+
+// PutOptions sets the properties of a stored object
+type PutOptions struct {
+	ContentType string
+	Metadata    map[string]string
+}
+
+// Bucket stores named blobs with metadata in a collection, behind an
+// S3-like Put/Get/List/Delete interface, for small objects such as avatars
+// or generated reports that benefit from git's history. Names may contain
+// slashes, and List filters them by prefix.
+//
+// Each object is kept as two documents written in one commit, one with its
+// properties and one with its content, so listing never transfers content.
+type Bucket struct {
+	client     *Client
+	collection string
+}
+
+// Bucket returns a blob store in collection
+func (c *Client) Bucket(collection string) *Bucket {
+	return &Bucket{client: c, collection: collection}
+}
+
+// objectIDs maps an object name onto the IDs of its documents
+func objectIDs(name string) (meta, content string) {
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(name))
+	return "o-" + encoded, "c-" + encoded
+}
+
+func validateObjectName(name string) error {
+	if name == "" || !utf8.ValidString(name) {
+		return &ValidationError{Kind: "object name", Name: name, Reason: "must be non-empty UTF-8"}
+	}
+	return nil
+}
+
+// Put stores data under name, replacing any existing object. opts may be
+// nil.
+func (b *Bucket) Put(ctx context.Context, name string, data []byte, opts *PutOptions) (*Object, error) {
+	if err := validateObjectName(name); err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = &PutOptions{}
+	}
+
+	sum := sha256.Sum256(data)
+	object := &Object{
+		Name:        name,
+		Size:        int64(len(data)),
+		ContentType: opts.ContentType,
+		Metadata:    opts.Metadata,
+		ETag:        hex.EncodeToString(sum[:]),
+		Modified:    time.Now().UTC().Truncate(time.Millisecond),
+	}
+	if object.ContentType == "" {
+		object.ContentType = "application/octet-stream"
+	}
+
+	metadata := make(map[string]interface{}, len(object.Metadata))
+	for key, value := range object.Metadata {
+		metadata[key] = value
+	}
+
+	metaID, contentID := objectIDs(name)
+	changes := []Change{
+		{Op: ChangeUpsert, ID: metaID, Document: Document{
+			"object":      true,
+			"name":        name,
+			"size":        object.Size,
+			"contentType": object.ContentType,
+			"metadata":    metadata,
+			"etag":        object.ETag,
+			"modified":    object.Modified.UnixMilli(),
+		}},
+		{Op: ChangeUpsert, ID: contentID, Document: Document{
+			"data": base64.StdEncoding.EncodeToString(data),
+		}},
+	}
+	if _, err := b.client.ApplyChanges(ctx, b.collection, changes); err != nil {
+		return nil, fmt.Errorf("failed to put object %s: %w", name, err)
+	}
+	return object, nil
+}
+
+// Get returns the content and properties of an object, or
+// ErrObjectNotFound
+func (b *Bucket) Get(ctx context.Context, name string) ([]byte, *Object, error) {
+	object, err := b.Stat(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, contentID := objectIDs(name)
+	docs, err := b.client.Find(ctx, b.collection, Query{"_id": contentID})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get object %s: %w", name, err)
+	}
+	if len(docs) == 0 {
+		return nil, nil, ErrObjectNotFound
+	}
+
+	encoded, _ := docs[0]["data"].(string)
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode object %s: %w", name, err)
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != object.ETag {
+		// Replaced between the two reads
+		return nil, nil, fmt.Errorf("failed to get object %s: object changed while reading", name)
+	}
+	return data, object, nil
+}
+
+// Stat returns the properties of an object without its content, or
+// ErrObjectNotFound
+func (b *Bucket) Stat(ctx context.Context, name string) (*Object, error) {
+	if err := validateObjectName(name); err != nil {
+		return nil, err
+	}
+
+	metaID, _ := objectIDs(name)
+	docs, err := b.client.Find(ctx, b.collection, Query{"_id": metaID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object %s: %w", name, err)
+	}
+	if len(docs) == 0 {
+		return nil, ErrObjectNotFound
+	}
+	return decodeObject(docs[0]), nil
+}
+
+// List returns the objects whose names start with prefix, sorted by name
+func (b *Bucket) List(ctx context.Context, prefix string) ([]Object, error) {
+	query := Query{"object": true}
+	if prefix != "" {
+		query["name"] = Query{"$gte": prefix, "$lt": prefix + string(utf8.MaxRune)}
+	}
+	docs, err := b.client.Find(ctx, b.collection, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	objects := make([]Object, len(docs))
+	for i, doc := range docs {
+		objects[i] = *decodeObject(doc)
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Name < objects[j].Name })
+	return objects, nil
+}
+
+// Delete removes an object. Deleting a missing object is not an error.
+func (b *Bucket) Delete(ctx context.Context, name string) error {
+	if err := validateObjectName(name); err != nil {
+		return err
+	}
+
+	metaID, contentID := objectIDs(name)
+	changes := []Change{{Op: ChangeDelete, ID: metaID}, {Op: ChangeDelete, ID: contentID}}
+	if _, err := b.client.ApplyChanges(ctx, b.collection, changes); err != nil {
+		return fmt.Errorf("failed to delete object %s: %w", name, err)
+	}
+	return nil
+}
+
+// decodeObject reads the properties of an object from its document
+func decodeObject(doc Document) *Object {
+	object := &Object{}
+	object.Name, _ = doc["name"].(string)
+	object.ContentType, _ = doc["contentType"].(string)
+	object.ETag, _ = doc["etag"].(string)
+	if size, ok := doc["size"].(float64); ok {
+		object.Size = int64(size)
+	}
+	if modified, ok := doc["modified"].(float64); ok {
+		object.Modified = time.UnixMilli(int64(modified)).UTC()
+	}
+	if metadata, ok := doc["metadata"].(map[string]interface{}); ok && len(metadata) > 0 {
+		object.Metadata = make(map[string]string, len(metadata))
+		for key, value := range metadata {
+			object.Metadata[key], _ = value.(string)
+		}
+	}
+	return object
+}
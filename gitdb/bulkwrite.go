@@ -0,0 +1,311 @@
+package gitdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// WriteModel is one operation of a BulkWrite: an InsertOneModel,
+// UpdateOneModel, UpdateManyModel, ReplaceOneModel, DeleteOneModel or
+// DeleteManyModel
+type WriteModel interface {
+	write(ctx context.Context, c *Client, collection string, result *bulkOpResult) error
+}
+
+// InsertOneModel inserts a document
+type InsertOneModel struct {
+	Document Document
+}
+
+// UpdateOneModel updates the document with ID
+type UpdateOneModel struct {
+	ID     string
+	Update Update
+}
+
+// UpdateManyModel updates the documents matching Filter
+type UpdateManyModel struct {
+	Filter Query
+	Update Update
+}
+
+// ReplaceOneModel replaces the document with ID whole. A missing document
+// matches nothing and is left missing unless Upsert is set, in which case
+// it is created. The replacement is prepared like an inserted document,
+// with the collection's timestamps, schema version and schema check. A
+// replacement that leaves the fields unchanged matches without modifying.
+// An existing document is replaced as FindOneAndReplace does, with a write
+// conditioned on it being unchanged since it was read, so a document
+// deleted meanwhile is not recreated unless Upsert is set.
+type ReplaceOneModel struct {
+	ID       string
+	Document Document
+	Upsert   bool
+}
+
+// DeleteOneModel deletes the document with ID. Deleting a missing
+// document counts nothing and is not an error.
+type DeleteOneModel struct {
+	ID string
+}
+
+// DeleteManyModel deletes the documents matching Filter
+type DeleteManyModel struct {
+	Filter Query
+}
+
+// BulkWriteOptions tunes BulkWrite
+type BulkWriteOptions struct {
+	// Unordered runs the operations concurrently, in no particular order,
+	// and carries on past failures. By default operations run one after
+	// the other and the first failure stops the rest.
+	Unordered bool
+	// Concurrency bounds the operations an unordered bulk write runs at
+	// once (default 8)
+	Concurrency int
+}
+
+// BulkWriteResult summarizes a bulk write. Operations that failed or did
+// not run contribute nothing to the counts.
+type BulkWriteResult struct {
+	InsertedCount int
+	MatchedCount  int
+	ModifiedCount int
+	DeletedCount  int
+	// UpsertedCount counts the documents ReplaceOneModels with Upsert
+	// created
+	UpsertedCount int
+	// InsertedIDs maps the index of each InsertOneModel that succeeded to
+	// the ID of its document
+	InsertedIDs map[int]string
+	// UpsertedIDs maps the index of each ReplaceOneModel that created its
+	// document to the document's ID
+	UpsertedIDs map[int]string
+	// Errors lists the operations that failed, in index order
+	Errors []*BulkWriteError
+}
+
+// BulkWriteError reports an operation of a bulk write that failed
+type BulkWriteError struct {
+	// Index is the position of the operation in the models passed to
+	// BulkWrite
+	Index int
+	Err   error
+}
+
+func (e *BulkWriteError) Error() string {
+	return fmt.Sprintf("operation %d: %v", e.Index, e.Err)
+}
+
+func (e *BulkWriteError) Unwrap() error {
+	return e.Err
+}
+
+// bulkOpResult is the outcome of a single operation of a bulk write
+type bulkOpResult struct {
+	insertedID string
+	inserted   int
+	matched    int
+	modified   int
+	deleted    int
+	upsertedID string
+	upserted   int
+}
+
+// BulkWrite runs a batch of mixed write operations against a collection.
+// Each operation goes through the same path as the corresponding client
+// method, so aliases, collection options and retries apply, and each
+// creates its own commit. The batch is not atomic: operations that ran
+// before a failure stay applied, and other writers can see and change the
+// collection between operations. Use ApplyChanges for upserts and deletes
+// that must land in one commit. The result is returned even when
+// operations fail; the error then wraps the first failure as a
+// *BulkWriteError.
+func (c *Client) BulkWrite(ctx context.Context, collection string, models []WriteModel, opts BulkWriteOptions) (*BulkWriteResult, error) {
+	if _, err := c.collectionName(collection); err != nil {
+		return nil, err
+	}
+	for i, model := range models {
+		if model == nil {
+			return nil, fmt.Errorf("invalid bulk write: operation %d is nil", i)
+		}
+	}
+
+	results := make([]*bulkOpResult, len(models))
+	errs := make([]error, len(models))
+	if opts.Unordered {
+		c.bulkWriteUnordered(ctx, collection, models, opts.Concurrency, results, errs)
+	} else {
+		for i, model := range models {
+			results[i] = &bulkOpResult{}
+			if errs[i] = model.write(ctx, c, collection, results[i]); errs[i] != nil {
+				break
+			}
+		}
+	}
+
+	result := &BulkWriteResult{InsertedIDs: make(map[int]string), UpsertedIDs: make(map[int]string)}
+	for i, r := range results {
+		if errs[i] != nil {
+			result.Errors = append(result.Errors, &BulkWriteError{Index: i, Err: errs[i]})
+			continue
+		}
+		if r == nil {
+			continue
+		}
+		result.InsertedCount += r.inserted
+		result.MatchedCount += r.matched
+		result.ModifiedCount += r.modified
+		result.DeletedCount += r.deleted
+		result.UpsertedCount += r.upserted
+		if r.inserted > 0 {
+			result.InsertedIDs[i] = r.insertedID
+		}
+		if r.upserted > 0 {
+			result.UpsertedIDs[i] = r.upsertedID
+		}
+	}
+	if len(result.Errors) > 0 {
+		return result, fmt.Errorf("failed to bulk write: %d of %d operations failed: %w", len(result.Errors), len(models), result.Errors[0])
+	}
+	return result, nil
+}
+
+// bulkWriteUnordered runs models with at most concurrency in flight
+func (c *Client) bulkWriteUnordered(ctx context.Context, collection string, models []WriteModel, concurrency int, results []*bulkOpResult, errs []error) {
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = &bulkOpResult{}
+				errs[i] = models[i].write(ctx, c, collection, results[i])
+			}
+		}()
+	}
+	for i := range models {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func (m InsertOneModel) write(ctx context.Context, c *Client, collection string, r *bulkOpResult) error {
+	id, err := c.Insert(ctx, collection, m.Document)
+	if err != nil {
+		return err
+	}
+	r.insertedID, r.inserted = id, 1
+	return nil
+}
+
+func (m UpdateOneModel) write(ctx context.Context, c *Client, collection string, r *bulkOpResult) error {
+	res, err := c.Update(ctx, collection, m.ID, m.Update)
+	if err != nil {
+		return err
+	}
+	r.matched, r.modified = res.MatchedCount, res.ModifiedCount
+	return nil
+}
+
+func (m UpdateManyModel) write(ctx context.Context, c *Client, collection string, r *bulkOpResult) error {
+	res, err := c.UpdateMany(ctx, collection, m.Filter, m.Update)
+	if err != nil {
+		return err
+	}
+	r.matched, r.modified = res.MatchedCount, res.ModifiedCount
+	return nil
+}
+
+func (m ReplaceOneModel) write(ctx context.Context, c *Client, collection string, r *bulkOpResult) error {
+	if err := validateDocumentFields(m.Document); err != nil {
+		return err
+	}
+	current, err := c.FindByID(ctx, collection, m.ID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if err == nil {
+		if same, err := sameFields(current, m.Document); err == nil && same {
+			r.matched = 1
+			return nil
+		}
+		_, err = c.FindOneAndReplace(ctx, collection, Query{"_id": m.ID}, m.Document, FindOneAndOptions{})
+		if err == nil {
+			r.matched, r.modified = 1, 1
+			return nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		// Deleted since it was read
+	}
+	if !m.Upsert {
+		return nil
+	}
+
+	doc, err := c.prepareInsert(collection, m.Document)
+	if err != nil {
+		return err
+	}
+	encoded, err := c.encodeDocument(collection, doc)
+	if err != nil {
+		return err
+	}
+	if _, err := c.ApplyChanges(ctx, collection, []Change{{Op: ChangeUpsert, ID: m.ID, Document: encoded}}); err != nil {
+		return err
+	}
+	r.upsertedID, r.upserted = m.ID, 1
+	return nil
+}
+
+// sameFields reports whether two documents hold the same fields, ignoring
+// the ID and metadata fields
+func sameFields(a, b Document) (bool, error) {
+	encoded := make([][]byte, 2)
+	for i, doc := range []Document{a, b} {
+		fields := make(Document, len(doc))
+		for key, value := range doc {
+			if !strings.HasPrefix(key, "_") {
+				fields[key] = value
+			}
+		}
+		data, err := CanonicalJSON(fields)
+		if err != nil {
+			return false, err
+		}
+		encoded[i] = data
+	}
+	return bytes.Equal(encoded[0], encoded[1]), nil
+}
+
+func (m DeleteOneModel) write(ctx context.Context, c *Client, collection string, r *bulkOpResult) error {
+	err := c.Delete(ctx, collection, m.ID)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	r.deleted = 1
+	return nil
+}
+
+func (m DeleteManyModel) write(ctx context.Context, c *Client, collection string, r *bulkOpResult) error {
+	n, err := c.DeleteMany(ctx, collection, m.Filter)
+	if err != nil {
+		return err
+	}
+	r.deleted = n
+	return nil
+}
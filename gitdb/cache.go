@@ -0,0 +1,137 @@
+package gitdb
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrCacheMiss is returned by Cache.Get when a key is missing or expired
+var ErrCacheMiss = errors.New("cache miss")
+
+// Cache is a small persistent key/value cache backed by a collection. It
+// implements the Get/Set/Delete shape shared by common Go cache
+// abstractions, so GitDB can act as a shared config store for small values.
+// Misses are reported with ErrCacheMiss, so adapting it to an interface
+// with its own miss error, such as autocert.Cache, takes a wrapper that
+// translates the error.
+//
+// Expired entries are ignored by Get and removed lazily; call Purge to sweep
+// them.
+type Cache struct {
+	client     *Client
+	collection string
+}
+
+// Cache returns a cache stored in collection
+func (c *Client) Cache(collection string) *Cache {
+	return &Cache{client: c, collection: collection}
+}
+
+// cacheID maps arbitrary keys onto valid document IDs
+func cacheID(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+// Get returns the value stored under key, or ErrCacheMiss
+func (ch *Cache) Get(ctx context.Context, key string) ([]byte, error) {
+	docs, err := ch.client.Find(ctx, ch.collection, Query{"_id": cacheID(key)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache key %s: %w", key, err)
+	}
+	if len(docs) == 0 {
+		return nil, ErrCacheMiss
+	}
+
+	doc := docs[0]
+	if expiresAt, ok := doc["expiresAt"].(float64); ok && int64(expiresAt) <= time.Now().UnixMilli() {
+		ch.client.DeleteMany(ctx, ch.collection, Query{"_id": cacheID(key), "expiresAt": Query{"$lte": time.Now().UnixMilli()}})
+		return nil, ErrCacheMiss
+	}
+
+	encoded, _ := doc["value"].(string)
+	value, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cache key %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// Set stores value under key. A ttl of zero keeps the entry until deleted.
+func (ch *Cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	fields := Document{
+		"key":       key,
+		"value":     base64.StdEncoding.EncodeToString(value),
+		"expiresAt": nil,
+	}
+	if ttl > 0 {
+		fields["expiresAt"] = time.Now().Add(ttl).UnixMilli()
+	}
+
+	id := cacheID(key)
+	updated, err := ch.client.UpdateMany(ctx, ch.collection, Query{"_id": id}, Update{"$set": fields})
+	if err != nil {
+		return fmt.Errorf("failed to set cache key %s: %w", key, err)
+	}
+	if updated.MatchedCount > 0 {
+		return nil
+	}
+
+	doc := Document{"_id": id}
+	for k, v := range fields {
+		doc[k] = v
+	}
+	if _, insertErr := ch.client.Insert(ctx, ch.collection, doc); insertErr != nil {
+		// A concurrent Set may have created the entry first
+		updated, err := ch.client.UpdateMany(ctx, ch.collection, Query{"_id": id}, Update{"$set": fields})
+		if err != nil || updated.MatchedCount == 0 {
+			return fmt.Errorf("failed to set cache key %s: %w", key, insertErr)
+		}
+	}
+	return nil
+}
+
+// Put stores value under key without expiry
+func (ch *Cache) Put(ctx context.Context, key string, value []byte) error {
+	return ch.Set(ctx, key, value, 0)
+}
+
+// Delete removes key. Deleting a missing key is not an error.
+func (ch *Cache) Delete(ctx context.Context, key string) error {
+	if _, err := ch.client.DeleteMany(ctx, ch.collection, Query{"_id": cacheID(key)}); err != nil {
+		return fmt.Errorf("failed to delete cache key %s: %w", key, err)
+	}
+	return nil
+}
+
+// TTL returns the time left before key expires, or zero if it never does
+func (ch *Cache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	docs, err := ch.client.Find(ctx, ch.collection, Query{"_id": cacheID(key)})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get cache key %s: %w", key, err)
+	}
+	if len(docs) == 0 {
+		return 0, ErrCacheMiss
+	}
+
+	expiresAt, ok := docs[0]["expiresAt"].(float64)
+	if !ok {
+		return 0, nil
+	}
+	left := time.Until(time.UnixMilli(int64(expiresAt)))
+	if left <= 0 {
+		return 0, ErrCacheMiss
+	}
+	return left, nil
+}
+
+// Purge removes every expired entry and returns how many were removed
+func (ch *Cache) Purge(ctx context.Context) (int, error) {
+	removed, err := ch.client.DeleteMany(ctx, ch.collection, Query{"expiresAt": Query{"$lte": time.Now().UnixMilli()}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge cache: %w", err)
+	}
+	return removed, nil
+}
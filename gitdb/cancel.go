@@ -0,0 +1,72 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// OperationIDHeader identifies a query so it can be cancelled on the server
+const OperationIDHeader = "X-GitDB-Operation-ID"
+
+// cancelTimeout bounds the follow-up request cancelling an abandoned query
+const cancelTimeout = 5 * time.Second
+
+// operationID tags a cancellable query with a fresh operation ID and
+// returns it, or "" when req cannot be cancelled or op is not a query
+func (c *Client) operationID(op string, req *http.Request) string {
+	if req.Context().Done() == nil || !(queryOperations[op] || op == "traverse documents") {
+		return ""
+	}
+	id, err := newToken()
+	if err != nil {
+		return ""
+	}
+	req.Header.Set(OperationIDHeader, id)
+	return id
+}
+
+// cancelOperation asks the server to stop executing an abandoned query,
+// so that a cancelled context stops the server's work and not only the
+// client's wait. The query may have been sent to any endpoint, so every
+// endpoint is told; it runs in the background and Close waits for it. The
+// requests go through do like any other, so they are scoped, versioned
+// and signed, and are bound by cancelTimeout.
+func (c *Client) cancelOperation(id string) {
+	state := c.shared()
+	if err := state.lifecycle.begin(); err != nil {
+		return
+	}
+
+	// do routes BaseURL to the active endpoint after a failover
+	bases := []string{c.BaseURL}
+	state.mu.RLock()
+	pool := state.readEndpoints
+	state.mu.RUnlock()
+	if pool != nil {
+		pool.mu.Lock()
+		for _, ep := range pool.endpoints {
+			bases = append(bases, ep.base)
+		}
+		pool.mu.Unlock()
+	}
+
+	go func() {
+		defer state.lifecycle.end()
+
+		ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+		defer cancel()
+
+		sent := make(map[string]bool, len(bases))
+		for _, base := range bases {
+			if sent[base] {
+				continue
+			}
+			sent[base] = true
+
+			url := fmt.Sprintf("%s/api/v1/operations/%s", base, pathSegment(id))
+			c.doJSON(ctx, "DELETE", url, nil, nil, http.StatusOK, "cancel operation")
+		}
+	}()
+}
@@ -0,0 +1,35 @@
+package gitdb
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// CanonicalJSON encodes v as compact JSON with object keys sorted at every
+// level, including inside values with custom MarshalJSON methods, and without
+// HTML escaping. Equal queries, updates and documents always produce the same
+// bytes, so the output is safe for request signing, cache keys and
+// record/replay fixtures. The client uses it for every request body.
+func CanonicalJSON(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// Round-trip through generic values: maps re-encode with sorted keys and
+	// json.Number keeps numbers exactly as first written
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(generic); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
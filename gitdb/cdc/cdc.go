@@ -0,0 +1,241 @@
+// Package cdc exports the changes to GitDB collections to message brokers
+// such as Kafka, NATS or Google Pub/Sub, so downstream systems stay in
+// sync. Delivery is at-least-once: each collection's position in its
+// change stream is checkpointed in GitDB itself, and only after the
+// broker accepted the changes up to it, so an exporter that stops between
+// the two publishes those changes again when it restarts.
+package cdc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb"
+)
+
+// DefaultCheckpointCollection stores the checkpoints of exporters that do
+// not name another collection
+const DefaultCheckpointCollection = "_cdc_checkpoints"
+
+// Event is a document created, replaced or deleted in a collection
+type Event struct {
+	Collection string `json:"collection"`
+	// Op is gitdb.ChangeUpsert or gitdb.ChangeDelete
+	Op string `json:"op"`
+	ID string `json:"id"`
+	// Document is the stored document after an upsert
+	Document gitdb.Document `json:"document,omitempty"`
+	// Commit is the commit the change is part of the net changes up to.
+	// Events are net changes, so several writes to a document between two
+	// exports are published as one event.
+	Commit string `json:"commit"`
+}
+
+// Key returns "<collection>/<id>", a stable key for the document the event
+// is about. Use it as the Kafka message key or Pub/Sub ordering key so the
+// events of a document stay in order.
+func (e Event) Key() string {
+	return e.Collection + "/" + e.ID
+}
+
+// Publisher delivers events to a broker. Publish must return nil only once
+// the broker has durably accepted every event, and may be retried with the
+// same events after an error.
+type Publisher interface {
+	Publish(ctx context.Context, events []Event) error
+}
+
+// PublisherFunc adapts a function to a Publisher
+type PublisherFunc func(ctx context.Context, events []Event) error
+
+// Publish calls f
+func (f PublisherFunc) Publish(ctx context.Context, events []Event) error {
+	return f(ctx, events)
+}
+
+// Options configures Export
+type Options struct {
+	// Name identifies the exporter; its checkpoints are stored under it,
+	// so exporters with different names publish the same changes
+	// independently. Required.
+	Name string
+
+	// Collections to export; all collections by default, except the
+	// checkpoint collection and internal collections
+	Collections []string
+	// IncludeInternal also exports the collections the client keeps its
+	// own state in, whose names start with "_", such as topics, sequences,
+	// locks and caches, when Collections is empty
+	IncludeInternal bool
+
+	// CheckpointCollection stores the checkpoints, DefaultCheckpointCollection
+	// by default
+	CheckpointCollection string
+
+	// Continuous keeps polling for changes until ctx is done instead of
+	// returning after one pass
+	Continuous bool
+	// Interval between passes in continuous mode, 10 seconds by default
+	Interval time.Duration
+	// OnError, when set, receives the errors of continuous passes, which
+	// are otherwise retried silently on the next pass
+	OnError func(collection string, err error)
+}
+
+// CollectionReport counts the events published for a collection
+type CollectionReport struct {
+	Published int
+	// Checkpoint is the commit the collection was exported up to
+	Checkpoint string
+}
+
+// Report summarises an export
+type Report struct {
+	Collections map[string]*CollectionReport
+}
+
+// Export publishes the changes to collections since their checkpoints and
+// advances the checkpoints. A collection without a checkpoint starts with
+// its full contents, published as upserts. In one-shot mode Export returns
+// after a single pass; in continuous mode it runs passes until ctx is done
+// and then returns the report with ctx's error.
+func Export(ctx context.Context, client *gitdb.Client, publisher Publisher, opts Options) (*Report, error) {
+	if opts.Name == "" {
+		return nil, errors.New("cdc: exporter name is required")
+	}
+	if opts.CheckpointCollection == "" {
+		opts.CheckpointCollection = DefaultCheckpointCollection
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 10 * time.Second
+	}
+
+	if err := client.EnsureCollection(ctx, opts.CheckpointCollection); err != nil {
+		return nil, fmt.Errorf("failed to create checkpoint collection: %w", err)
+	}
+	checkpoints := client.KV(opts.CheckpointCollection)
+	report := &Report{Collections: make(map[string]*CollectionReport)}
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		collections, err := exportCollections(ctx, client, opts)
+		if err == nil {
+			for _, collection := range collections {
+				if err = ctx.Err(); err != nil {
+					break
+				}
+				if err = exportCollection(ctx, client, publisher, checkpoints, opts.Name, collection, report); err != nil {
+					err = fmt.Errorf("failed to export %s: %w", collection, err)
+					if !opts.Continuous {
+						break
+					}
+					if opts.OnError != nil {
+						opts.OnError(collection, err)
+					}
+					err = nil
+				}
+			}
+		} else if opts.Continuous {
+			if opts.OnError != nil {
+				opts.OnError("", err)
+			}
+			err = nil
+		}
+
+		if !opts.Continuous || err != nil {
+			return report, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return report, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// exportCollections resolves the collections to export
+func exportCollections(ctx context.Context, client *gitdb.Client, opts Options) ([]string, error) {
+	if len(opts.Collections) > 0 {
+		return opts.Collections, nil
+	}
+
+	collections, err := client.ListCollections(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	var names []string
+	for _, collection := range collections {
+		if collection.Name == opts.CheckpointCollection {
+			continue
+		}
+		if opts.IncludeInternal || !strings.HasPrefix(collection.Name, "_") {
+			names = append(names, collection.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// exportCollection publishes the changes to one collection since its
+// checkpoint
+func exportCollection(ctx context.Context, client *gitdb.Client, publisher Publisher, checkpoints *gitdb.KV, name, collection string, report *Report) error {
+	stats := report.Collections[collection]
+	if stats == nil {
+		stats = &CollectionReport{}
+		report.Collections[collection] = stats
+	}
+
+	key := checkpointKey(name, collection)
+	since, err := checkpoints.Get(ctx, key)
+	if err != nil && !errors.Is(err, gitdb.ErrKeyNotFound) {
+		return fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+	stats.Checkpoint = since
+
+	for {
+		changes, err := client.Changes(ctx, collection, since)
+		if err != nil {
+			return err
+		}
+
+		if len(changes.Changes) > 0 {
+			events := make([]Event, len(changes.Changes))
+			for i, change := range changes.Changes {
+				events[i] = Event{
+					Collection: collection,
+					Op:         change.Op,
+					ID:         change.ID,
+					Document:   change.Document,
+					Commit:     changes.Head,
+				}
+			}
+			if err := publisher.Publish(ctx, events); err != nil {
+				return fmt.Errorf("failed to publish: %w", err)
+			}
+			stats.Published += len(events)
+		}
+
+		if changes.Head != "" && changes.Head != since {
+			if err := checkpoints.Set(ctx, key, changes.Head); err != nil {
+				return fmt.Errorf("failed to save checkpoint: %w", err)
+			}
+			since = changes.Head
+			stats.Checkpoint = since
+		}
+		if !changes.More {
+			return nil
+		}
+	}
+}
+
+// checkpointKey is the ID of an exporter's checkpoint for a collection
+func checkpointKey(name, collection string) string {
+	return name + "." + collection
+}
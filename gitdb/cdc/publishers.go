@@ -0,0 +1,69 @@
+package cdc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb"
+)
+
+// SendFunc sends one message to a broker and returns once it is
+// acknowledged. Most broker clients map onto it directly:
+//
+//	// Kafka, with github.com/segmentio/kafka-go
+//	func(ctx context.Context, key string, value []byte) error {
+//		return writer.WriteMessages(ctx, kafka.Message{Key: []byte(key), Value: value})
+//	}
+//
+//	// NATS JetStream, with github.com/nats-io/nats.go
+//	func(ctx context.Context, key string, value []byte) error {
+//		_, err := js.Publish("gitdb.changes", value, nats.Context(ctx))
+//		return err
+//	}
+//
+//	// Google Pub/Sub, with cloud.google.com/go/pubsub
+//	func(ctx context.Context, key string, value []byte) error {
+//		_, err := topic.Publish(ctx, &pubsub.Message{Data: value, OrderingKey: key}).Get(ctx)
+//		return err
+//	}
+type SendFunc func(ctx context.Context, key string, value []byte) error
+
+// JSONPublisher returns a Publisher that sends each event as JSON, keyed
+// by Event.Key, in order
+func JSONPublisher(send SendFunc) Publisher {
+	return PublisherFunc(func(ctx context.Context, events []Event) error {
+		for _, event := range events {
+			value, err := json.Marshal(event)
+			if err != nil {
+				return fmt.Errorf("failed to encode event %s: %w", event.Key(), err)
+			}
+			if err := send(ctx, event.Key(), value); err != nil {
+				return fmt.Errorf("failed to send event %s: %w", event.Key(), err)
+			}
+		}
+		return nil
+	})
+}
+
+// TopicPublisher returns a Publisher that appends events to a GitDB topic,
+// which consumers read with Client.Subscribe
+func TopicPublisher(client *gitdb.Client, topic string) Publisher {
+	return PublisherFunc(func(ctx context.Context, events []Event) error {
+		for _, event := range events {
+			payload := gitdb.Document{
+				"collection": event.Collection,
+				"op":         event.Op,
+				"id":         event.ID,
+				"commit":     event.Commit,
+			}
+			if event.Document != nil {
+				payload["document"] = event.Document
+			}
+			if _, err := client.Publish(ctx, topic, payload); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
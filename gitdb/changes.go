@@ -0,0 +1,100 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Change operations
+const (
+	ChangeUpsert = "upsert"
+	ChangeDelete = "delete"
+)
+
+// Change is a document created, replaced or deleted in a collection
+type Change struct {
+	Op string `json:"op"`
+	ID string `json:"id"`
+	// Document is the stored document after an upsert
+	Document Document `json:"document,omitempty"`
+}
+
+// ChangeSet lists the net changes to a collection between two commits
+type ChangeSet struct {
+	// Since is the commit the changes start from, "" for the beginning
+	Since string `json:"since"`
+	// Head is the commit the changes lead to; pass it as since to the next
+	// call to continue from here
+	Head string `json:"head"`
+	// Changes holds at most one change per document
+	Changes []Change `json:"changes"`
+	// More is set when the server stopped early; call again from Head for
+	// the rest
+	More bool `json:"more"`
+}
+
+// Changes returns the net changes to a collection since the given commit,
+// or its full contents when since is "". Commit SHAs make reliable
+// watermarks for incremental copies. Documents are returned as stored,
+// without field aliases or computed fields applied.
+func (c *Client) Changes(ctx context.Context, collection, since string) (*ChangeSet, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/api/v1/collections/%s/changes", c.BaseURL, name)
+	if since != "" {
+		endpoint += "?since=" + url.QueryEscape(since)
+	}
+
+	var changes ChangeSet
+	if err := c.doJSON(ctx, "GET", endpoint, nil, &changes, http.StatusOK, "get changes"); err != nil {
+		return nil, err
+	}
+	return &changes, nil
+}
+
+// ApplyChanges applies changes to a collection in a single commit,
+// replacing upserted documents whole and ignoring deletes of missing
+// documents, and returns the commit. Documents are written as given,
+// without field aliases.
+func (c *Client) ApplyChanges(ctx context.Context, collection string, changes []Change) (string, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return "", err
+	}
+
+	for _, change := range changes {
+		if err := ValidateDocumentID(change.ID); err != nil {
+			return "", err
+		}
+		switch change.Op {
+		case ChangeUpsert:
+			if err := validateDocumentFields(change.Document); err != nil {
+				return "", err
+			}
+			if err := c.checkDocumentSize(collection, change.Document); err != nil {
+				return "", err
+			}
+		case ChangeDelete:
+		default:
+			return "", fmt.Errorf("unknown change operation %q", change.Op)
+		}
+	}
+
+	endpoint := fmt.Sprintf("%s/api/v1/collections/%s/changes", c.BaseURL, name)
+
+	data := map[string]interface{}{"changes": changes}
+	defer c.evictCollection(collection)
+
+	var result struct {
+		Commit string `json:"commit"`
+	}
+	if err := c.doJSON(ctx, "POST", endpoint, data, &result, http.StatusOK, "apply changes"); err != nil {
+		return "", err
+	}
+	return result.Commit, nil
+}
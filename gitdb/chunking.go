@@ -0,0 +1,107 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// WithMaxInValues makes Find split queries whose $in list on a field holds
+// more than n values into several requests of at most n values each, so a
+// filter on thousands of IDs stays under the server's request size limits.
+// The results are merged in request order with duplicates, by _id,
+// dropped. Only top-level fields are split, and only one per query.
+func WithMaxInValues(n int) Option {
+	return func(o *options) {
+		o.maxInValues = n
+	}
+}
+
+// findChunked runs query in chunks when it has an $in list longer than
+// the client's MaxInValues, reporting false when it does not
+func (c *Client) findChunked(ctx context.Context, collection string, query Query) ([]Document, bool, error) {
+	limit := c.shared().maxInValues
+	if limit <= 0 {
+		return nil, false, nil
+	}
+	chunks := splitInQuery(query, limit)
+	if chunks == nil {
+		return nil, false, nil
+	}
+
+	maxDocuments := c.shared().maxDocuments
+	seen := make(map[string]bool)
+	var documents []Document
+	for _, chunk := range chunks {
+		docs, err := c.find(ctx, collection, chunk)
+		if err != nil {
+			return nil, true, err
+		}
+		for _, doc := range docs {
+			if id, ok := doc["_id"].(string); ok {
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+			}
+			documents = append(documents, doc)
+		}
+		if maxDocuments > 0 && len(documents) > maxDocuments {
+			return nil, true, fmt.Errorf("failed to decode documents: %w: more than %d documents", ErrResponseTooLarge, maxDocuments)
+		}
+	}
+	return documents, true, nil
+}
+
+// splitInQuery returns copies of query that each hold at most limit values
+// of its longest top-level $in list, or nil if no list is longer than limit
+func splitInQuery(query Query, limit int) []Query {
+	var (
+		field  string
+		values reflect.Value
+	)
+	for key, value := range query {
+		cond, ok := asMap(value)
+		if !ok {
+			continue
+		}
+		list := reflect.ValueOf(cond["$in"])
+		if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+			continue
+		}
+		if list.Len() <= limit || values.IsValid() && (list.Len() < values.Len() || list.Len() == values.Len() && key > field) {
+			continue
+		}
+		field, values = key, list
+	}
+	if !values.IsValid() {
+		return nil
+	}
+
+	cond, _ := asMap(query[field])
+	var chunks []Query
+	for start := 0; start < values.Len(); start += limit {
+		end := start + limit
+		if end > values.Len() {
+			end = values.Len()
+		}
+		in := make([]interface{}, 0, end-start)
+		for i := start; i < end; i++ {
+			in = append(in, values.Index(i).Interface())
+		}
+
+		chunkCond := make(map[string]interface{}, len(cond))
+		for op, operand := range cond {
+			chunkCond[op] = operand
+		}
+		chunkCond["$in"] = in
+
+		chunk := make(Query, len(query))
+		for key, value := range query {
+			chunk[key] = value
+		}
+		chunk[field] = chunkCond
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
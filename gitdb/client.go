@@ -2,7 +2,9 @@ package gitdb
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -16,6 +18,17 @@ type Client struct {
 	Owner      string
 	Repo       string
 	HTTPClient *http.Client
+
+	namespace    string
+	namespaceErr error
+	collections  *collectionRegistry
+	state        *clientState
+	verification *signatureCheck
+	principal    string
+	branch       string
+	session      *sessionState
+	maxTime      time.Duration
+	writeToken   *writeTokenState
 }
 
 // Document represents a GitDB document
@@ -49,8 +62,8 @@ type GraphQLResponse struct {
 }
 
 // NewClient creates a new GitDB client
-func NewClient(token, owner, repo string) *Client {
-	return &Client{
+func NewClient(token, owner, repo string, opts ...Option) *Client {
+	c := &Client{
 		BaseURL: "http://localhost:7896",
 		Token:   token,
 		Owner:   owner,
@@ -58,7 +71,17 @@ func NewClient(token, owner, repo string) *Client {
 		HTTPClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		collections: newCollectionRegistry(),
+		state:       newClientState(),
+	}
+
+	var o options
+	for _, opt := range opts {
+		opt(&o)
 	}
+	o.apply(c)
+
+	return c
 }
 
 // SetBaseURL sets the base URL for the client
@@ -67,8 +90,13 @@ func (c *Client) SetBaseURL(url string) {
 }
 
 // Health checks if the GitDB server is healthy
-func (c *Client) Health() error {
-	resp, err := c.HTTPClient.Get(c.BaseURL + "/health")
+func (c *Client) Health(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.BaseURL+"/health", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do("health check", req)
 	if err != nil {
 		return fmt.Errorf("health check failed: %w", err)
 	}
@@ -82,16 +110,21 @@ func (c *Client) Health() error {
 }
 
 // CreateCollection creates a new collection
-func (c *Client) CreateCollection(name string) error {
+func (c *Client) CreateCollection(ctx context.Context, name string) error {
+	name, err := c.collectionName(name)
+	if err != nil {
+		return err
+	}
+
 	url := fmt.Sprintf("%s/api/v1/collections", c.BaseURL)
-	
+
 	data := map[string]string{"name": name}
-	jsonData, err := json.Marshal(data)
+	jsonData, err := CanonicalJSON(data)
 	if err != nil {
 		return fmt.Errorf("failed to marshal collection data: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -99,40 +132,56 @@ func (c *Client) CreateCollection(name string) error {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.Token)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do("create collection", req)
 	if err != nil {
 		return fmt.Errorf("failed to create collection: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to create collection: %s", string(body))
+		return fmt.Errorf("failed to create collection: %w", newAPIError(resp))
 	}
 
 	return nil
 }
 
+// EnsureCollection creates a collection unless it already exists, for
+// tools that write to collections they did not create themselves
+func (c *Client) EnsureCollection(ctx context.Context, name string) error {
+	collections, err := c.ListCollections(ctx)
+	if err != nil {
+		return err
+	}
+	for _, existing := range collections {
+		if existing.Name == name {
+			return nil
+		}
+	}
+	if err := c.CreateCollection(ctx, name); err != nil && !errors.Is(err, ErrConflict) {
+		return err
+	}
+	return nil
+}
+
 // ListCollections lists all collections
-func (c *Client) ListCollections() ([]Collection, error) {
+func (c *Client) ListCollections(ctx context.Context) ([]Collection, error) {
 	url := fmt.Sprintf("%s/api/v1/collections", c.BaseURL)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+c.Token)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do("list collections", req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list collections: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to list collections: %s", string(body))
+		return nil, fmt.Errorf("failed to list collections: %w", newAPIError(resp))
 	}
 
 	var collections []Collection
@@ -140,84 +189,160 @@ func (c *Client) ListCollections() ([]Collection, error) {
 		return nil, fmt.Errorf("failed to decode collections: %w", err)
 	}
 
-	return collections, nil
+	return c.filterNamespace(collections), nil
 }
 
 // DeleteCollection deletes a collection
-func (c *Client) DeleteCollection(name string) error {
+func (c *Client) DeleteCollection(ctx context.Context, name string) error {
+	name, err := c.collectionName(name)
+	if err != nil {
+		return err
+	}
+
 	url := fmt.Sprintf("%s/api/v1/collections/%s", c.BaseURL, name)
 
-	req, err := http.NewRequest("DELETE", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+c.Token)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do("delete collection", req)
 	if err != nil {
 		return fmt.Errorf("failed to delete collection: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to delete collection: %s", string(body))
+		return fmt.Errorf("failed to delete collection: %w", newAPIError(resp))
 	}
 
 	return nil
 }
 
-// Insert inserts a document into a collection
-func (c *Client) Insert(collection string, document Document) (string, error) {
-	url := fmt.Sprintf("%s/api/v1/collections/%s/documents", c.BaseURL, collection)
+// Insert inserts a document into a collection and returns its ID
+func (c *Client) Insert(ctx context.Context, collection string, document Document) (string, error) {
+	result, err := c.InsertWithResult(ctx, collection, document)
+	if err != nil {
+		return "", err
+	}
+	return result.ID, nil
+}
+
+// InsertResult describes an inserted document
+type InsertResult struct {
+	ID string
+	// Commit is the SHA of the commit that stored the document, when the
+	// server reports it
+	Commit string
+	// Timestamp is when the server stored the document
+	Timestamp time.Time
+	// Fields holds the fields the server assigned, such as defaults or
+	// creation times, when it reports them
+	Fields Document
+}
+
+// InsertWithResult inserts a document into a collection and returns its ID
+// with the commit and time it was stored at, so callers can record
+// provenance without reading the document back
+func (c *Client) InsertWithResult(ctx context.Context, collection string, document Document) (*InsertResult, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateDocumentFields(document); err != nil {
+		return nil, err
+	}
+	document, err = c.prepareInsert(collection, document)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := c.encodeDocument(collection, document)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkDocumentSize(collection, encoded); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/documents", c.BaseURL, name)
 
-	jsonData, err := json.Marshal(document)
+	jsonData, err := CanonicalJSON(encoded)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal document: %w", err)
+		return nil, fmt.Errorf("failed to marshal document: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.Token)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do("insert document", req)
 	if err != nil {
-		return "", fmt.Errorf("failed to insert document: %w", err)
+		return nil, fmt.Errorf("failed to insert document: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("failed to insert document: %s", string(body))
+		return nil, fmt.Errorf("failed to insert document: %w", newAPIError(resp))
 	}
 
 	var result map[string]interface{}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	if id, ok := result["_id"].(string); ok {
-		return id, nil
+	id, ok := result["_id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("no document ID returned")
 	}
 
-	return "", fmt.Errorf("no document ID returned")
+	inserted := &InsertResult{ID: id, Commit: resp.Header.Get(CommitHeader)}
+	if commit, ok := result["commit"].(string); ok {
+		inserted.Commit = commit
+	}
+	if ts, ok := result["timestamp"].(string); ok {
+		inserted.Timestamp, _ = time.Parse(time.RFC3339Nano, ts)
+	}
+	if inserted.Timestamp.IsZero() {
+		inserted.Timestamp, _ = http.ParseTime(resp.Header.Get("Date"))
+	}
+	if fields, ok := result["fields"].(map[string]interface{}); ok {
+		if inserted.Fields, err = c.decodeDocument(collection, fields); err != nil {
+			return nil, err
+		}
+	}
+	return inserted, nil
 }
 
 // Find finds documents in a collection
-func (c *Client) Find(collection string, query Query) ([]Document, error) {
-	url := fmt.Sprintf("%s/api/v1/collections/%s/documents", c.BaseURL, collection)
+func (c *Client) Find(ctx context.Context, collection string, query Query) ([]Document, error) {
+	if documents, chunked, err := c.findChunked(ctx, collection, query); chunked {
+		return documents, err
+	}
+	return c.find(ctx, collection, query)
+}
+
+// find finds documents in a collection with a single request
+func (c *Client) find(ctx context.Context, collection string, query Query) ([]Document, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return nil, err
+	}
 
-	jsonData, err := json.Marshal(query)
+	url := fmt.Sprintf("%s/api/v1/collections/%s/documents", c.BaseURL, name)
+
+	jsonData, err := CanonicalJSON(c.encodeQuery(collection, c.liveQuery(collection, query)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal query: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url+"/find", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url+"/find", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -225,59 +350,75 @@ func (c *Client) Find(collection string, query Query) ([]Document, error) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.Token)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do("find documents", req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find documents: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to find documents: %s", string(body))
+		return nil, fmt.Errorf("failed to find documents: %w", newAPIError(resp))
 	}
 
-	var documents []Document
-	if err := json.NewDecoder(resp.Body).Decode(&documents); err != nil {
+	documents, err := c.decodeDocumentList(resp.Body)
+	if err != nil {
 		return nil, fmt.Errorf("failed to decode documents: %w", err)
 	}
+	if err := c.verifyDocuments(collection, documents); err != nil {
+		return nil, err
+	}
+	if err := c.readDocuments(ctx, collection, documents, false); err != nil {
+		return nil, err
+	}
 
 	return documents, nil
 }
 
 // FindOne finds a single document in a collection
-func (c *Client) FindOne(collection string, query Query) (Document, error) {
-	documents, err := c.Find(collection, query)
+func (c *Client) FindOne(ctx context.Context, collection string, query Query) (Document, error) {
+	documents, err := c.Find(ctx, collection, query)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(documents) == 0 {
-		return nil, fmt.Errorf("no document found")
+		return nil, fmt.Errorf("failed to find document: %w", ErrNotFound)
 	}
 
 	return documents[0], nil
 }
 
 // FindByID finds a document by ID
-func (c *Client) FindByID(collection, id string) (Document, error) {
-	url := fmt.Sprintf("%s/api/v1/collections/%s/documents/%s", c.BaseURL, collection, id)
+func (c *Client) FindByID(ctx context.Context, collection, id string) (Document, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return nil, err
+	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	if err := ValidateDocumentID(id); err != nil {
+		return nil, err
+	}
+	if doc, ok := c.cachedDocument(collection, name, id); ok {
+		return doc, nil
+	}
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/documents/%s", c.BaseURL, name, pathSegment(id))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+c.Token)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do("find document", req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find document: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to find document: %s", string(body))
+		return nil, fmt.Errorf("failed to find document: %w", newAPIError(resp))
 	}
 
 	var document Document
@@ -285,120 +426,264 @@ func (c *Client) FindByID(collection, id string) (Document, error) {
 		return nil, fmt.Errorf("failed to decode document: %w", err)
 	}
 
+	if err := c.verifyDocuments(collection, []Document{document}); err != nil {
+		return nil, err
+	}
+	if document[DeletedAtField] != nil && c.softDeletes(collection) {
+		return nil, fmt.Errorf("failed to find document: document %s is deleted: %w", id, ErrNotFound)
+	}
+	documents := []Document{document}
+	if err := c.readDocuments(ctx, collection, documents, false); err != nil {
+		return nil, err
+	}
+	document = documents[0]
+	c.cacheDocument(collection, name, id, document)
 	return document, nil
 }
 
+// UpdateResult describes the outcome of an update. A zero ModifiedCount
+// with a non-zero MatchedCount means the documents already held the
+// updated values.
+type UpdateResult struct {
+	MatchedCount  int
+	ModifiedCount int
+	// UpsertedID is the ID of the document the update inserted, if any
+	UpsertedID string
+	// Commit is the SHA of the commit holding the changes, "" when nothing
+	// was modified or the server does not report it
+	Commit string
+}
+
+// decodeUpdateResult reads an update result from resp. Servers that do not
+// report matches are taken to have matched what they modified. An update
+// of one document by ID that reports no counts at all matched and modified
+// it, since a missing document is a 404; for other updates missing counts
+// are an error, as they cannot be told apart from an update that matched
+// nothing.
+func decodeUpdateResult(resp *http.Response, byID bool) (*UpdateResult, error) {
+	var body struct {
+		MatchedCount  *int   `json:"matchedCount"`
+		ModifiedCount *int   `json:"modifiedCount"`
+		UpsertedID    string `json:"upsertedId"`
+		Commit        string `json:"commit"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if body.MatchedCount == nil && body.ModifiedCount == nil && !byID {
+		return nil, fmt.Errorf("failed to decode response: server did not report how many documents it updated")
+	}
+
+	result := &UpdateResult{
+		MatchedCount:  1,
+		ModifiedCount: 1,
+		UpsertedID:    body.UpsertedID,
+		Commit:        resp.Header.Get(CommitHeader),
+	}
+	if body.ModifiedCount != nil {
+		result.ModifiedCount = *body.ModifiedCount
+		result.MatchedCount = *body.ModifiedCount
+	}
+	if body.MatchedCount != nil {
+		result.MatchedCount = *body.MatchedCount
+	}
+	if body.Commit != "" {
+		result.Commit = body.Commit
+	}
+	return result, nil
+}
+
 // Update updates a document by ID
-func (c *Client) Update(collection, id string, update Update) error {
-	url := fmt.Sprintf("%s/api/v1/collections/%s/documents/%s", c.BaseURL, collection, id)
+func (c *Client) Update(ctx context.Context, collection, id string, update Update) (*UpdateResult, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ValidateDocumentID(id); err != nil {
+		return nil, err
+	}
+	if err := validateUpdateFields(update); err != nil {
+		return nil, err
+	}
+	update, err = c.prepareUpdate(collection, update)
+	if err != nil {
+		return nil, err
+	}
+	defer c.evictDocument(collection, name, id)
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/documents/%s", c.BaseURL, name, pathSegment(id))
 
-	jsonData, err := json.Marshal(update)
+	jsonData, err := CanonicalJSON(c.encodeUpdate(collection, update))
 	if err != nil {
-		return fmt.Errorf("failed to marshal update: %w", err)
+		return nil, fmt.Errorf("failed to marshal update: %w", err)
 	}
 
-	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.Token)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do("update document", req)
 	if err != nil {
-		return fmt.Errorf("failed to update document: %w", err)
+		return nil, fmt.Errorf("failed to update document: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to update document: %s", string(body))
+		return nil, fmt.Errorf("failed to update document: %w", newAPIError(resp))
 	}
 
-	return nil
+	return decodeUpdateResult(resp, true)
 }
 
 // UpdateMany updates multiple documents
-func (c *Client) UpdateMany(collection string, query Query, update Update) (int, error) {
-	url := fmt.Sprintf("%s/api/v1/collections/%s/documents/update-many", c.BaseURL, collection)
+func (c *Client) UpdateMany(ctx context.Context, collection string, query Query, update Update) (*UpdateResult, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateUpdateFields(update); err != nil {
+		return nil, err
+	}
+	update, err = c.prepareUpdate(collection, update)
+	if err != nil {
+		return nil, err
+	}
+	return c.updateStored(ctx, collection, name, c.encodeQuery(collection, c.liveQuery(collection, query)), c.encodeUpdate(collection, update))
+}
+
+// updateStored sends an update whose query and update already use stored
+// field names
+func (c *Client) updateStored(ctx context.Context, collection, name string, query Query, update Update) (*UpdateResult, error) {
+	defer c.evictCollection(collection)
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/documents/update-many", c.BaseURL, name)
 
 	data := map[string]interface{}{
-		"query": query,
+		"query":  query,
 		"update": update,
 	}
 
-	jsonData, err := json.Marshal(data)
+	jsonData, err := CanonicalJSON(data)
 	if err != nil {
-		return 0, fmt.Errorf("failed to marshal update data: %w", err)
+		return nil, fmt.Errorf("failed to marshal update data: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return 0, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.Token)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do("update documents", req)
 	if err != nil {
-		return 0, fmt.Errorf("failed to update documents: %w", err)
+		return nil, fmt.Errorf("failed to update documents: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("failed to update documents: %s", string(body))
+		return nil, fmt.Errorf("failed to update documents: %w", newAPIError(resp))
 	}
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, fmt.Errorf("failed to decode response: %w", err)
+	result, err := decodeUpdateResult(resp, false)
+	if err != nil {
+		return nil, err
 	}
+	return result, nil
+}
 
-	if count, ok := result["modifiedCount"].(float64); ok {
-		return int(count), nil
+// Delete deletes a document by ID
+func (c *Client) Delete(ctx context.Context, collection, id string) error {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return err
 	}
 
-	return 0, fmt.Errorf("no modified count returned")
-}
+	if err := ValidateDocumentID(id); err != nil {
+		return err
+	}
 
-// Delete deletes a document by ID
-func (c *Client) Delete(collection, id string) error {
-	url := fmt.Sprintf("%s/api/v1/collections/%s/documents/%s", c.BaseURL, collection, id)
+	if err := c.applyDeleteActions(ctx, collection, []interface{}{id}); err != nil {
+		return err
+	}
+	if c.softDeletes(collection) {
+		_, err := c.Update(ctx, collection, id, Update{"$set": Document{DeletedAtField: time.Now().UnixMilli()}})
+		return err
+	}
+	defer c.evictDocument(collection, name, id)
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/documents/%s", c.BaseURL, name, pathSegment(id))
 
-	req, err := http.NewRequest("DELETE", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+c.Token)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do("delete document", req)
 	if err != nil {
 		return fmt.Errorf("failed to delete document: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to delete document: %s", string(body))
+		return fmt.Errorf("failed to delete document: %w", newAPIError(resp))
 	}
 
 	return nil
 }
 
 // DeleteMany deletes multiple documents
-func (c *Client) DeleteMany(collection string, query Query) (int, error) {
-	url := fmt.Sprintf("%s/api/v1/collections/%s/documents/delete-many", c.BaseURL, collection)
+func (c *Client) DeleteMany(ctx context.Context, collection string, query Query) (int, error) {
+	if c.hasDeleteActions(collection) {
+		documents, err := c.Find(ctx, collection, query)
+		if err != nil {
+			return 0, err
+		}
+		if err := c.applyDeleteActions(ctx, collection, documentIDs(documents)); err != nil {
+			return 0, err
+		}
+	}
+
+	return c.deleteMany(ctx, collection, query)
+}
+
+func (c *Client) deleteMany(ctx context.Context, collection string, query Query) (int, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return 0, err
+	}
+
+	if c.softDeletes(collection) {
+		result, err := c.UpdateMany(ctx, collection, query, Update{"$set": Document{DeletedAtField: time.Now().UnixMilli()}})
+		if err != nil {
+			return 0, err
+		}
+		return result.ModifiedCount, nil
+	}
+	return c.deleteStored(ctx, collection, name, c.encodeQuery(collection, query))
+}
+
+// deleteStored sends a delete whose query already uses stored field names
+func (c *Client) deleteStored(ctx context.Context, collection, name string, query Query) (int, error) {
+	defer c.evictCollection(collection)
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/documents/delete-many", c.BaseURL, name)
 
-	jsonData, err := json.Marshal(query)
+	jsonData, err := CanonicalJSON(query)
 	if err != nil {
 		return 0, fmt.Errorf("failed to marshal query: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return 0, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -406,15 +691,14 @@ func (c *Client) DeleteMany(collection string, query Query) (int, error) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.Token)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do("delete documents", req)
 	if err != nil {
 		return 0, fmt.Errorf("failed to delete documents: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("failed to delete documents: %s", string(body))
+		return 0, fmt.Errorf("failed to delete documents: %w", newAPIError(resp))
 	}
 
 	var result map[string]interface{}
@@ -430,15 +714,20 @@ func (c *Client) DeleteMany(collection string, query Query) (int, error) {
 }
 
 // Count counts documents in a collection
-func (c *Client) Count(collection string, query Query) (int, error) {
-	url := fmt.Sprintf("%s/api/v1/collections/%s/documents/count", c.BaseURL, collection)
+func (c *Client) Count(ctx context.Context, collection string, query Query) (int, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return 0, err
+	}
 
-	jsonData, err := json.Marshal(query)
+	url := fmt.Sprintf("%s/api/v1/collections/%s/documents/count", c.BaseURL, name)
+
+	jsonData, err := CanonicalJSON(c.encodeQuery(collection, c.liveQuery(collection, query)))
 	if err != nil {
 		return 0, fmt.Errorf("failed to marshal query: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return 0, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -446,15 +735,14 @@ func (c *Client) Count(collection string, query Query) (int, error) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.Token)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do("count documents", req)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count documents: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("failed to count documents: %s", string(body))
+		return 0, fmt.Errorf("failed to count documents: %w", newAPIError(resp))
 	}
 
 	var result map[string]interface{}
@@ -470,7 +758,11 @@ func (c *Client) Count(collection string, query Query) (int, error) {
 }
 
 // GraphQL executes a GraphQL query
-func (c *Client) GraphQL(query string, variables map[string]interface{}) (*GraphQLResponse, error) {
+func (c *Client) GraphQL(ctx context.Context, query string, variables map[string]interface{}) (*GraphQLResponse, error) {
+	if c.namespace != "" {
+		return nil, fmt.Errorf("GraphQL is not available on namespaced clients")
+	}
+
 	url := fmt.Sprintf("%s/graphql", c.BaseURL)
 
 	request := GraphQLRequest{
@@ -478,12 +770,12 @@ func (c *Client) GraphQL(query string, variables map[string]interface{}) (*Graph
 		Variables: variables,
 	}
 
-	jsonData, err := json.Marshal(request)
+	jsonData, err := CanonicalJSON(request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal GraphQL request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -491,15 +783,14 @@ func (c *Client) GraphQL(query string, variables map[string]interface{}) (*Graph
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.Token)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do("execute GraphQL query", req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute GraphQL query: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to execute GraphQL query: %s", string(body))
+		return nil, fmt.Errorf("failed to execute GraphQL query: %w", newAPIError(resp))
 	}
 
 	var response GraphQLResponse
@@ -512,4 +803,4 @@ func (c *Client) GraphQL(query string, variables map[string]interface{}) (*Graph
 	}
 
 	return &response, nil
-} 
\ No newline at end of file
+}
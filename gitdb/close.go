@@ -0,0 +1,132 @@
+package gitdb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrClientClosed is returned by requests made after Close
+var ErrClientClosed = errors.New("gitdb client is closed")
+
+// lifecycle tracks in-flight requests and shutdown work of a client
+type lifecycle struct {
+	mu       sync.Mutex
+	closed   bool
+	inflight int
+	drained  chan struct{} // closed once closed and inflight reaches zero
+	closers  []func(context.Context) error
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{drained: make(chan struct{})}
+}
+
+// begin registers a new in-flight request
+func (l *lifecycle) begin() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrClientClosed
+	}
+	l.inflight++
+	return nil
+}
+
+// end marks an in-flight request as finished
+func (l *lifecycle) end() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inflight--
+	if l.closed && l.inflight == 0 {
+		close(l.drained)
+	}
+}
+
+// onClose registers work to run when the client is closed, such as
+// flushing buffered writes or stopping background goroutines
+func (l *lifecycle) onClose(fn func(context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closers = append(l.closers, fn)
+}
+
+// Close shuts the client down gracefully. Background work started from the
+// client (buffered writers, expiry watchers, health checks) is flushed or
+// stopped first, then new requests are refused with ErrClientClosed and
+// Close waits for in-flight requests to finish before closing idle
+// connections. If ctx expires first, Close returns its error and leaves the
+// remaining requests running. Close applies to every client sharing state
+// with this one, such as namespaced clients.
+func (c *Client) Close(ctx context.Context) error {
+	lc := c.shared().lifecycle
+
+	lc.mu.Lock()
+	if lc.closed {
+		lc.mu.Unlock()
+		return ErrClientClosed
+	}
+	closers := lc.closers
+	lc.closers = nil
+	lc.mu.Unlock()
+
+	var firstErr error
+	for _, closer := range closers {
+		if err := closer(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	lc.mu.Lock()
+	lc.closed = true
+	if lc.inflight == 0 {
+		close(lc.drained)
+	}
+	lc.mu.Unlock()
+
+	select {
+	case <-lc.drained:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	c.HTTPClient.CloseIdleConnections()
+
+	return firstErr
+}
+
+// waitContext runs a blocking stop function, giving up when ctx is done
+func waitContext(ctx context.Context, stop func()) error {
+	done := make(chan struct{})
+	go func() {
+		stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// trackedBody ends an in-flight request when its response body is closed
+type trackedBody struct {
+	io.ReadCloser
+	once   sync.Once
+	lc     *lifecycle
+	cancel context.CancelFunc // releases the request's timeout, if any
+}
+
+func (b *trackedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.lc.end()
+		if b.cancel != nil {
+			b.cancel()
+		}
+	})
+	return err
+}
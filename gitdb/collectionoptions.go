@@ -0,0 +1,464 @@
+package gitdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Fields maintained by collection options, holding Unix milliseconds
+const (
+	CreatedAtField = "createdAt"
+	UpdatedAtField = "updatedAt"
+	DeletedAtField = "deletedAt"
+)
+
+// Codec converts a collection's documents between the form the application
+// uses and the form stored on the server, e.g. to encrypt or compress
+// fields. Decode must undo Encode. Queries and update operators do not pass
+// through the codec, so fields it transforms cannot be queried or updated
+// piecemeal.
+type Codec interface {
+	Encode(doc Document) (Document, error)
+	Decode(doc Document) (Document, error)
+}
+
+// CollectionOptions are defaults applied to every operation on a
+// collection through the client
+type CollectionOptions struct {
+	// Codec, when set, converts documents on their way to and from the
+	// server, using the application's field names
+	Codec Codec
+
+	// Timestamps sets CreatedAtField on inserts and UpdatedAtField on
+	// inserts and updates, unless the write sets them itself
+	Timestamps bool
+
+	// SoftDelete makes Delete and DeleteMany set DeletedAtField instead of
+	// removing documents, and hides documents that have it from finds,
+	// counts and UpdateMany. Queries that name DeletedAtField are sent as
+	// given, so deleted documents can still be found; clear the field with
+	// Update to restore one.
+	SoftDelete bool
+
+	// Schema is checked before inserts and updates are sent, so invalid
+	// writes fail without a round trip. It takes the same rules as
+	// SetValidationRules, keyed by field path.
+	Schema map[string]FieldRule
+
+	// WriteBackMigrations stores documents upgraded by the collection's
+	// migrations when Find, FindByID or a cursor reads them, so each is
+	// migrated only once. A document whose fields changed since it was
+	// read is left alone, and so is one read with a projection.
+	WriteBackMigrations bool
+
+	// CacheTTL, when positive, keeps documents read by FindByID in memory
+	// for this long. Writes made through the client evict them; writes by
+	// other clients go unseen until the entry expires. Clients made with
+	// VerifySignatures neither use nor fill the cache.
+	CacheTTL time.Duration
+}
+
+// cachedDocument is a FindByID result kept for CacheTTL
+type cachedDocument struct {
+	doc     Document
+	expires time.Time
+}
+
+// SetCollectionOptions registers the defaults for a collection, replacing
+// any registered before. Passing nil removes them.
+func (c *Client) SetCollectionOptions(collection string, opts *CollectionOptions) error {
+	if _, err := c.collectionName(collection); err != nil {
+		return err
+	}
+
+	r := c.registry()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.documents, collection)
+	if opts == nil {
+		delete(r.options, collection)
+		return nil
+	}
+
+	for path, rule := range opts.Schema {
+		if err := ValidateFieldPath(path); err != nil {
+			return err
+		}
+		if !ruleTypes[rule.Type] {
+			return fmt.Errorf("invalid schema for field %s: unknown type %q", path, rule.Type)
+		}
+	}
+	if opts.CacheTTL < 0 {
+		return fmt.Errorf("invalid cache ttl %s: must not be negative", opts.CacheTTL)
+	}
+
+	r.options[collection] = copyCollectionOptions(opts)
+	return nil
+}
+
+// CollectionOptions returns a copy of the defaults registered for a
+// collection, or nil
+func (c *Client) CollectionOptions(collection string) *CollectionOptions {
+	opts := c.collectionOptions(collection)
+	if opts == nil {
+		return nil
+	}
+	return copyCollectionOptions(opts)
+}
+
+func copyCollectionOptions(opts *CollectionOptions) *CollectionOptions {
+	copied := *opts
+	if opts.Schema != nil {
+		copied.Schema = make(map[string]FieldRule, len(opts.Schema))
+		for path, rule := range opts.Schema {
+			copied.Schema[path] = rule
+		}
+	}
+	return &copied
+}
+
+func (c *Client) collectionOptions(collection string) *CollectionOptions {
+	if c.collections == nil {
+		return nil
+	}
+	c.collections.mu.RLock()
+	defer c.collections.mu.RUnlock()
+	return c.collections.options[collection]
+}
+
+// prepareInsert applies the collection's timestamps to a copy of doc and
+// checks it against the schema
+func (c *Client) prepareInsert(collection string, doc Document) (Document, error) {
+	doc = c.stampSchemaVersion(collection, doc)
+	opts := c.collectionOptions(collection)
+	if opts == nil {
+		return doc, nil
+	}
+
+	if opts.Timestamps {
+		now := time.Now().UnixMilli()
+		stamped := make(Document, len(doc)+2)
+		for key, value := range doc {
+			stamped[key] = value
+		}
+		if _, ok := stamped[CreatedAtField]; !ok {
+			stamped[CreatedAtField] = now
+		}
+		if _, ok := stamped[UpdatedAtField]; !ok {
+			stamped[UpdatedAtField] = now
+		}
+		doc = stamped
+	}
+
+	if len(opts.Schema) > 0 {
+		fields, err := jsonFields(doc)
+		if err != nil {
+			return nil, err
+		}
+		for path, rule := range opts.Schema {
+			value, ok := lookupField(fields, path)
+			if !ok {
+				if rule.Required {
+					return nil, schemaError(collection, path, "is required")
+				}
+				continue
+			}
+			if err := checkFieldRule(collection, path, rule, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return doc, nil
+}
+
+// prepareUpdate adds the collection's update timestamp to update and
+// checks the fields it sets or removes against the schema
+func (c *Client) prepareUpdate(collection string, update Update) (Update, error) {
+	opts := c.collectionOptions(collection)
+	if opts == nil {
+		return update, nil
+	}
+
+	operators := false
+	for key := range update {
+		if strings.HasPrefix(key, "$") {
+			operators = true
+		}
+	}
+
+	set := map[string]interface{}(update)
+	if operators {
+		set, _ = asMap(update["$set"])
+	}
+
+	if len(opts.Schema) > 0 {
+		fields, err := jsonFields(set)
+		if err != nil {
+			return nil, err
+		}
+		for path, value := range fields {
+			if rule, ok := opts.Schema[path]; ok {
+				if err := checkFieldRule(collection, path, rule, value); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if operators {
+			unset, _ := asMap(update["$unset"])
+			for path := range unset {
+				if opts.Schema[path].Required {
+					return nil, schemaError(collection, path, "is required")
+				}
+			}
+		}
+	}
+
+	if opts.Timestamps {
+		if _, ok := set[UpdatedAtField]; !ok {
+			stamped := make(map[string]interface{}, len(set)+1)
+			for key, value := range set {
+				stamped[key] = value
+			}
+			stamped[UpdatedAtField] = time.Now().UnixMilli()
+
+			if operators {
+				copied := make(Update, len(update))
+				for key, value := range update {
+					copied[key] = value
+				}
+				copied["$set"] = stamped
+				update = copied
+			} else {
+				update = Update(stamped)
+			}
+		}
+	}
+	return update, nil
+}
+
+// liveQuery restricts query to documents that are not soft-deleted, unless
+// it names the deletion field itself
+func (c *Client) liveQuery(collection string, query Query) Query {
+	opts := c.collectionOptions(collection)
+	if opts == nil || !opts.SoftDelete {
+		return query
+	}
+	if _, ok := query[DeletedAtField]; ok {
+		return query
+	}
+
+	live := make(Query, len(query)+1)
+	for key, value := range query {
+		live[key] = value
+	}
+	live[DeletedAtField] = nil
+	return live
+}
+
+// softDeletes reports whether deletes in collection only mark documents
+func (c *Client) softDeletes(collection string) bool {
+	opts := c.collectionOptions(collection)
+	return opts != nil && opts.SoftDelete
+}
+
+// encodeCodec passes a document through the collection's codec
+func (c *Client) encodeCodec(collection string, doc Document) (Document, error) {
+	opts := c.collectionOptions(collection)
+	if opts == nil || opts.Codec == nil || doc == nil {
+		return doc, nil
+	}
+	encoded, err := opts.Codec.Encode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+	return encoded, nil
+}
+
+// decodeCodec passes a stored document back through the collection's codec
+func (c *Client) decodeCodec(collection string, doc Document) (Document, error) {
+	opts := c.collectionOptions(collection)
+	if opts == nil || opts.Codec == nil || doc == nil {
+		return doc, nil
+	}
+	decoded, err := opts.Codec.Decode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode document: %w", err)
+	}
+	return decoded, nil
+}
+
+// cacheKey identifies a document as seen by this client, since derived
+// clients share the registry but may read other repositories or rows
+func (c *Client) cacheKey(name, id string) string {
+	return strings.Join([]string{c.BaseURL, c.Owner, c.Repo, c.Branch(), c.Principal(), name, id}, "\x00")
+}
+
+// cachedDocument returns a copy of a cached FindByID result. Clients that
+// verify signatures bypass the cache, since a cached document may have been
+// read by a client that did not check it.
+func (c *Client) cachedDocument(collection, name, id string) (Document, bool) {
+	opts := c.collectionOptions(collection)
+	if opts == nil || opts.CacheTTL <= 0 || c.verification != nil {
+		return nil, false
+	}
+
+	r := c.registry()
+	r.mu.RLock()
+	entry, ok := r.documents[collection][c.cacheKey(name, id)]
+	r.mu.RUnlock()
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return copyDocument(entry.doc), true
+}
+
+// cacheDocument keeps a FindByID result for the collection's CacheTTL
+func (c *Client) cacheDocument(collection, name, id string, doc Document) {
+	opts := c.collectionOptions(collection)
+	if opts == nil || opts.CacheTTL <= 0 || c.verification != nil {
+		return
+	}
+
+	r := c.registry()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	docs := r.documents[collection]
+	if docs == nil {
+		docs = make(map[string]cachedDocument)
+		r.documents[collection] = docs
+	}
+	now := time.Now()
+	for key, entry := range docs {
+		if now.After(entry.expires) {
+			delete(docs, key)
+		}
+	}
+	docs[c.cacheKey(name, id)] = cachedDocument{doc: copyDocument(doc), expires: now.Add(opts.CacheTTL)}
+}
+
+// evictDocument drops a cached document after a write to it
+func (c *Client) evictDocument(collection, name, id string) {
+	if c.collections == nil {
+		return
+	}
+	c.collections.mu.Lock()
+	defer c.collections.mu.Unlock()
+	delete(c.collections.documents[collection], c.cacheKey(name, id))
+}
+
+// evictCollection drops every cached document of a collection after a
+// write that may have touched any of them
+func (c *Client) evictCollection(collection string) {
+	if c.collections == nil {
+		return
+	}
+	c.collections.mu.Lock()
+	defer c.collections.mu.Unlock()
+	delete(c.collections.documents, collection)
+}
+
+// copyDocument deep-copies a document so cached entries cannot be changed
+// through the copies handed out
+func copyDocument(doc Document) Document {
+	if doc == nil {
+		return nil
+	}
+	return Document(copyValue(map[string]interface{}(doc)).(map[string]interface{}))
+}
+
+func copyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			copied[key] = copyValue(value)
+		}
+		return copied
+	case Document:
+		return copyDocument(v)
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, value := range v {
+			copied[i] = copyValue(value)
+		}
+		return copied
+	}
+	return v
+}
+
+// jsonFields returns fields as they will be sent, so schema types are
+// checked on their JSON form
+func jsonFields(fields map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal document: %w", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to marshal document: %w", err)
+	}
+	return decoded, nil
+}
+
+// lookupField finds a dotted field path in a document
+func lookupField(doc map[string]interface{}, path string) (interface{}, bool) {
+	if value, ok := doc[path]; ok {
+		return value, true
+	}
+	head, rest, nested := strings.Cut(path, ".")
+	if !nested {
+		return nil, false
+	}
+	inner, ok := doc[head].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return lookupField(inner, rest)
+}
+
+// checkFieldRule checks a JSON value against a schema rule
+func checkFieldRule(collection, path string, rule FieldRule, value interface{}) error {
+	if rule.Required && value == nil {
+		return schemaError(collection, path, "is required")
+	}
+	if rule.Type != "" && jsonType(value) != rule.Type {
+		return schemaError(collection, path, fmt.Sprintf("must be of type %s, not %s", rule.Type, jsonType(value)))
+	}
+	if len(rule.Enum) > 0 {
+		allowed, _ := json.Marshal(rule.Enum)
+		var options []interface{}
+		json.Unmarshal(allowed, &options)
+		for _, option := range options {
+			if fmt.Sprint(option) == fmt.Sprint(value) && jsonType(option) == jsonType(value) {
+				return nil
+			}
+		}
+		return schemaError(collection, path, fmt.Sprintf("must be one of %v", rule.Enum))
+	}
+	return nil
+}
+
+// jsonType names the type of a decoded JSON value as FieldRule does
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return "null"
+}
+
+func schemaError(collection, path, reason string) error {
+	return &ValidationError{Kind: "document for " + collection, Name: path, Reason: reason}
+}
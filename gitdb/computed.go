@@ -0,0 +1,144 @@
+package gitdb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ComputeFunc derives a field's value from a document as it is read
+type ComputeFunc func(doc Document) interface{}
+
+// computedField is a computed field registered on a collection
+type computedField struct {
+	name    string
+	compute ComputeFunc
+}
+
+// SetComputedField registers a field that is computed whenever documents
+// are read from the collection, such as fullName from firstName and
+// lastName, so derived data never has to be stored. Computed fields see the
+// document after field aliases are applied, and run in registration order so
+// later fields can use earlier ones. They are stripped from documents and
+// updates written back to the collection. Passing a nil fn removes the
+// field.
+func (c *Client) SetComputedField(collection, field string, fn ComputeFunc) {
+	r := c.registry()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fields := r.computed[collection][:0:0]
+	for _, f := range r.computed[collection] {
+		if f.name != field {
+			fields = append(fields, f)
+		}
+	}
+	if fn != nil {
+		fields = append(fields, computedField{name: field, compute: fn})
+	}
+
+	if len(fields) == 0 {
+		delete(r.computed, collection)
+		return
+	}
+	r.computed[collection] = fields
+}
+
+// ComputedFields returns the names of the computed fields registered for a
+// collection, in evaluation order
+func (c *Client) ComputedFields(collection string) []string {
+	fields := c.computedFields(collection)
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.name
+	}
+	return names
+}
+
+func (c *Client) computedFields(collection string) []computedField {
+	if c.collections == nil {
+		return nil
+	}
+	c.collections.mu.RLock()
+	defer c.collections.mu.RUnlock()
+	return c.collections.computed[collection]
+}
+
+// computeFields sets the collection's computed fields on doc
+func (c *Client) computeFields(collection string, doc Document) Document {
+	fields := c.computedFields(collection)
+	if len(fields) == 0 || doc == nil {
+		return doc
+	}
+	for _, f := range fields {
+		doc[f.name] = f.compute(doc)
+	}
+	return doc
+}
+
+// stripComputed removes the collection's computed fields from fields,
+// copying it only when there is something to remove
+func (c *Client) stripComputed(collection string, fields map[string]interface{}) map[string]interface{} {
+	computed := c.computedFields(collection)
+	if len(computed) == 0 || fields == nil {
+		return fields
+	}
+
+	var stripped map[string]interface{}
+	for _, f := range computed {
+		if _, ok := fields[f.name]; !ok {
+			continue
+		}
+		if stripped == nil {
+			stripped = make(map[string]interface{}, len(fields))
+			for key, value := range fields {
+				stripped[key] = value
+			}
+		}
+		delete(stripped, f.name)
+	}
+	if stripped == nil {
+		return fields
+	}
+	return stripped
+}
+
+// Concat returns a ComputeFunc joining the string forms of fields with sep,
+// skipping missing fields, e.g. Concat(" ", "firstName", "lastName")
+func Concat(sep string, fields ...string) ComputeFunc {
+	return func(doc Document) interface{} {
+		parts := make([]string, 0, len(fields))
+		for _, field := range fields {
+			value, ok := doc[field]
+			if !ok || value == nil {
+				continue
+			}
+			if s, ok := value.(string); ok {
+				if s != "" {
+					parts = append(parts, s)
+				}
+				continue
+			}
+			parts = append(parts, fmt.Sprint(value))
+		}
+		return strings.Join(parts, sep)
+	}
+}
+
+// Sum returns a ComputeFunc adding up numeric fields, treating missing and
+// non-numeric fields as zero
+func Sum(fields ...string) ComputeFunc {
+	return func(doc Document) interface{} {
+		total := 0.0
+		for _, field := range fields {
+			switch v := doc[field].(type) {
+			case float64:
+				total += v
+			case int:
+				total += float64(v)
+			case int64:
+				total += float64(v)
+			}
+		}
+		return total
+	}
+}
@@ -0,0 +1,156 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MaxWaitHeader bounds how long a replica holds a read carrying
+// AfterCommitHeader, in milliseconds
+const MaxWaitHeader = "X-GitDB-Max-Wait"
+
+// defaultConsistencyWait is used when ConsistencyPolicy.MaxWait is zero
+const defaultConsistencyWait = 5 * time.Second
+
+// ConsistencyPolicy configures read-your-writes consistency for clients
+// whose reads are spread across replicas with SetReadEndpoints
+type ConsistencyPolicy struct {
+	// MaxWait is how long a replica may wait to catch up with the client's
+	// last write before the read fails, 5 seconds by default
+	MaxWait time.Duration
+
+	// FallbackToPrimary sends a read that a replica could not serve in time
+	// to BaseURL instead of failing it
+	FallbackToPrimary bool
+}
+
+// ReplicationLagError is returned when a replica did not catch up with the
+// commit a read had to observe in time
+type ReplicationLagError struct {
+	Commit   string
+	Endpoint string
+	MaxWait  time.Duration
+}
+
+func (e *ReplicationLagError) Error() string {
+	if e.MaxWait > 0 {
+		return fmt.Sprintf("replica %s did not reach commit %s within %s", e.Endpoint, e.Commit, e.MaxWait)
+	}
+	return fmt.Sprintf("replica %s did not reach commit %s", e.Endpoint, e.Commit)
+}
+
+// SetReadYourWrites makes every read of the client, and of every client
+// derived from it, observe the client's own prior writes: the client
+// remembers the commit of its latest write to each repository and branch,
+// and replicas hold reads of that repository and branch until they have it. A nil policy disables this, which is the default. Sessions
+// offer the same guarantee scoped to a unit of work.
+func (c *Client) SetReadYourWrites(policy *ConsistencyPolicy) {
+	state := c.shared()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.consistency = policy
+}
+
+// writeScope is the repository and branch a commit belongs to
+type writeScope struct {
+	owner, repo, branch string
+}
+
+// writeScope returns the repository and branch the client's requests go to
+func (c *Client) writeScope() writeScope {
+	return writeScope{owner: c.Owner, repo: c.Repo, branch: c.branch}
+}
+
+// primaryOnlyKey marks requests that must not be routed to read endpoints
+type primaryOnlyKey struct{}
+
+// primaryOnly reports whether req must be sent to BaseURL
+func primaryOnly(req *http.Request) bool {
+	return req.Context().Value(primaryOnlyKey{}) != nil
+}
+
+// consistencyRequest asks the server to serve a read from a state
+// containing the client's last write
+func (c *Client) consistencyRequest(op string, req *http.Request) {
+	if !readOperations[op] || req.Header.Get(SnapshotHeader) != "" {
+		return
+	}
+
+	state := c.shared()
+	state.mu.RLock()
+	policy, lastWrite := state.consistency, state.lastWrite[c.writeScope()]
+	state.mu.RUnlock()
+	if policy == nil {
+		return
+	}
+
+	if lastWrite != "" && req.Header.Get(AfterCommitHeader) == "" {
+		req.Header.Set(AfterCommitHeader, lastWrite)
+	}
+	if req.Header.Get(AfterCommitHeader) != "" {
+		req.Header.Set(MaxWaitHeader, strconv.FormatInt(policy.maxWait().Milliseconds(), 10))
+	}
+}
+
+// consistencyResponse records the commit of a successful write and turns a
+// replica's refusal to serve a read into a ReplicationLagError, or resends
+// the read to the primary when the policy allows it
+func (c *Client) consistencyResponse(op string, req *http.Request, resp *http.Response) (*http.Response, error) {
+	state := c.shared()
+	state.mu.RLock()
+	policy := state.consistency
+	state.mu.RUnlock()
+
+	if !readOperations[op] {
+		if commit := resp.Header.Get(CommitHeader); commit != "" && resp.StatusCode < 300 {
+			state.mu.Lock()
+			if state.lastWrite == nil {
+				state.lastWrite = make(map[writeScope]string)
+			}
+			state.lastWrite[c.writeScope()] = commit
+			state.mu.Unlock()
+		}
+		return resp, nil
+	}
+
+	commit := req.Header.Get(AfterCommitHeader)
+	if commit == "" || resp.StatusCode != http.StatusPreconditionFailed {
+		return resp, nil
+	}
+
+	endpoint := c.BaseURL
+	if resp.Request != nil && resp.Request.URL != nil {
+		endpoint = resp.Request.URL.Scheme + "://" + resp.Request.URL.Host
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	lagErr := &ReplicationLagError{Commit: commit, Endpoint: endpoint}
+	if policy != nil {
+		lagErr.MaxWait = policy.maxWait()
+	}
+
+	if policy == nil || !policy.FallbackToPrimary || primaryOnly(req) {
+		return nil, lagErr
+	}
+
+	next, err := rewindRequest(req)
+	if err != nil {
+		return nil, lagErr
+	}
+	next = next.WithContext(context.WithValue(next.Context(), primaryOnlyKey{}, true))
+	next.Header.Del(AfterCommitHeader)
+	next.Header.Del(MaxWaitHeader)
+	return c.doAttempts(op, next)
+}
+
+func (p *ConsistencyPolicy) maxWait() time.Duration {
+	if p.MaxWait > 0 {
+		return p.MaxWait
+	}
+	return defaultConsistencyWait
+}
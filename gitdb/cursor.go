@@ -0,0 +1,213 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// defaultBatchSize is the number of documents a cursor fetches per request
+// unless set with Cursor.BatchSize
+const defaultBatchSize = 100
+
+// Cursor walks the documents matching a query batch by batch. The server
+// keeps the cursor on the commit current when it was opened, so documents
+// changing mid-iteration are neither skipped nor returned twice, unlike
+// skip/limit paging. A batch is only fetched once the previous one has been
+// consumed, bound by the context passed to the call to Next that needs it.
+//
+//	cur := client.FindAll("users", gitdb.Query{"active": true})
+//	defer cur.Close(ctx)
+//	for cur.Next(ctx) {
+//		doc := cur.Document()
+//		...
+//	}
+//	if err := cur.Err(); err != nil {
+//		...
+//	}
+//
+// Decode converts each document into a struct.
+type Cursor struct {
+	client     *Client
+	collection string
+	query      Query
+	opts       FindOptions
+	batchSize  int
+
+	id        string
+	batch     []Document
+	pos       int
+	current   Document
+	opened    bool
+	exhausted bool
+	err       error
+}
+
+// cursorPage is a batch of documents returned by the cursor API
+type cursorPage struct {
+	ID        string     `json:"id"`
+	Documents []Document `json:"documents"`
+	Exhausted bool       `json:"exhausted"`
+}
+
+// FindAll returns a cursor over all documents in collection matching
+// query. Nothing is sent until the first call to Next.
+func (c *Client) FindAll(collection string, query Query) *Cursor {
+	return &Cursor{
+		client:     c,
+		collection: collection,
+		query:      query,
+		batchSize:  defaultBatchSize,
+	}
+}
+
+// FindCursor returns a cursor over the documents in collection matching
+// query, sorted, paged and compared as opts specifies. Nothing is sent
+// until the first call to Next.
+//
+//	cur := client.FindCursor("users", gitdb.Query{"active": true}, gitdb.FindOptions{Sort: []gitdb.SortField{gitdb.Asc("name")}})
+//	defer cur.Close(ctx)
+//	for cur.Next(ctx) {
+//		var user User
+//		if err := cur.Decode(&user); err != nil {
+//			...
+//		}
+//	}
+//	if err := cur.Err(); err != nil {
+//		...
+//	}
+func (c *Client) FindCursor(collection string, query Query, opts FindOptions) *Cursor {
+	return &Cursor{
+		client:     c,
+		collection: collection,
+		query:      query,
+		opts:       opts,
+		batchSize:  defaultBatchSize,
+	}
+}
+
+// BatchSize sets how many documents the cursor fetches per request. It
+// must be called before the first call to Next.
+func (cur *Cursor) BatchSize(n int) *Cursor {
+	if n > 0 && !cur.opened {
+		cur.batchSize = n
+	}
+	return cur
+}
+
+// Sort orders the documents by each field in turn, then by _id unless it
+// is one of the fields. It must be called before the first call to Next.
+func (cur *Cursor) Sort(fields ...SortField) *Cursor {
+	if !cur.opened {
+		cur.opts.Sort = fields
+	}
+	return cur
+}
+
+// Next advances to the next document, fetching the next batch when the
+// current one is used up, with ctx bounding that request. It returns false
+// when the documents are exhausted or an error occurred; check Err to tell
+// them apart.
+func (cur *Cursor) Next(ctx context.Context) bool {
+	if cur.err != nil {
+		return false
+	}
+
+	for cur.pos >= len(cur.batch) {
+		if cur.opened && (cur.exhausted || cur.id == "") {
+			cur.current = nil
+			return false
+		}
+		if err := cur.fetch(ctx); err != nil {
+			cur.err = err
+			cur.current = nil
+			return false
+		}
+	}
+
+	cur.current = cur.batch[cur.pos]
+	cur.pos++
+	return true
+}
+
+// Document returns the document Next advanced to
+func (cur *Cursor) Document() Document {
+	return cur.current
+}
+
+// Decode decodes the document Next advanced to into v, through its JSON
+// encoding
+func (cur *Cursor) Decode(v interface{}) error {
+	if cur.current == nil {
+		return fmt.Errorf("failed to decode document: cursor is not on a document")
+	}
+	return cur.current.Decode(v)
+}
+
+// Err returns the error that stopped the cursor, if any
+func (cur *Cursor) Err() error {
+	return cur.err
+}
+
+// Close releases the cursor on the server, with ctx bounding the request.
+// It is safe to call more than once and after the cursor is exhausted.
+func (cur *Cursor) Close(ctx context.Context) error {
+	if cur.id == "" || cur.exhausted {
+		cur.exhausted = true
+		return nil
+	}
+	cur.exhausted = true
+
+	url := fmt.Sprintf("%s/api/v1/cursors/%s", cur.client.BaseURL, pathSegment(cur.id))
+	return cur.client.doJSON(ctx, "DELETE", url, nil, nil, http.StatusOK, "close cursor")
+}
+
+// fetch opens the cursor or retrieves its next batch
+func (cur *Cursor) fetch(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var page cursorPage
+	if !cur.opened {
+		name, err := cur.client.collectionName(cur.collection)
+		if err != nil {
+			return err
+		}
+
+		url := fmt.Sprintf("%s/api/v1/collections/%s/documents/cursor", cur.client.BaseURL, name)
+		options, err := cur.client.encodeFindOptions(cur.collection, cur.opts)
+		if err != nil {
+			return err
+		}
+		data := map[string]interface{}{
+			"query":     cur.client.encodeQuery(cur.collection, cur.client.liveQuery(cur.collection, cur.query)),
+			"batchSize": cur.batchSize,
+		}
+		for key, value := range options {
+			data[key] = value
+		}
+		if err := cur.client.doJSON(ctx, "POST", url, data, &page, http.StatusOK, "open cursor"); err != nil {
+			return err
+		}
+		cur.opened = true
+	} else {
+		url := fmt.Sprintf("%s/api/v1/cursors/%s?batchSize=%d", cur.client.BaseURL, pathSegment(cur.id), cur.batchSize)
+		if err := cur.client.doJSON(ctx, "GET", url, nil, &page, http.StatusOK, "fetch cursor"); err != nil {
+			return err
+		}
+	}
+
+	if err := cur.client.verifyDocuments(cur.collection, page.Documents); err != nil {
+		return err
+	}
+	if err := cur.client.readDocuments(ctx, cur.collection, page.Documents, len(cur.opts.Projection) > 0); err != nil {
+		return err
+	}
+
+	cur.id = page.ID
+	cur.batch = page.Documents
+	cur.pos = 0
+	cur.exhausted = page.Exhausted
+	return nil
+}
@@ -0,0 +1,292 @@
+// Package datagen produces realistic fake documents from a template and
+// bulk-loads them into GitDB, for demos, load tests and reproducing bugs at
+// scale.
+//
+// A template is a document whose string values may contain placeholders:
+//
+//	datagen.Template{
+//	    "name":    "{{name}}",
+//	    "email":   "{{email}}",
+//	    "age":     "{{int 18 90}}",
+//	    "joined":  "{{date 2020-01-01 2024-12-31}}",
+//	    "plan":    "{{oneOf free pro enterprise}}",
+//	    "address": map[string]interface{}{"city": "{{city}}", "country": "{{country}}"},
+//	    "tags":    datagen.Repeat{Min: 1, Max: 3, Item: "{{word}}"},
+//	}
+//
+// A value that is exactly one placeholder is replaced by a typed value (int,
+// float, bool); placeholders embedded in longer strings are formatted into the
+// string. Name, email and username placeholders within one document describe
+// the same person.
+package datagen
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb"
+)
+
+// Template describes the shape of generated documents
+type Template map[string]interface{}
+
+// Repeat generates an array of between Min and Max items from Item
+type Repeat struct {
+	Min  int
+	Max  int
+	Item interface{}
+}
+
+// Func generates a value with custom logic
+type Func func(g *Generator) interface{}
+
+// Generator produces documents. A Generator is not safe for concurrent use.
+type Generator struct {
+	rng    *rand.Rand
+	seq    int
+	person *person
+}
+
+type person struct {
+	first, last, username string
+}
+
+// New returns a generator seeded with seed, so the same seed and template
+// always produce the same documents
+func New(seed int64) *Generator {
+	return &Generator{rng: rand.New(rand.NewSource(seed))}
+}
+
+// Rand exposes the generator's random source to custom Funcs
+func (g *Generator) Rand() *rand.Rand {
+	return g.rng
+}
+
+// Document generates one document from tpl
+func (g *Generator) Document(tpl Template) gitdb.Document {
+	g.seq++
+	g.person = nil
+	return gitdb.Document(g.object(tpl))
+}
+
+// Documents generates n documents from tpl
+func (g *Generator) Documents(tpl Template, n int) []gitdb.Document {
+	docs := make([]gitdb.Document, n)
+	for i := range docs {
+		docs[i] = g.Document(tpl)
+	}
+	return docs
+}
+
+func (g *Generator) object(tpl map[string]interface{}) map[string]interface{} {
+	// Walk keys in sorted order so the random stream, and therefore the
+	// output, depends only on the seed
+	keys := make([]string, 0, len(tpl))
+	for key := range tpl {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	out := make(map[string]interface{}, len(tpl))
+	for _, key := range keys {
+		out[key] = g.value(tpl[key])
+	}
+	return out
+}
+
+func (g *Generator) value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return g.expand(v)
+	case Template:
+		return g.object(v)
+	case map[string]interface{}:
+		return g.object(v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = g.value(item)
+		}
+		return out
+	case Repeat:
+		n := v.Min
+		if v.Max > v.Min {
+			n += g.rng.Intn(v.Max - v.Min + 1)
+		}
+		out := make([]interface{}, n)
+		for i := range out {
+			out[i] = g.value(v.Item)
+		}
+		return out
+	case Func:
+		return v(g)
+	case func(*Generator) interface{}:
+		return v(g)
+	}
+	return v
+}
+
+var placeholder = regexp.MustCompile(`\{\{\s*([^}]+?)\s*\}\}`)
+
+func (g *Generator) expand(s string) interface{} {
+	if m := placeholder.FindStringSubmatch(s); m != nil && m[0] == s {
+		return g.generate(m[1])
+	}
+	return placeholder.ReplaceAllStringFunc(s, func(match string) string {
+		return fmt.Sprint(g.generate(placeholder.FindStringSubmatch(match)[1]))
+	})
+}
+
+// generate evaluates a single placeholder expression
+func (g *Generator) generate(expr string) interface{} {
+	args := strings.Fields(expr)
+	name, args := args[0], args[1:]
+
+	switch name {
+	case "firstName":
+		return g.who().first
+	case "lastName":
+		return g.who().last
+	case "name":
+		p := g.who()
+		return p.first + " " + p.last
+	case "username":
+		return g.who().username
+	case "email":
+		p := g.who()
+		return p.username + "@" + g.pick(domains)
+	case "phone":
+		return fmt.Sprintf("+1-%03d-%03d-%04d", 200+g.rng.Intn(800), g.rng.Intn(1000), g.rng.Intn(10000))
+	case "street":
+		return fmt.Sprintf("%d %s %s", 1+g.rng.Intn(9999), g.pick(lastNames), g.pick(streetSuffixes))
+	case "city":
+		return g.pick(cities)
+	case "country":
+		return g.pick(countries)
+	case "company":
+		return g.pick(lastNames) + " " + g.pick(companySuffixes)
+	case "word":
+		return g.pick(words)
+	case "sentence":
+		n := 6 + g.rng.Intn(8)
+		parts := make([]string, n)
+		for i := range parts {
+			parts[i] = g.pick(words)
+		}
+		sentence := strings.Join(parts, " ") + "."
+		return strings.ToUpper(sentence[:1]) + sentence[1:]
+	case "uuid":
+		b := make([]byte, 16)
+		g.rng.Read(b)
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+	case "bool":
+		return g.rng.Intn(2) == 1
+	case "seq":
+		return g.seq
+	case "int":
+		lo, hi := g.intRange(args, 0, 100)
+		return lo + g.rng.Intn(hi-lo+1)
+	case "float":
+		lo, hi := g.floatRange(args, 0, 1)
+		return lo + g.rng.Float64()*(hi-lo)
+	case "date":
+		return g.date(args).Format("2006-01-02")
+	case "timestamp":
+		return g.date(args).Format(time.RFC3339)
+	case "oneOf":
+		if len(args) == 0 {
+			return ""
+		}
+		return g.pick(args)
+	}
+	return "{{" + expr + "}}"
+}
+
+func (g *Generator) who() *person {
+	if g.person == nil {
+		first, last := g.pick(firstNames), g.pick(lastNames)
+		g.person = &person{
+			first:    first,
+			last:     last,
+			username: fmt.Sprintf("%s.%s%d", strings.ToLower(first), strings.ToLower(last), g.rng.Intn(100)),
+		}
+	}
+	return g.person
+}
+
+func (g *Generator) pick(options []string) string {
+	return options[g.rng.Intn(len(options))]
+}
+
+func (g *Generator) intRange(args []string, lo, hi int) (int, int) {
+	if len(args) == 2 {
+		a, errA := strconv.Atoi(args[0])
+		b, errB := strconv.Atoi(args[1])
+		if errA == nil && errB == nil && a <= b {
+			return a, b
+		}
+	}
+	return lo, hi
+}
+
+func (g *Generator) floatRange(args []string, lo, hi float64) (float64, float64) {
+	if len(args) == 2 {
+		a, errA := strconv.ParseFloat(args[0], 64)
+		b, errB := strconv.ParseFloat(args[1], 64)
+		if errA == nil && errB == nil && a <= b {
+			return a, b
+		}
+	}
+	return lo, hi
+}
+
+// date picks a time between the two YYYY-MM-DD arguments, defaulting to
+// 2020 through 2024
+func (g *Generator) date(args []string) time.Time {
+	from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if len(args) == 2 {
+		a, errA := time.Parse("2006-01-02", args[0])
+		b, errB := time.Parse("2006-01-02", args[1])
+		if errA == nil && errB == nil && a.Before(b) {
+			from, to = a, b
+		}
+	}
+	return from.Add(time.Duration(g.rng.Int63n(int64(to.Sub(from)))))
+}
+
+var (
+	firstNames = []string{
+		"James", "Mary", "Robert", "Patricia", "John", "Jennifer", "Michael", "Linda", "David", "Elizabeth",
+		"Priya", "Arjun", "Wei", "Mei", "Carlos", "Sofia", "Ahmed", "Fatima", "Kenji", "Yuki",
+		"Olga", "Ivan", "Amara", "Kwame", "Lucas", "Emma", "Noah", "Olivia", "Liam", "Ava",
+	}
+	lastNames = []string{
+		"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis", "Rodriguez", "Martinez",
+		"Kumar", "Sharma", "Chen", "Wang", "Silva", "Santos", "Hassan", "Ali", "Tanaka", "Sato",
+		"Ivanova", "Petrov", "Okafor", "Mensah", "Muller", "Schmidt", "Rossi", "Dubois", "Nguyen", "Kim",
+	}
+	domains         = []string{"example.com", "example.org", "mail.test", "inbox.test", "corp.example"}
+	streetSuffixes  = []string{"Street", "Avenue", "Road", "Lane", "Boulevard", "Drive", "Way"}
+	companySuffixes = []string{"Inc", "LLC", "Group", "Labs", "Systems", "Partners", "Holdings"}
+	cities          = []string{
+		"New York", "London", "Bengaluru", "Chennai", "Tokyo", "Berlin", "Paris", "Sao Paulo", "Lagos", "Toronto",
+		"Sydney", "Singapore", "Nairobi", "Madrid", "Seoul", "Mexico City", "Amsterdam", "Dubai",
+	}
+	countries = []string{
+		"United States", "United Kingdom", "India", "Japan", "Germany", "France", "Brazil", "Nigeria", "Canada",
+		"Australia", "Singapore", "Kenya", "Spain", "South Korea", "Mexico", "Netherlands",
+	}
+	words = []string{
+		"alpha", "bright", "cloud", "delta", "engine", "forest", "garden", "harbor", "island", "jungle",
+		"kernel", "lumen", "meadow", "nebula", "orbit", "prism", "quartz", "river", "summit", "tundra",
+		"umbra", "vertex", "willow", "xenon", "yonder", "zephyr", "amber", "cobalt", "ember", "frost",
+	}
+)
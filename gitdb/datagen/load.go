@@ -0,0 +1,96 @@
+package datagen
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb"
+)
+
+// LoadOptions tunes Load
+type LoadOptions struct {
+	// Seed makes the generated data reproducible
+	Seed int64
+	// Concurrency is the number of parallel inserts (default 4)
+	Concurrency int
+	// Progress, when set, is called after every successful insert with the
+	// number of documents loaded so far
+	Progress func(loaded int)
+}
+
+// Load generates n documents from tpl and inserts them into collection. It
+// returns the IDs of the inserted documents in generation order. On error the
+// IDs loaded so far are returned alongside it.
+func Load(ctx context.Context, client *gitdb.Client, collection string, tpl Template, n int, opts LoadOptions) ([]string, error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+
+	docs := New(opts.Seed).Documents(tpl, n)
+	ids := make([]string, n)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan int)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		loaded   int
+		firstErr error
+	)
+	for w := 0; w < opts.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				id, err := client.Insert(ctx, collection, docs[i])
+
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = fmt.Errorf("failed to load document %d: %w", i, err)
+						cancel()
+					}
+				} else {
+					ids[i] = id
+					loaded++
+					if opts.Progress != nil {
+						opts.Progress(loaded)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for i := range docs {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	if firstErr != nil {
+		return compact(ids), firstErr
+	}
+	return ids, nil
+}
+
+func compact(ids []string) []string {
+	out := ids[:0]
+	for _, id := range ids {
+		if id != "" {
+			out = append(out, id)
+		}
+	}
+	return out
+}
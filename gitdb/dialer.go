@@ -0,0 +1,147 @@
+package gitdb
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDialTimeout = 30 * time.Second
+	defaultKeepAlive   = 30 * time.Second
+)
+
+// WithDialer makes the client open connections with dial, for example to
+// route through a proxy or a custom network stack
+func WithDialer(dial DialFunc) Option {
+	return func(o *options) {
+		o.dial = dial
+	}
+}
+
+// WithDNSCache caches resolved server addresses for ttl, for environments
+// where DNS is slow or flaky. Failed lookups fall back to the last known
+// addresses.
+func WithDNSCache(ttl time.Duration) Option {
+	return func(o *options) {
+		o.dnsCache = &dnsCache{ttl: ttl, entries: make(map[string]dnsEntry)}
+	}
+}
+
+// WithStaticHosts maps host names to fixed addresses, bypassing DNS for
+// them, e.g. {"gitdb.internal": "10.0.0.12"}
+func WithStaticHosts(hosts map[string]string) Option {
+	return func(o *options) {
+		o.staticHosts = make(map[string]string, len(hosts))
+		for host, addr := range hosts {
+			o.staticHosts[host] = addr
+		}
+	}
+}
+
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+// dnsCache remembers host lookups for a fixed time
+type dnsCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+}
+
+// lookup resolves host, serving cached addresses while they are fresh and
+// stale ones when a new lookup fails
+func (d *dnsCache) lookup(ctx context.Context, host string) ([]string, error) {
+	d.mu.Lock()
+	entry, ok := d.entries[host]
+	d.mu.Unlock()
+
+	if ok && time.Now().Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		if ok {
+			return entry.addrs, nil
+		}
+		return nil, err
+	}
+
+	d.mu.Lock()
+	d.entries[host] = dnsEntry{addrs: addrs, expires: time.Now().Add(d.ttl)}
+	d.mu.Unlock()
+
+	return addrs, nil
+}
+
+// hostResolver resolves host names through the static host table and the
+// DNS cache, as resolvingDialer does
+type hostResolver struct {
+	static map[string]string
+	cache  *dnsCache
+	// custom is set when a custom dialer resolves the names that are
+	// neither static nor cached itself
+	custom bool
+}
+
+// lookup resolves host. It returns no addresses and no error for names
+// that a custom dialer resolves.
+func (r *hostResolver) lookup(ctx context.Context, host string) ([]string, error) {
+	if r == nil {
+		return net.DefaultResolver.LookupHost(ctx, host)
+	}
+	if mapped, ok := r.static[host]; ok {
+		return []string{mapped}, nil
+	}
+	if r.cache != nil {
+		return r.cache.lookup(ctx, host)
+	}
+	if r.custom {
+		return nil, nil
+	}
+	return net.DefaultResolver.LookupHost(ctx, host)
+}
+
+// resolvingDialer wraps dial so host names are resolved through the static
+// host table and the DNS cache before dialing
+func resolvingDialer(dial DialFunc, static map[string]string, cache *dnsCache) DialFunc {
+	if static == nil && cache == nil {
+		return dial
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return dial(ctx, network, addr)
+		}
+
+		var addrs []string
+		if mapped, ok := static[host]; ok {
+			addrs = []string{mapped}
+		} else if cache != nil {
+			if addrs, err = cache.lookup(ctx, host); err != nil {
+				return nil, err
+			}
+		} else {
+			return dial(ctx, network, addr)
+		}
+
+		var lastErr error
+		for _, ip := range addrs {
+			conn, err := dial(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+		if lastErr == nil {
+			lastErr = errors.New("no addresses for " + host)
+		}
+		return nil, lastErr
+	}
+}
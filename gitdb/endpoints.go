@@ -0,0 +1,193 @@
+package gitdb
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LoadBalancing selects how reads are spread across endpoints
+type LoadBalancing int
+
+const (
+	// RoundRobin sends reads to each endpoint in turn
+	RoundRobin LoadBalancing = iota
+
+	// LeastLatency sends reads to the endpoint with the lowest recent
+	// latency, occasionally probing the others
+	LeastLatency
+)
+
+// latencyDecay weights new samples in an endpoint's moving average latency
+const latencyDecay = 0.2
+
+// exploreEvery makes LeastLatency send one in this many reads round-robin so
+// that slower endpoints get a chance to show they recovered
+const exploreEvery = 20
+
+// endpoint is a server base URL reads can be routed to
+type endpoint struct {
+	pool    *endpointPool
+	base    string
+	latency time.Duration // exponentially weighted moving average
+	samples int64
+	evicted bool // set by health checks
+}
+
+// endpointPool balances reads across a set of endpoints
+type endpointPool struct {
+	mu        sync.Mutex
+	strategy  LoadBalancing
+	endpoints []*endpoint
+	next      int
+	picks     int
+}
+
+// SetReadEndpoints spreads reads across the given server base URLs, for
+// example GitDB replicas serving the same repository. Writes keep going to
+// BaseURL. Calling it without URLs sends reads back to BaseURL.
+func (c *Client) SetReadEndpoints(strategy LoadBalancing, urls ...string) error {
+	pool, err := newEndpointPool(strategy, urls)
+	if err != nil {
+		return err
+	}
+
+	state := c.shared()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.readEndpoints = pool
+	return nil
+}
+
+// newEndpointPool validates urls and builds a pool of them, nil when there
+// are none
+func newEndpointPool(strategy LoadBalancing, urls []string) (*endpointPool, error) {
+	if len(urls) == 0 {
+		return nil, nil
+	}
+	if strategy != RoundRobin && strategy != LeastLatency {
+		return nil, fmt.Errorf("unknown load balancing strategy %d", strategy)
+	}
+
+	pool := &endpointPool{strategy: strategy}
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid endpoint URL %q", raw)
+		}
+		pool.endpoints = append(pool.endpoints, &endpoint{pool: pool, base: strings.TrimSuffix(raw, "/")})
+	}
+	return pool, nil
+}
+
+// bases returns the base URLs of the pool's endpoints
+func (p *endpointPool) bases() []string {
+	bases := make([]string, len(p.endpoints))
+	for i, ep := range p.endpoints {
+		bases[i] = ep.base
+	}
+	return bases
+}
+
+// route picks the endpoint an attempt of op is sent to, returning the
+// request rewritten for it. Reads go to the read endpoints when configured;
+// everything else goes to the active failover endpoint. The returned
+// endpoint is nil unless a read endpoint was picked.
+func (c *Client) route(op string, req *http.Request) (*http.Request, *endpoint) {
+	state := c.shared()
+	state.mu.RLock()
+	pool := state.readEndpoints
+	fo := state.failover
+	state.mu.RUnlock()
+
+	if pool != nil && readOperations[op] && !primaryOnly(req) {
+		if ep := pool.pick(); ep != nil {
+			if rewritten, ok := rewriteBase(req, c.BaseURL, ep.base); ok {
+				return rewritten, ep
+			}
+		}
+	}
+
+	if fo != nil {
+		return fo.route(req, c.BaseURL), nil
+	}
+	return req, nil
+}
+
+// pick selects the endpoint for the next read
+func (p *endpointPool) pick() *endpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	candidates := make([]*endpoint, 0, len(p.endpoints))
+	for _, ep := range p.endpoints {
+		if !ep.evicted {
+			candidates = append(candidates, ep)
+		}
+	}
+	if len(candidates) == 0 {
+		// Everything failed its probes: better to try than to refuse
+		candidates = p.endpoints
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	p.picks++
+	if p.strategy == LeastLatency && p.picks%exploreEvery != 0 {
+		var best *endpoint
+		for _, ep := range candidates {
+			if ep.samples == 0 {
+				return ep // measure unknown endpoints first
+			}
+			if best == nil || ep.latency < best.latency {
+				best = ep
+			}
+		}
+		return best
+	}
+
+	ep := candidates[p.next%len(candidates)]
+	p.next++
+	return ep
+}
+
+// observe records the latency of a completed attempt against the endpoint
+func (ep *endpoint) observe(latency time.Duration, failed bool) {
+	ep.pool.mu.Lock()
+	defer ep.pool.mu.Unlock()
+
+	if failed {
+		// Treat failures as slow so LeastLatency steers away from them
+		latency *= 10
+	}
+	if ep.samples == 0 {
+		ep.latency = latency
+	} else {
+		ep.latency = time.Duration(float64(ep.latency)*(1-latencyDecay) + float64(latency)*latencyDecay)
+	}
+	ep.samples++
+}
+
+// rewriteBase returns a copy of req sent to newBase instead of oldBase
+func rewriteBase(req *http.Request, oldBase, newBase string) (*http.Request, bool) {
+	raw := req.URL.String()
+	oldBase = strings.TrimSuffix(oldBase, "/")
+	if !strings.HasPrefix(raw, oldBase) {
+		return nil, false
+	}
+
+	u, err := url.Parse(newBase + strings.TrimPrefix(raw, oldBase))
+	if err != nil {
+		return nil, false
+	}
+
+	rewritten := req.Clone(req.Context())
+	rewritten.Body = req.Body
+	rewritten.URL = u
+	rewritten.Host = ""
+	return rewritten, true
+}
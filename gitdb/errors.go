@@ -0,0 +1,112 @@
+package gitdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Errors matched by the *APIError of a failed request, according to its
+// HTTP status, so callers can tell failures apart with errors.Is
+var (
+	// ErrNotFound matches 404 responses, such as a missing document or
+	// collection. FindOne and FindByID of a soft-deleted document return
+	// it too.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict matches 409 and 412 responses, such as an insert with
+	// an ID that is taken or a write whose precondition no longer holds
+	ErrConflict = errors.New("conflict")
+	// ErrUnauthorized matches 401 and 403 responses: the token is
+	// missing, invalid or not allowed to perform the operation
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrRateLimited matches 429 responses that outlasted the client's
+	// retry policy
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// APIError is an error response from the server. Operations return it
+// wrapped, so use errors.As to inspect it:
+//
+//	var apiErr *gitdb.APIError
+//	if errors.As(err, &apiErr) && apiErr.StatusCode >= 500 {
+//		...
+//	}
+type APIError struct {
+	// StatusCode is the HTTP status of the response
+	StatusCode int
+	// Code is the server's machine-readable error code, empty if it sent
+	// none
+	Code string
+	// Message is the server's description of the error, or the response
+	// body when it is not a JSON error object
+	Message string
+	// Body is the raw response body
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Is reports whether the error's status corresponds to target, one of
+// ErrNotFound, ErrConflict, ErrUnauthorized or ErrRateLimited
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict || e.StatusCode == http.StatusPreconditionFailed
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// newAPIError reads an error response. The server reports errors as
+// {"error": "message", "code": "..."} or {"error": {"code": "...",
+// "message": "..."}}; other bodies are kept as the message verbatim.
+func newAPIError(resp *http.Response) *APIError {
+	body, _ := io.ReadAll(resp.Body)
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(body)),
+		Body:       body,
+	}
+
+	var payload struct {
+		Error   json.RawMessage `json:"error"`
+		Code    string          `json:"code"`
+		Message string          `json:"message"`
+	}
+	if json.Unmarshal(body, &payload) != nil {
+		return apiErr
+	}
+
+	var nested struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	message, code := payload.Message, payload.Code
+	if json.Unmarshal(payload.Error, &message) != nil && json.Unmarshal(payload.Error, &nested) == nil {
+		if nested.Message != "" {
+			message = nested.Message
+		}
+		if nested.Code != "" {
+			code = nested.Code
+		}
+	}
+
+	if message != "" {
+		apiErr.Message = message
+	}
+	apiErr.Code = code
+	return apiErr
+}
@@ -0,0 +1,129 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ExpiryOptions configures an expiry watcher
+type ExpiryOptions struct {
+	// Field holds the expiry time as Unix milliseconds. It may be a dotted
+	// path into a nested object, such as "session.expiresAt".
+	Field string
+
+	// Interval between scans for expired documents, one minute by default
+	Interval time.Duration
+
+	// Keep leaves expired documents in place after the handler succeeds
+	// instead of deleting them. Kept documents are marked with the expiry
+	// they were handled for in the _expiredAt field and are not handed over
+	// again until their expiry changes and passes once more.
+	Keep bool
+
+	// OnError is called with scan and delete failures; they are otherwise
+	// dropped and retried on the next scan
+	OnError func(error)
+}
+
+// expiredAtField marks documents kept by an expiry watcher with the expiry
+// they were handled for
+const expiredAtField = "_expiredAt"
+
+// ExpiryWatcher runs a handler for documents whose expiry time has passed
+type ExpiryWatcher struct {
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// WatchExpired starts a background watcher that calls handler for each
+// document of collection whose opts.Field lies in the past, then deletes it.
+// Handling is at-least-once: if handler returns an error the document is
+// kept and handed over again on the next scan, and watchers on several
+// processes may both handle a document that expires while they scan.
+func (c *Client) WatchExpired(collection string, opts ExpiryOptions, handler func(Document) error) (*ExpiryWatcher, error) {
+	if err := ValidateFieldPath(opts.Field); err != nil {
+		return nil, err
+	}
+	if _, err := c.collectionName(collection); err != nil {
+		return nil, err
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+
+	w := &ExpiryWatcher{stop: make(chan struct{}), done: make(chan struct{})}
+	c.shared().lifecycle.onClose(func(ctx context.Context) error {
+		return waitContext(ctx, w.Stop)
+	})
+
+	go func() {
+		defer close(w.done)
+
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+
+		for {
+			c.handleExpired(context.Background(), collection, opts, handler)
+
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return w, nil
+}
+
+// Stop stops the watcher and waits for an in-progress scan to finish
+func (w *ExpiryWatcher) Stop() {
+	w.once.Do(func() { close(w.stop) })
+	<-w.done
+}
+
+func (c *Client) handleExpired(ctx context.Context, collection string, opts ExpiryOptions, handler func(Document) error) {
+	report := func(err error) {
+		if opts.OnError != nil {
+			opts.OnError(err)
+		}
+	}
+
+	expired, err := c.Find(ctx, collection, Query{opts.Field: Query{"$lte": time.Now().UnixMilli()}})
+	if err != nil {
+		report(fmt.Errorf("failed to scan %s for expired documents: %w", collection, err))
+		return
+	}
+
+	for _, doc := range expired {
+		// opts.Field may be a dotted path into a nested object
+		expiry, _ := lookupField(doc, opts.Field)
+		if opts.Keep && doc[expiredAtField] != nil && reflect.DeepEqual(doc[expiredAtField], expiry) {
+			continue
+		}
+		if err := handler(doc); err != nil {
+			report(err)
+			continue
+		}
+
+		id, ok := doc["_id"].(string)
+		if !ok {
+			continue
+		}
+		if opts.Keep {
+			// Only mark if the expiry was not changed while we handled it
+			if _, err := c.UpdateMany(ctx, collection, Query{"_id": id, opts.Field: expiry}, Update{"$set": Document{expiredAtField: expiry}}); err != nil {
+				report(fmt.Errorf("failed to mark expired document %s: %w", id, err))
+			}
+			continue
+		}
+		// Only delete if the expiry was not extended while we handled it
+		if _, err := c.DeleteMany(ctx, collection, Query{"_id": id, opts.Field: expiry}); err != nil {
+			report(fmt.Errorf("failed to delete expired document %s: %w", id, err))
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package gitdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpiryWithNestedField(t *testing.T) {
+	expiresAt := float64(time.Now().Add(-time.Minute).UnixMilli())
+	var updates, deletes []map[string]interface{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/documents/find"):
+			json.NewEncoder(w).Encode([]Document{
+				{"_id": "s1", "session": map[string]interface{}{"expiresAt": expiresAt}},
+			})
+		case strings.HasSuffix(r.URL.Path, "/documents/update-many"):
+			updates = append(updates, body)
+			json.NewEncoder(w).Encode(map[string]interface{}{"matchedCount": 1, "modifiedCount": 1})
+		case strings.HasSuffix(r.URL.Path, "/documents/delete-many"):
+			deletes = append(deletes, body)
+			json.NewEncoder(w).Encode(map[string]interface{}{"deletedCount": 1})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient("token", "owner", "repo")
+	c.BaseURL = srv.URL
+	ctx := context.Background()
+	report := func(err error) { t.Errorf("expiry: %v", err) }
+	handled := 0
+	handler := func(Document) error { handled++; return nil }
+
+	c.handleExpired(ctx, "sessions", ExpiryOptions{Field: "session.expiresAt", OnError: report}, handler)
+	want := []map[string]interface{}{{"_id": "s1", "session.expiresAt": expiresAt}}
+	if !reflect.DeepEqual(deletes, want) {
+		t.Errorf("deletes = %v, want %v", deletes, want)
+	}
+
+	c.handleExpired(ctx, "sessions", ExpiryOptions{Field: "session.expiresAt", Keep: true, OnError: report}, handler)
+	if len(updates) != 1 || !reflect.DeepEqual(updates[0]["query"], want[0]) {
+		t.Fatalf("updates = %v, want one conditioned on %v", updates, want[0])
+	}
+	if set := updates[0]["update"].(map[string]interface{})["$set"]; !reflect.DeepEqual(set, map[string]interface{}{expiredAtField: expiresAt}) {
+		t.Errorf("mark = %v, want %s set to the expiry", set, expiredAtField)
+	}
+	if handled != 2 {
+		t.Errorf("handled %d documents, want 2", handled)
+	}
+}
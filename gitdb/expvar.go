@@ -0,0 +1,57 @@
+package gitdb
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+var (
+	expvarOnce sync.Once
+	expvarRoot *expvar.Map
+)
+
+// PublishExpvar publishes the client's metrics through expvar as
+// gitdb.<name>, so existing /debug/vars scrapers pick them up. Publishing
+// another client under the same name replaces the previous one.
+func (c *Client) PublishExpvar(name string) {
+	expvarOnce.Do(func() {
+		if existing, ok := expvar.Get("gitdb").(*expvar.Map); ok {
+			expvarRoot = existing
+			return
+		}
+		expvarRoot = expvar.NewMap("gitdb")
+	})
+
+	expvarRoot.Set(name, expvar.Func(func() interface{} {
+		return expvarMetrics(c.Metrics())
+	}))
+}
+
+// expvarMetrics converts a metrics snapshot into JSON-friendly counters and
+// millisecond gauges
+func expvarMetrics(m Metrics) map[string]interface{} {
+	ms := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+
+	operations := make(map[string]interface{}, len(m.Operations))
+	for name, op := range m.Operations {
+		operations[name] = map[string]interface{}{
+			"requests":     op.Requests,
+			"errors":       op.Errors,
+			"clientErrors": op.ClientErrors,
+			"retries":      op.Retries,
+			"meanMs":       ms(op.Mean),
+			"p50Ms":        ms(op.P50),
+			"p90Ms":        ms(op.P90),
+			"p99Ms":        ms(op.P99),
+			"maxMs":        ms(op.Max),
+		}
+	}
+
+	return map[string]interface{}{
+		"since":      m.Since.UTC().Format(time.RFC3339),
+		"operations": operations,
+	}
+}
@@ -0,0 +1,194 @@
+package gitdb
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultFailoverCheckInterval is how often a failed-over client checks
+// whether the primary has recovered
+const defaultFailoverCheckInterval = 30 * time.Second
+
+// failoverHealthTimeout bounds each health check made by the failover logic
+const failoverHealthTimeout = 5 * time.Second
+
+// FailoverOptions configures automatic failover from the primary endpoint
+// (the client's BaseURL) to secondary endpoints
+type FailoverOptions struct {
+	// Secondaries are tried in order when the primary is unhealthy
+	Secondaries []string
+
+	// CheckInterval is how often the primary is checked for recovery while
+	// the client is failed over, 30 seconds by default
+	CheckInterval time.Duration
+
+	// OnEvent is called whenever the active endpoint changes
+	OnEvent func(FailoverEvent)
+}
+
+// FailoverEvent reports a change of the endpoint requests are sent to
+type FailoverEvent struct {
+	From     string
+	To       string
+	Failback bool // true when returning to the primary
+	Reason   string
+	Time     time.Time
+}
+
+// failover tracks which endpoint requests are currently sent to
+type failover struct {
+	client      *Client
+	primary     string
+	secondaries []string
+	interval    time.Duration
+	onEvent     func(FailoverEvent)
+
+	mu        sync.Mutex
+	active    string
+	checking  bool
+	lastCheck time.Time
+}
+
+// SetFailover enables automatic failover. When a request to the active
+// endpoint fails and the primary then fails a health check, requests are
+// transparently sent to the first healthy secondary. While failed over, the
+// primary is checked every CheckInterval and requests fail back as soon as
+// it is healthy again. Passing nil disables failover.
+func (c *Client) SetFailover(opts *FailoverOptions) error {
+	var fo *failover
+	if opts != nil {
+		if len(opts.Secondaries) == 0 {
+			return fmt.Errorf("failover requires at least one secondary endpoint")
+		}
+		fo = &failover{
+			client:   c,
+			primary:  strings.TrimSuffix(c.BaseURL, "/"),
+			interval: opts.CheckInterval,
+			onEvent:  opts.OnEvent,
+		}
+		if fo.interval <= 0 {
+			fo.interval = defaultFailoverCheckInterval
+		}
+		for _, raw := range opts.Secondaries {
+			u, err := url.Parse(raw)
+			if err != nil || u.Scheme == "" || u.Host == "" {
+				return fmt.Errorf("invalid endpoint URL %q", raw)
+			}
+			fo.secondaries = append(fo.secondaries, strings.TrimSuffix(raw, "/"))
+		}
+		fo.active = fo.primary
+	}
+
+	state := c.shared()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.failover = fo
+	return nil
+}
+
+// ActiveEndpoint returns the base URL requests are currently sent to
+func (c *Client) ActiveEndpoint() string {
+	if fo := c.failoverState(); fo != nil {
+		return fo.current()
+	}
+	return c.BaseURL
+}
+
+func (c *Client) failoverState() *failover {
+	state := c.shared()
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+	return state.failover
+}
+
+func (fo *failover) current() string {
+	fo.mu.Lock()
+	defer fo.mu.Unlock()
+	return fo.active
+}
+
+// routeFailover rewrites req for the active endpoint, if the client has
+// failed over, and schedules a recovery check of the primary when due
+func (fo *failover) route(req *http.Request, base string) *http.Request {
+	fo.mu.Lock()
+	active := fo.active
+	due := active != fo.primary && time.Since(fo.lastCheck) >= fo.interval
+	fo.mu.Unlock()
+
+	if due {
+		fo.check("primary recovery check")
+	}
+	if active == fo.primary {
+		return req
+	}
+
+	if rewritten, ok := rewriteBase(req, base, active); ok {
+		return rewritten
+	}
+	return req
+}
+
+// check evaluates endpoint health in the background, unless a check is
+// already running
+func (fo *failover) check(reason string) {
+	fo.mu.Lock()
+	if fo.checking {
+		fo.mu.Unlock()
+		return
+	}
+	fo.checking = true
+	fo.mu.Unlock()
+
+	go func() {
+		defer func() {
+			fo.mu.Lock()
+			fo.checking = false
+			fo.lastCheck = time.Now()
+			fo.mu.Unlock()
+		}()
+		fo.evaluate(reason)
+	}()
+}
+
+// evaluate picks the endpoint requests should go to: the primary when it is
+// healthy, otherwise the first healthy secondary
+func (fo *failover) evaluate(reason string) {
+	target := ""
+	if fo.client.probe(fo.primary, failoverHealthTimeout) {
+		target = fo.primary
+	} else {
+		for _, secondary := range fo.secondaries {
+			if fo.client.probe(secondary, failoverHealthTimeout) {
+				target = secondary
+				break
+			}
+		}
+	}
+	if target == "" {
+		return // nothing healthy to move to
+	}
+
+	fo.switchTo(target, reason)
+}
+
+// switchTo makes target the active endpoint, emitting an event if it changed
+func (fo *failover) switchTo(target, reason string) {
+	fo.mu.Lock()
+	from := fo.active
+	fo.active = target
+	fo.mu.Unlock()
+
+	if from != target && fo.onEvent != nil {
+		fo.onEvent(FailoverEvent{
+			From:     from,
+			To:       target,
+			Failback: target == fo.primary,
+			Reason:   reason,
+			Time:     time.Now(),
+		})
+	}
+}
@@ -0,0 +1,430 @@
+package gitdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// UnchangedSinceHeader makes a write conditional on a commit: the server
+// applies it only if none of the documents it matches changed after that
+// commit, and answers 412 Precondition Failed otherwise
+const UnchangedSinceHeader = "X-GitDB-Unchanged-Since"
+
+// findAndModifyAttempts bounds how often the FindOneAnd methods read a
+// candidate again after another writer changed it first
+const findAndModifyAttempts = 10
+
+// ReturnDocument selects which version of a document the FindOneAnd
+// methods return
+type ReturnDocument int
+
+const (
+	// ReturnBefore returns the document as it was before the change
+	ReturnBefore ReturnDocument = iota
+	// ReturnAfter returns the document as the change left it
+	ReturnAfter
+)
+
+// FindOneAndOptions tunes FindOneAndUpdate, FindOneAndReplace and
+// FindOneAndDelete
+type FindOneAndOptions struct {
+	// Sort picks the document to change when several match, such as the
+	// oldest pending job; by default the one with the lowest _id
+	Sort []SortField
+	// ReturnDocument selects the version returned, ReturnBefore by
+	// default. FindOneAndDelete always returns the deleted document.
+	ReturnDocument ReturnDocument
+}
+
+// FindOneAndUpdate applies update to the first document matching query and
+// returns it as it was before or after, for queue and lock patterns such as
+// claiming a job. The document is changed by ID with a write conditioned,
+// through UnchangedSinceHeader, on it not having changed since the commit
+// it was read at, so of several concurrent callers only one changes a
+// given version of it; the others move on to the next match. It returns
+// ErrNotFound when nothing matches, and ErrConflict when the matching
+// documents kept changing under it.
+func (c *Client) FindOneAndUpdate(ctx context.Context, collection string, query Query, update Update, opts FindOneAndOptions) (Document, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateUpdateFields(update); err != nil {
+		return nil, err
+	}
+	update, err = c.prepareUpdate(collection, update)
+	if err != nil {
+		return nil, err
+	}
+	encoded := c.encodeUpdate(collection, update)
+
+	for attempt := 0; attempt < findAndModifyAttempts; attempt++ {
+		stored, before, since, err := c.findCandidate(ctx, collection, name, query, opts.Sort)
+		if err != nil {
+			return nil, err
+		}
+
+		matched, commit, err := c.updateUnchanged(ctx, collection, name, stored, since, encoded)
+		if errors.Is(err, ErrConflict) {
+			continue // changed since it was read
+		}
+		if err != nil {
+			return nil, err
+		}
+		if matched == 0 {
+			continue // deleted since it was read
+		}
+
+		if opts.ReturnDocument == ReturnAfter {
+			return c.updatedDocument(ctx, collection, stored, encoded, commit)
+		}
+		return before, nil
+	}
+	return nil, fmt.Errorf("failed to update document: %w: matching documents kept changing", ErrConflict)
+}
+
+// FindOneAndReplace replaces the first document matching query whole,
+// keeping its ID, and returns it as it was before or after. The
+// replacement is prepared like an inserted document, and written under the
+// same condition as FindOneAndUpdate.
+func (c *Client) FindOneAndReplace(ctx context.Context, collection string, query Query, replacement Document, opts FindOneAndOptions) (Document, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateDocumentFields(replacement); err != nil {
+		return nil, err
+	}
+	doc, err := c.prepareInsert(collection, replacement)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := c.encodeDocument(collection, doc)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkDocumentSize(collection, encoded); err != nil {
+		return nil, err
+	}
+
+	// Underscored fields, such as _id and _verified, are not document data
+	set := make(map[string]interface{}, len(encoded))
+	for key, value := range encoded {
+		if !strings.HasPrefix(key, "_") {
+			set[key] = value
+		}
+	}
+
+	for attempt := 0; attempt < findAndModifyAttempts; attempt++ {
+		stored, before, since, err := c.findCandidate(ctx, collection, name, query, opts.Sort)
+		if err != nil {
+			return nil, err
+		}
+
+		update := Update{"$set": set}
+		unset := make(map[string]interface{})
+		for key := range stored {
+			if _, ok := set[key]; !ok && !strings.HasPrefix(key, "_") {
+				unset[key] = ""
+			}
+		}
+		if len(unset) > 0 {
+			update["$unset"] = unset
+		}
+
+		matched, _, err := c.updateUnchanged(ctx, collection, name, stored, since, update)
+		if errors.Is(err, ErrConflict) {
+			continue // changed since it was read
+		}
+		if err != nil {
+			return nil, err
+		}
+		if matched == 0 {
+			continue // deleted since it was read
+		}
+
+		if opts.ReturnDocument == ReturnAfter {
+			after := copyDocument(encoded)
+			after["_id"] = stored["_id"]
+			return c.decodeDocument(collection, after)
+		}
+		return before, nil
+	}
+	return nil, fmt.Errorf("failed to replace document: %w: matching documents kept changing", ErrConflict)
+}
+
+// FindOneAndDelete deletes the first document matching query and returns
+// it, or only marks it deleted in collections with soft deletes. The
+// delete is conditioned on the document being unchanged since it was
+// read, as in FindOneAndUpdate. Relation OnDelete actions run before it,
+// as in DeleteMany.
+func (c *Client) FindOneAndDelete(ctx context.Context, collection string, query Query, opts FindOneAndOptions) (Document, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < findAndModifyAttempts; attempt++ {
+		stored, before, since, err := c.findCandidate(ctx, collection, name, query, opts.Sort)
+		if err != nil {
+			return nil, err
+		}
+		if c.hasDeleteActions(collection) {
+			if err := c.applyDeleteActions(ctx, collection, []interface{}{stored["_id"]}); err != nil {
+				return nil, err
+			}
+		}
+
+		var deleted int
+		if c.softDeletes(collection) {
+			update, err := c.prepareUpdate(collection, Update{"$set": Document{DeletedAtField: time.Now().UnixMilli()}})
+			if err != nil {
+				return nil, err
+			}
+			deleted, _, err = c.updateUnchanged(ctx, collection, name, stored, since, c.encodeUpdate(collection, update))
+		} else {
+			url := fmt.Sprintf("%s/api/v1/collections/%s/documents/delete-many", c.BaseURL, name)
+			deleted, _, err = c.writeUnchanged(ctx, collection, url, Query{"_id": stored["_id"]}, since, "deletedCount", "delete documents")
+		}
+		if errors.Is(err, ErrConflict) {
+			continue // changed since it was read
+		}
+		if err != nil {
+			return nil, err
+		}
+		if deleted == 0 {
+			continue // deleted since it was read
+		}
+		return before, nil
+	}
+	return nil, fmt.Errorf("failed to delete document: %w: matching documents kept changing", ErrConflict)
+}
+
+// findCandidate returns the first live document matching query in sort
+// order, both as stored and decoded, with the commit it was read at, or
+// ErrNotFound
+func (c *Client) findCandidate(ctx context.Context, collection, name string, query Query, sort []SortField) (Document, Document, string, error) {
+	options, err := c.encodeFindOptions(collection, FindOptions{Sort: sort, Limit: 1})
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/documents/query", c.BaseURL, name)
+
+	data := map[string]interface{}{"query": c.encodeQuery(collection, c.liveQuery(collection, query))}
+	for key, value := range options {
+		data[key] = value
+	}
+
+	jsonData, err := CanonicalJSON(data)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to marshal query: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+
+	resp, err := c.do("find documents", req)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to find documents: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, "", fmt.Errorf("failed to find documents: %w", newAPIError(resp))
+	}
+
+	documents, err := c.decodeDocumentList(resp.Body)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(documents) == 0 {
+		return nil, nil, "", fmt.Errorf("failed to find document: %w", ErrNotFound)
+	}
+	commit := resp.Header.Get(CommitHeader)
+	if commit == "" {
+		return nil, nil, "", fmt.Errorf("failed to find document: server did not report the commit it read")
+	}
+	if err := c.verifyDocuments(collection, documents[:1]); err != nil {
+		return nil, nil, "", err
+	}
+
+	stored := documents[0]
+	if _, ok := stored["_id"].(string); !ok {
+		return nil, nil, "", fmt.Errorf("failed to find document: document without a string _id")
+	}
+	decoded := []Document{copyDocument(stored)}
+	if err := c.readDocuments(ctx, collection, decoded, false); err != nil {
+		return nil, nil, "", err
+	}
+	return stored, decoded[0], commit, nil
+}
+
+// updateUnchanged applies update to a stored document if it is unchanged
+// since commit since, as writeUnchanged does
+func (c *Client) updateUnchanged(ctx context.Context, collection, name string, stored Document, since string, update Update) (int, string, error) {
+	url := fmt.Sprintf("%s/api/v1/collections/%s/documents/update-many", c.BaseURL, name)
+	data := map[string]interface{}{
+		"query":  Query{"_id": stored["_id"]},
+		"update": update,
+	}
+	return c.writeUnchanged(ctx, collection, url, data, since, "matchedCount", "update documents")
+}
+
+// writeUnchanged sends a write carrying UnchangedSinceHeader and returns
+// the count the server reports under countField, with the commit of the
+// write. A write refused because its documents changed since commit
+// since returns ErrConflict. A response without the count is an error
+// rather than a miss: the write may well have been applied.
+func (c *Client) writeUnchanged(ctx context.Context, collection, url string, in interface{}, since, countField, action string) (int, string, error) {
+	defer c.evictCollection(collection)
+
+	jsonData, err := CanonicalJSON(in)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set(UnchangedSinceHeader, since)
+
+	resp, err := c.do(action, req)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to %s: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, "", fmt.Errorf("failed to %s: %w", action, newAPIError(resp))
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	count, ok := result[countField].(float64)
+	if !ok {
+		return 0, "", fmt.Errorf("failed to %s: server did not report %s", action, countField)
+	}
+
+	commit := resp.Header.Get(CommitHeader)
+	if body, ok := result["commit"].(string); ok && body != "" {
+		commit = body
+	}
+	return int(count), commit, nil
+}
+
+// updatedDocument returns a stored document as the update written at
+// commit left it, decoded. Updates made of $set, $unset and $inc are
+// applied to the document as read, which the write was conditioned on;
+// for others the document is read as it was at commit.
+func (c *Client) updatedDocument(ctx context.Context, collection string, stored Document, update Update, commit string) (Document, error) {
+	if after, ok := applyUpdate(stored, update); ok {
+		return c.decodeDocument(collection, after)
+	}
+	return c.revisionAt(ctx, collection, stored["_id"].(string), commit)
+}
+
+// applyUpdate returns a copy of doc with update applied, and false if the
+// update uses anything besides $set, $unset and $inc on numbers
+func applyUpdate(doc Document, update Update) (Document, bool) {
+	after, err := jsonFields(doc)
+	if err != nil {
+		return nil, false
+	}
+	for op, value := range update {
+		fields, ok := asMap(value)
+		if !ok {
+			return nil, false
+		}
+		// Through JSON, so values compare and add up as they would read back
+		operands, err := jsonFields(fields)
+		if err != nil {
+			return nil, false
+		}
+		for path, operand := range operands {
+			switch op {
+			case "$set":
+				if !setField(after, path, operand) {
+					return nil, false
+				}
+			case "$unset":
+				unsetField(after, path)
+			case "$inc":
+				current, _ := lookupField(after, path)
+				base, isNumber := current.(float64)
+				delta, ok := operand.(float64)
+				if !ok || (current != nil && !isNumber) || !setField(after, path, base+delta) {
+					return nil, false
+				}
+			default:
+				return nil, false
+			}
+		}
+	}
+	return Document(after), true
+}
+
+// setField sets a dotted field path in a document, creating the objects
+// along it, and returns false if one of them is not an object
+func setField(doc map[string]interface{}, path string, value interface{}) bool {
+	head, rest, nested := strings.Cut(path, ".")
+	if !nested {
+		doc[path] = value
+		return true
+	}
+	if doc[head] == nil {
+		doc[head] = map[string]interface{}{}
+	}
+	inner, ok := doc[head].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	return setField(inner, rest, value)
+}
+
+// unsetField removes a dotted field path from a document
+func unsetField(doc map[string]interface{}, path string) {
+	head, rest, nested := strings.Cut(path, ".")
+	if !nested {
+		delete(doc, path)
+		return
+	}
+	if inner, ok := doc[head].(map[string]interface{}); ok {
+		unsetField(inner, rest)
+	}
+}
+
+// revisionAt returns a document as it was at commit
+func (c *Client) revisionAt(ctx context.Context, collection, id, commit string) (Document, error) {
+	if commit == "" {
+		return nil, fmt.Errorf("failed to read updated document: server did not report the commit of the update")
+	}
+	revisions, err := c.DocumentHistory(ctx, collection, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, revision := range revisions {
+		if revision.Commit == commit && revision.Document != nil {
+			revision.Document["_id"] = id
+			return revision.Document, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to read updated document: no revision at commit %s", commit)
+}
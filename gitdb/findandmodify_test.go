@@ -0,0 +1,106 @@
+package gitdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFindOneAndUpdateConditionsOnCommit(t *testing.T) {
+	var mu sync.Mutex
+	job := map[string]interface{}{
+		"_id":     "j1",
+		"status":  "pending",
+		"owner":   map[string]interface{}{"$ref": "users", "$id": "u1"},
+		"retries": float64(1),
+	}
+	head := "c1"
+	var queries []map[string]interface{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/documents/query"):
+			w.Header().Set(CommitHeader, head)
+			json.NewEncoder(w).Encode([]map[string]interface{}{job})
+
+		case strings.HasSuffix(r.URL.Path, "/documents/update-many"):
+			queries = append(queries, body["query"].(map[string]interface{}))
+			if r.Header.Get(UnchangedSinceHeader) != head {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			// Another writer lands between the write and any later read
+			job["status"] = "running"
+			job["retries"] = float64(2)
+			head = "c2"
+			w.Header().Set(CommitHeader, head)
+			json.NewEncoder(w).Encode(map[string]interface{}{"matchedCount": 1, "modifiedCount": 1})
+			job["status"] = "done"
+			head = "c3"
+
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient("token", "owner", "repo")
+	c.BaseURL = srv.URL
+
+	after, err := c.FindOneAndUpdate(context.Background(), "jobs",
+		Query{"status": "pending"},
+		Update{"$set": Document{"status": "running"}, "$inc": Document{"retries": 1}},
+		FindOneAndOptions{ReturnDocument: ReturnAfter})
+	if err != nil {
+		t.Fatalf("find and update: %v", err)
+	}
+
+	if want := []map[string]interface{}{{"_id": "j1"}}; !reflect.DeepEqual(queries, want) {
+		t.Errorf("update queries = %v, want %v", queries, want)
+	}
+	if after["status"] != "running" || after["retries"] != float64(2) {
+		t.Errorf("after = %v, want the document as the write left it", after)
+	}
+	if owner, _ := after["owner"].(map[string]interface{}); owner["$id"] != "u1" {
+		t.Errorf("after owner = %v, want the reference kept", after["owner"])
+	}
+}
+
+func TestFindOneAndUpdateRequiresCounts(t *testing.T) {
+	writes := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/documents/query"):
+			w.Header().Set(CommitHeader, "c1")
+			json.NewEncoder(w).Encode([]Document{{"_id": "j1", "status": "pending"}})
+		case strings.HasSuffix(r.URL.Path, "/documents/update-many"):
+			writes++
+			json.NewEncoder(w).Encode(map[string]interface{}{})
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient("token", "owner", "repo")
+	c.BaseURL = srv.URL
+
+	_, err := c.FindOneAndUpdate(context.Background(), "jobs", Query{"status": "pending"},
+		Update{"$set": Document{"status": "running"}}, FindOneAndOptions{})
+	if err == nil {
+		t.Fatal("find and update succeeded without counts, want an error")
+	}
+	if writes != 1 {
+		t.Errorf("sent %d writes, want 1", writes)
+	}
+}
@@ -0,0 +1,228 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Collation controls how strings are compared when filtering, sorting and
+// indexing, so that ordering and equality follow the rules of a language
+// rather than byte values
+type Collation struct {
+	// Locale is a language tag such as "de", "fr_CA" or "sv"; "simple"
+	// compares bytes
+	Locale string `json:"locale"`
+	// CaseInsensitive makes "a" and "A" compare equal
+	CaseInsensitive bool `json:"caseInsensitive,omitempty"`
+	// AccentInsensitive makes "a" and "á" compare equal
+	AccentInsensitive bool `json:"accentInsensitive,omitempty"`
+	// NumericOrdering compares runs of digits as numbers, so "2" sorts
+	// before "10"
+	NumericOrdering bool `json:"numericOrdering,omitempty"`
+}
+
+// SortField is one key of a sort order
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+// Projection selects the fields returned for each document: either only
+// the fields mapped to true, or every field except those mapped to false.
+// The two cannot be mixed, except that an inclusion may exclude _id.
+type Projection map[string]bool
+
+// Include returns a projection of only the given fields, plus _id
+func Include(fields ...string) Projection {
+	p := make(Projection, len(fields))
+	for _, field := range fields {
+		p[field] = true
+	}
+	return p
+}
+
+// Exclude returns a projection of every field but the given ones, such as
+// large payloads that list views do not need
+func Exclude(fields ...string) Projection {
+	p := make(Projection, len(fields))
+	for _, field := range fields {
+		p[field] = false
+	}
+	return p
+}
+
+// FindOptions shapes the results of FindWithOptions
+type FindOptions struct {
+	// Sort orders the results by each field in turn, then by _id unless it
+	// is one of the fields, so that the order is total and stable across
+	// pages
+	Sort []SortField
+	// Skip and Limit page through the results; a zero Limit returns all
+	Skip  int
+	Limit int
+	// Collation applies to the query's string comparisons and to Sort
+	Collation *Collation
+	// Projection trims the returned documents to save bandwidth
+	Projection Projection
+	// DistinctOn keeps only the first document, in Sort order, for each
+	// value of a field, such as the latest reading per device when sorted
+	// by time descending. Skip and Limit apply to the deduplicated results.
+	DistinctOn string
+}
+
+// Asc sorts by field in ascending order
+func Asc(field string) SortField {
+	return SortField{Field: field}
+}
+
+// Desc sorts by field in descending order
+func Desc(field string) SortField {
+	return SortField{Field: field, Descending: true}
+}
+
+// FindWithOptions finds documents in a collection, sorted, paged and
+// compared as opts specifies
+func (c *Client) FindWithOptions(ctx context.Context, collection string, query Query, opts FindOptions) ([]Document, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := c.encodeFindOptions(collection, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/documents/query", c.BaseURL, name)
+
+	data := map[string]interface{}{"query": c.encodeQuery(collection, c.liveQuery(collection, query))}
+	for key, value := range options {
+		data[key] = value
+	}
+
+	var documents []Document
+	if err := c.doJSON(ctx, "POST", url, data, &documents, http.StatusOK, "find documents"); err != nil {
+		return nil, err
+	}
+	if err := c.verifyDocuments(collection, documents); err != nil {
+		return nil, err
+	}
+	if err := c.readDocuments(ctx, collection, documents, len(opts.Projection) > 0); err != nil {
+		return nil, err
+	}
+	return documents, nil
+}
+
+// encodeFindOptions validates opts and translates them into request fields
+func (c *Client) encodeFindOptions(collection string, opts FindOptions) (map[string]interface{}, error) {
+	if opts.Skip < 0 || opts.Limit < 0 {
+		return nil, fmt.Errorf("invalid paging: skip and limit must not be negative")
+	}
+	if err := validateCollation(opts.Collation); err != nil {
+		return nil, err
+	}
+
+	options := make(map[string]interface{})
+	if len(opts.Sort) > 0 {
+		sort, err := c.encodeSort(collection, opts.Sort)
+		if err != nil {
+			return nil, err
+		}
+		options["sort"] = sort
+	}
+	if opts.Skip > 0 {
+		options["skip"] = opts.Skip
+	}
+	if opts.Limit > 0 {
+		options["limit"] = opts.Limit
+	}
+	if opts.Collation != nil {
+		options["collation"] = opts.Collation
+	}
+	if opts.DistinctOn != "" {
+		if err := ValidateFieldPath(opts.DistinctOn); err != nil {
+			return nil, err
+		}
+		options["distinctOn"] = c.storedField(collection, opts.DistinctOn)
+	}
+	if len(opts.Projection) > 0 {
+		projection, err := c.encodeProjection(collection, opts.Projection)
+		if err != nil {
+			return nil, err
+		}
+		options["projection"] = projection
+	}
+	return options, nil
+}
+
+// encodeSort validates a sort order and translates it into request fields
+// with stored field names. Unless the order already includes _id, _id is
+// appended as a final key so that documents with equal sort values always
+// come back in the same order, and pages neither skip nor repeat them.
+func (c *Client) encodeSort(collection string, fields []SortField) ([]map[string]interface{}, error) {
+	sort := make([]map[string]interface{}, 0, len(fields)+1)
+	tiebreak := true
+	for _, key := range fields {
+		if err := ValidateFieldPath(key.Field); err != nil {
+			return nil, err
+		}
+		if key.Field == "_id" {
+			tiebreak = false
+		}
+		order := 1
+		if key.Descending {
+			order = -1
+		}
+		sort = append(sort, map[string]interface{}{"field": c.storedField(collection, key.Field), "order": order})
+	}
+	if tiebreak {
+		sort = append(sort, map[string]interface{}{"field": "_id", "order": 1})
+	}
+	return sort, nil
+}
+
+// encodeProjection validates a projection and translates it into the
+// server's 1/0 form with stored field names
+func (c *Client) encodeProjection(collection string, p Projection) (map[string]int, error) {
+	included, excluded := 0, 0
+	for field, include := range p {
+		if err := ValidateFieldPath(field); err != nil {
+			return nil, err
+		}
+		switch {
+		case include:
+			included++
+		case field != "_id":
+			excluded++
+		}
+	}
+	if included > 0 && excluded > 0 {
+		return nil, fmt.Errorf("invalid projection: cannot mix included and excluded fields")
+	}
+
+	projection := make(map[string]int, len(p))
+	for field, include := range p {
+		value := 0
+		if include {
+			value = 1
+		}
+		projection[c.storedField(collection, field)] = value
+	}
+	// Migrations need the schema version to know where a document starts
+	if len(c.migrations(collection)) > 0 {
+		if included > 0 {
+			projection[SchemaVersionField] = 1
+		} else {
+			delete(projection, SchemaVersionField)
+		}
+	}
+	return projection, nil
+}
+
+func validateCollation(collation *Collation) error {
+	if collation != nil && collation.Locale == "" {
+		return fmt.Errorf("invalid collation: locale is required")
+	}
+	return nil
+}
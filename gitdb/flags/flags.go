@@ -0,0 +1,249 @@
+// Package flags stores feature flags in a GitDB collection. Every change to
+// a flag is a commit, so the repository doubles as an audit log of who
+// flipped what and when.
+//
+// A Store caches the flags locally and refreshes them incrementally, so
+// evaluating a flag never waits on the network:
+//
+//	store := flags.New(client, "flags")
+//	if err := store.Refresh(ctx); err != nil {
+//	    log.Fatal(err)
+//	}
+//	go store.Run(ctx, 10*time.Second, nil)
+//
+//	newCheckout := store.Bool("new-checkout", false)
+//	if newCheckout.For(userID) {
+//	    ...
+//	}
+package flags
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb"
+)
+
+// Flag is the stored state of a feature flag
+type Flag struct {
+	Name string
+	// Value is served to the subjects inside the rollout
+	Value interface{}
+	// Rollout is the percentage of subjects, from 0 to 100, that get Value;
+	// the others get the default of the flag's definition
+	Rollout float64
+	// Description says what the flag is for
+	Description string
+}
+
+// Store is a locally cached set of flags backed by a collection, one
+// document per flag named after it
+type Store struct {
+	client     *gitdb.Client
+	collection string
+
+	mu        sync.RWMutex
+	flags     map[string]Flag
+	watermark string
+	nextSub   int
+	subs      map[int]func(name string, flag *Flag)
+}
+
+// New returns a store of the flags in collection. It is empty until the
+// first Refresh.
+func New(client *gitdb.Client, collection string) *Store {
+	return &Store{
+		client:     client,
+		collection: collection,
+		flags:      make(map[string]Flag),
+		subs:       make(map[int]func(string, *Flag)),
+	}
+}
+
+// Get returns the cached state of a flag
+func (s *Store) Get(name string) (Flag, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	flag, ok := s.flags[name]
+	return flag, ok
+}
+
+// All returns the cached state of every flag
+func (s *Store) All() []Flag {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	all := make([]Flag, 0, len(s.flags))
+	for _, flag := range s.flags {
+		all = append(all, flag)
+	}
+	return all
+}
+
+// Set stores a flag, replacing its previous state, in a commit of its own
+func (s *Store) Set(ctx context.Context, flag Flag) error {
+	if flag.Rollout < 0 || flag.Rollout > 100 {
+		return fmt.Errorf("invalid rollout %v for flag %s: must be between 0 and 100", flag.Rollout, flag.Name)
+	}
+
+	doc := gitdb.Document{
+		"value":       flag.Value,
+		"rollout":     flag.Rollout,
+		"description": flag.Description,
+	}
+	if _, err := s.client.ApplyChanges(ctx, s.collection, []gitdb.Change{{Op: gitdb.ChangeUpsert, ID: flag.Name, Document: doc}}); err != nil {
+		return fmt.Errorf("failed to set flag %s: %w", flag.Name, err)
+	}
+	return s.Refresh(ctx)
+}
+
+// Delete removes a flag, so its definitions fall back to their defaults
+func (s *Store) Delete(ctx context.Context, name string) error {
+	if _, err := s.client.ApplyChanges(ctx, s.collection, []gitdb.Change{{Op: gitdb.ChangeDelete, ID: name}}); err != nil {
+		return fmt.Errorf("failed to delete flag %s: %w", name, err)
+	}
+	return s.Refresh(ctx)
+}
+
+// History returns the past states of a flag, newest first
+func (s *Store) History(ctx context.Context, name string) ([]gitdb.Revision, error) {
+	return s.client.DocumentHistory(ctx, s.collection, name)
+}
+
+// Subscribe calls fn with every flag that changes on a refresh, with a nil
+// flag when it was deleted, until the returned function is called. fn runs
+// on the refreshing goroutine and must not block.
+func (s *Store) Subscribe(fn func(name string, flag *Flag)) (unsubscribe func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.nextSub
+	s.nextSub++
+	s.subs[id] = fn
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subs, id)
+	}
+}
+
+// Refresh fetches the flags changed since the last refresh
+func (s *Store) Refresh(ctx context.Context) error {
+	s.mu.RLock()
+	since := s.watermark
+	s.mu.RUnlock()
+
+	for {
+		changes, err := s.client.Changes(ctx, s.collection, since)
+		if err != nil {
+			return fmt.Errorf("failed to refresh flags: %w", err)
+		}
+		if !s.apply(since, changes) {
+			// A concurrent refresh got here first
+			return nil
+		}
+		since = changes.Head
+		if !changes.More {
+			return nil
+		}
+	}
+}
+
+// apply updates the cache with a change set starting at since and notifies
+// subscribers, reporting false if the cache has moved on from since
+func (s *Store) apply(since string, changes *gitdb.ChangeSet) bool {
+	type notification struct {
+		name string
+		flag *Flag
+	}
+	var notify []notification
+
+	s.mu.Lock()
+	if s.watermark != since {
+		s.mu.Unlock()
+		return false
+	}
+	for _, change := range changes.Changes {
+		if change.Op == gitdb.ChangeDelete {
+			if _, ok := s.flags[change.ID]; ok {
+				delete(s.flags, change.ID)
+				notify = append(notify, notification{change.ID, nil})
+			}
+			continue
+		}
+		flag := decodeFlag(change.ID, change.Document)
+		s.flags[change.ID] = flag
+		notify = append(notify, notification{change.ID, &flag})
+	}
+	if changes.Head != "" {
+		s.watermark = changes.Head
+	}
+	subs := make([]func(string, *Flag), 0, len(s.subs))
+	for _, fn := range s.subs {
+		subs = append(subs, fn)
+	}
+	s.mu.Unlock()
+
+	for _, n := range notify {
+		for _, fn := range subs {
+			fn(n.name, n.flag)
+		}
+	}
+	return true
+}
+
+// Run refreshes the store every interval until ctx is done, then returns
+// ctx's error. Failed refreshes keep the cached flags and are passed to
+// onError when it is non-nil.
+func (s *Store) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if err := s.Refresh(ctx); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+// decodeFlag reads a flag from its document. Flags written without a
+// rollout apply to everyone.
+func decodeFlag(name string, doc gitdb.Document) Flag {
+	flag := Flag{Name: name, Value: doc["value"], Rollout: 100}
+	if rollout, ok := doc["rollout"].(float64); ok {
+		flag.Rollout = rollout
+	}
+	flag.Description, _ = doc["description"].(string)
+	return flag
+}
+
+// value returns the value of a flag for subject, and false when the flag
+// is missing or subject is outside its rollout. An empty subject is only
+// inside full rollouts.
+func (s *Store) value(name, subject string) (interface{}, bool) {
+	flag, ok := s.Get(name)
+	if !ok || !inRollout(name, subject, flag.Rollout) {
+		return nil, false
+	}
+	return flag.Value, true
+}
+
+// inRollout places subject in a stable bucket per flag, so raising a
+// rollout only ever adds subjects and different flags pick different ones
+func inRollout(name, subject string, rollout float64) bool {
+	if rollout >= 100 {
+		return true
+	}
+	if subject == "" || rollout <= 0 {
+		return false
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name + "\x00" + subject))
+	return float64(h.Sum32()%10000) < rollout*100
+}
@@ -0,0 +1,119 @@
+package flags
+
+// BoolFlag is a boolean flag definition
+type BoolFlag struct {
+	store *Store
+	name  string
+	def   bool
+}
+
+// Bool defines a boolean flag with the value def when it is unset, not a
+// boolean, or the subject is outside its rollout
+func (s *Store) Bool(name string, def bool) *BoolFlag {
+	return &BoolFlag{store: s, name: name, def: def}
+}
+
+// Enabled returns the value of the flag for everyone
+func (f *BoolFlag) Enabled() bool {
+	return f.For("")
+}
+
+// For returns the value of the flag for subject, such as a user ID
+func (f *BoolFlag) For(subject string) bool {
+	if value, ok := f.store.value(f.name, subject); ok {
+		if b, ok := value.(bool); ok {
+			return b
+		}
+	}
+	return f.def
+}
+
+// StringFlag is a string flag definition
+type StringFlag struct {
+	store *Store
+	name  string
+	def   string
+}
+
+// String defines a string flag with the value def when it is unset, not a
+// string, or the subject is outside its rollout
+func (s *Store) String(name, def string) *StringFlag {
+	return &StringFlag{store: s, name: name, def: def}
+}
+
+// Value returns the value of the flag for everyone
+func (f *StringFlag) Value() string {
+	return f.For("")
+}
+
+// For returns the value of the flag for subject
+func (f *StringFlag) For(subject string) string {
+	if value, ok := f.store.value(f.name, subject); ok {
+		if s, ok := value.(string); ok {
+			return s
+		}
+	}
+	return f.def
+}
+
+// IntFlag is an integer flag definition
+type IntFlag struct {
+	store *Store
+	name  string
+	def   int
+}
+
+// Int defines an integer flag with the value def when it is unset, not a
+// number, or the subject is outside its rollout
+func (s *Store) Int(name string, def int) *IntFlag {
+	return &IntFlag{store: s, name: name, def: def}
+}
+
+// Value returns the value of the flag for everyone
+func (f *IntFlag) Value() int {
+	return f.For("")
+}
+
+// For returns the value of the flag for subject
+func (f *IntFlag) For(subject string) int {
+	if value, ok := f.store.value(f.name, subject); ok {
+		switch n := value.(type) {
+		case float64:
+			return int(n)
+		case int:
+			return n
+		}
+	}
+	return f.def
+}
+
+// FloatFlag is a floating-point flag definition
+type FloatFlag struct {
+	store *Store
+	name  string
+	def   float64
+}
+
+// Float defines a floating-point flag with the value def when it is unset,
+// not a number, or the subject is outside its rollout
+func (s *Store) Float(name string, def float64) *FloatFlag {
+	return &FloatFlag{store: s, name: name, def: def}
+}
+
+// Value returns the value of the flag for everyone
+func (f *FloatFlag) Value() float64 {
+	return f.For("")
+}
+
+// For returns the value of the flag for subject
+func (f *FloatFlag) For(subject string) float64 {
+	if value, ok := f.store.value(f.name, subject); ok {
+		switch n := value.(type) {
+		case float64:
+			return n
+		case int:
+			return float64(n)
+		}
+	}
+	return f.def
+}
@@ -0,0 +1,633 @@
+// Package gitdbsql is a database/sql driver for GitDB. It translates a small
+// SQL subset into client calls so sql- and sqlx-based code and BI tools can
+// read and write GitDB collections:
+//
+//	SELECT * | COUNT(*) | COUNT(field) | field, ... FROM collection [WHERE cond]
+//	    [ORDER BY field [ASC|DESC], ...] [LIMIT n] [OFFSET n]
+//	INSERT INTO collection (field, ...) VALUES (value, ...), ...
+//	UPDATE collection SET field = value, ... [WHERE cond]
+//	DELETE FROM collection [WHERE cond]
+//
+// Conditions compare a field with =, !=, <>, <, <=, >, >=, IN, NOT IN,
+// IS NULL or IS NOT NULL and combine with AND, OR and parentheses. Dotted
+// field names reach into nested objects, and a leading collection qualifier
+// (users.name) is ignored. Values may be literals or ? / $N placeholders.
+// ORDER BY, LIMIT and OFFSET are applied client side. Transactions are not
+// supported.
+//
+// Register the driver with a blank import and open it with a DSN of the form
+//
+//	gitdb://TOKEN@host:port/owner/repo[?tls=true]
+//
+// or wrap an existing client with sql.OpenDB(gitdbsql.NewConnector(client)).
+package gitdbsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb"
+)
+
+func init() {
+	sql.Register("gitdb", Driver{})
+}
+
+// Driver implements driver.Driver for DSNs of the form
+// gitdb://TOKEN@host:port/owner/repo
+type Driver struct{}
+
+// Open parses dsn and returns a connection
+func (d Driver) Open(dsn string) (driver.Conn, error) {
+	client, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{client: client}, nil
+}
+
+// ParseDSN builds a client from a gitdb:// DSN
+func ParseDSN(dsn string) (*gitdb.Client, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DSN: %w", err)
+	}
+	if u.Scheme != "gitdb" {
+		return nil, fmt.Errorf("invalid DSN: scheme must be gitdb, got %q", u.Scheme)
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid DSN: path must be /owner/repo")
+	}
+
+	token := u.User.Username()
+	if password, ok := u.User.Password(); ok && token == "" {
+		token = password
+	}
+
+	scheme := "http"
+	if u.Query().Get("tls") == "true" {
+		scheme = "https"
+	}
+
+	client := gitdb.NewClient(token, parts[0], parts[1])
+	client.SetBaseURL(scheme + "://" + u.Host)
+	return client, nil
+}
+
+// NewConnector returns a connector that uses client for every connection
+func NewConnector(client *gitdb.Client) driver.Connector {
+	return connector{client: client}
+}
+
+type connector struct {
+	client *gitdb.Client
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) {
+	return &conn{client: c.client}, nil
+}
+
+func (c connector) Driver() driver.Driver {
+	return Driver{}
+}
+
+type conn struct {
+	client *gitdb.Client
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	parsed, err := parse(query)
+	if err != nil {
+		return nil, fmt.Errorf("gitdbsql: %w", err)
+	}
+	return &stmt{client: c.client, parsed: parsed}, nil
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return nil, errors.New("gitdbsql: transactions are not supported")
+}
+
+// Ping implements driver.Pinger using the server health check. Only
+// failures to reach the server report driver.ErrBadConn, so database/sql
+// retries them on a fresh connection; cancellation, unhealthy servers and
+// refused requests are returned as they are.
+func (c *conn) Ping(ctx context.Context) error {
+	err := c.client.Health(ctx)
+	if err == nil {
+		return nil
+	}
+	var netErr net.Error
+	if ctx.Err() == nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) && errors.As(err, &netErr) {
+		return driver.ErrBadConn
+	}
+	return err
+}
+
+type stmt struct {
+	client *gitdb.Client
+	parsed *statement
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return s.parsed.params
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.exec(context.Background(), values(args))
+}
+
+// ExecContext implements driver.StmtExecContext, bounding the client calls
+// by ctx
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	values, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.exec(ctx, values)
+}
+
+func (s *stmt) exec(ctx context.Context, values []interface{}) (driver.Result, error) {
+	p := s.parsed
+	switch p.kind {
+	case "INSERT":
+		for _, row := range p.rows {
+			doc := gitdb.Document{}
+			for i, column := range p.columns {
+				v, err := row[i].resolve(values)
+				if err != nil {
+					return nil, err
+				}
+				setPath(doc, column, normalize(v))
+			}
+			if _, err := s.client.Insert(ctx, p.collection, doc); err != nil {
+				return nil, err
+			}
+		}
+		return result(len(p.rows)), nil
+
+	case "UPDATE":
+		update, err := buildUpdate(p.set, values)
+		if err != nil {
+			return nil, err
+		}
+		query, err := buildQuery(p.where, values)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := s.client.UpdateMany(ctx, p.collection, query, update)
+		if err != nil {
+			return nil, err
+		}
+		return result(updated.ModifiedCount), nil
+
+	case "DELETE":
+		query, err := buildQuery(p.where, values)
+		if err != nil {
+			return nil, err
+		}
+		n, err := s.client.DeleteMany(ctx, p.collection, query)
+		return result(n), err
+	}
+
+	return nil, fmt.Errorf("gitdbsql: %s does not return a result, use Query", p.kind)
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.query(context.Background(), values(args))
+}
+
+// QueryContext implements driver.StmtQueryContext, bounding the client calls
+// by ctx
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	values, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.query(ctx, values)
+}
+
+func (s *stmt) query(ctx context.Context, values []interface{}) (driver.Rows, error) {
+	p := s.parsed
+	if p.kind != "SELECT" {
+		return nil, fmt.Errorf("gitdbsql: %s returns no rows, use Exec", p.kind)
+	}
+
+	query, err := buildQuery(p.where, values)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.count {
+		if p.countField != "" {
+			query = gitdb.Query{"$and": []interface{}{
+				map[string]interface{}(query),
+				map[string]interface{}{p.countField: map[string]interface{}{"$ne": nil}},
+			}}
+		}
+		n, err := s.client.Count(ctx, p.collection, query)
+		if err != nil {
+			return nil, err
+		}
+		return &rows{columns: []string{"count"}, docs: []gitdb.Document{{"count": int64(n)}}}, nil
+	}
+
+	docs, err := s.client.Find(ctx, p.collection, query)
+	if err != nil {
+		return nil, err
+	}
+	sortDocuments(docs, p.orderBy)
+
+	if p.offset != nil {
+		n, err := count(*p.offset, values)
+		if err != nil {
+			return nil, fmt.Errorf("gitdbsql: invalid OFFSET: %w", err)
+		}
+		if n > len(docs) {
+			n = len(docs)
+		}
+		docs = docs[n:]
+	}
+	if p.limit != nil {
+		n, err := count(*p.limit, values)
+		if err != nil {
+			return nil, fmt.Errorf("gitdbsql: invalid LIMIT: %w", err)
+		}
+		if n < len(docs) {
+			docs = docs[:n]
+		}
+	}
+
+	columns := p.columns
+	if columns == nil {
+		columns = allColumns(docs)
+	}
+	return &rows{columns: columns, docs: docs}, nil
+}
+
+// values converts driver arguments for placeholder resolution
+func values(args []driver.Value) []interface{} {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		values[i] = arg
+	}
+	return values
+}
+
+// namedValues converts context-aware driver arguments, rejecting named
+// parameters
+func namedValues(args []driver.NamedValue) ([]interface{}, error) {
+	values := make([]interface{}, len(args))
+	for _, arg := range args {
+		if arg.Name != "" {
+			return nil, fmt.Errorf("gitdbsql: named parameters are not supported")
+		}
+		values[arg.Ordinal-1] = arg.Value
+	}
+	return values, nil
+}
+
+type result int
+
+func (r result) LastInsertId() (int64, error) {
+	return 0, errors.New("gitdbsql: document IDs are strings, insert an explicit _id to know it")
+}
+
+func (r result) RowsAffected() (int64, error) {
+	return int64(r), nil
+}
+
+type rows struct {
+	columns []string
+	docs    []gitdb.Document
+	pos     int
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.docs) {
+		return io.EOF
+	}
+	doc := r.docs[r.pos]
+	r.pos++
+
+	for i, column := range r.columns {
+		dest[i] = driverValue(getPath(doc, column))
+	}
+	return nil
+}
+
+// count resolves a LIMIT or OFFSET operand
+func count(v value, args []interface{}) (int, error) {
+	resolved, err := v.resolve(args)
+	if err != nil {
+		return 0, err
+	}
+	switch n := resolved.(type) {
+	case int64:
+		if n >= 0 {
+			return int(n), nil
+		}
+	case float64:
+		if n >= 0 && n == float64(int64(n)) {
+			return int(n), nil
+		}
+	}
+	return 0, fmt.Errorf("%v is not a non-negative integer", resolved)
+}
+
+// buildUpdate translates the assignments of an UPDATE into a GitDB update;
+// fields set to NULL are removed
+func buildUpdate(assignments []assignment, args []interface{}) (gitdb.Update, error) {
+	set, unset := map[string]interface{}{}, map[string]interface{}{}
+	for _, a := range assignments {
+		v, err := a.value.resolve(args)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			unset[a.field] = ""
+		} else {
+			set[a.field] = normalize(v)
+		}
+	}
+	update := gitdb.Update{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return update, nil
+}
+
+var operators = map[string]string{
+	"!=":     "$ne",
+	"<>":     "$ne",
+	"<":      "$lt",
+	"<=":     "$lte",
+	">":      "$gt",
+	">=":     "$gte",
+	"IN":     "$in",
+	"NOT IN": "$nin",
+}
+
+// buildQuery translates a WHERE expression into a GitDB query
+func buildQuery(where expr, args []interface{}) (gitdb.Query, error) {
+	switch e := where.(type) {
+	case nil:
+		return gitdb.Query{}, nil
+
+	case *comparison:
+		var v interface{}
+		if e.list != nil {
+			list := make([]interface{}, len(e.list))
+			for i, item := range e.list {
+				resolved, err := item.resolve(args)
+				if err != nil {
+					return nil, err
+				}
+				list[i] = normalize(resolved)
+			}
+			v = list
+		} else {
+			resolved, err := e.value.resolve(args)
+			if err != nil {
+				return nil, err
+			}
+			v = normalize(resolved)
+		}
+
+		if e.op == "=" {
+			return gitdb.Query{e.field: v}, nil
+		}
+		return gitdb.Query{e.field: map[string]interface{}{operators[e.op]: v}}, nil
+
+	case *logical:
+		terms := make([]interface{}, len(e.terms))
+		for i, term := range e.terms {
+			q, err := buildQuery(term, args)
+			if err != nil {
+				return nil, err
+			}
+			terms[i] = map[string]interface{}(q)
+		}
+		if e.op == "OR" {
+			return gitdb.Query{"$or": terms}, nil
+		}
+		if merged, ok := mergeAnd(terms); ok {
+			return merged, nil
+		}
+		return gitdb.Query{"$and": terms}, nil
+	}
+	return nil, fmt.Errorf("gitdbsql: unsupported expression %T", where)
+}
+
+// mergeAnd folds AND-ed queries into one, combining operators on the same
+// field. It reports false when two terms cannot be combined, such as two
+// different equality values or repeated $or clauses.
+func mergeAnd(terms []interface{}) (gitdb.Query, bool) {
+	merged := gitdb.Query{}
+	for _, term := range terms {
+		for field, v := range term.(map[string]interface{}) {
+			existing, ok := merged[field]
+			if !ok {
+				merged[field] = v
+				continue
+			}
+			if strings.HasPrefix(field, "$") {
+				return nil, false
+			}
+
+			a, b := operatorMap(existing), operatorMap(v)
+			for op, operand := range b {
+				if _, clash := a[op]; clash {
+					return nil, false
+				}
+				a[op] = operand
+			}
+			merged[field] = a
+		}
+	}
+	return merged, true
+}
+
+// operatorMap returns a copy of an operator map, wrapping a plain value in
+// $eq
+func operatorMap(v interface{}) map[string]interface{} {
+	ops := map[string]interface{}{}
+	if m, ok := v.(map[string]interface{}); ok {
+		for op, operand := range m {
+			ops[op] = operand
+		}
+		return ops
+	}
+	ops["$eq"] = v
+	return ops
+}
+
+// normalize converts driver argument types into JSON friendly values
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return v
+}
+
+// driverValue converts a decoded JSON value into a driver.Value. Whole
+// numbers become int64; objects and arrays are returned as JSON text.
+func driverValue(v interface{}) driver.Value {
+	switch v := v.(type) {
+	case nil, bool, int64:
+		return v
+	case string:
+		// Times are written as RFC 3339 text; hand them back as time.Time so
+		// they scan into time fields as well as strings
+		if len(v) >= 20 && v[4] == '-' && v[10] == 'T' {
+			if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				return t
+			}
+		}
+		return v
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v)
+		}
+		return v
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func getPath(doc map[string]interface{}, path string) interface{} {
+	if v, ok := doc[path]; ok {
+		return v
+	}
+	head, rest, found := strings.Cut(path, ".")
+	if !found {
+		return nil
+	}
+	nested, ok := doc[head].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return getPath(nested, rest)
+}
+
+func setPath(doc map[string]interface{}, path string, v interface{}) {
+	head, rest, found := strings.Cut(path, ".")
+	if !found {
+		doc[path] = v
+		return
+	}
+	nested, ok := doc[head].(map[string]interface{})
+	if !ok {
+		nested = map[string]interface{}{}
+		doc[head] = nested
+	}
+	setPath(nested, rest, v)
+}
+
+// allColumns lists every top-level field in docs, _id first
+func allColumns(docs []gitdb.Document) []string {
+	seen := map[string]bool{"_id": true}
+	columns := []string{}
+	for _, doc := range docs {
+		for key := range doc {
+			if !seen[key] {
+				seen[key] = true
+				columns = append(columns, key)
+			}
+		}
+	}
+	sort.Strings(columns)
+	return append([]string{"_id"}, columns...)
+}
+
+func sortDocuments(docs []gitdb.Document, orderBy []order) {
+	if len(orderBy) == 0 {
+		return
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, o := range orderBy {
+			c := compare(getPath(docs[i], o.field), getPath(docs[j], o.field))
+			if c == 0 {
+				continue
+			}
+			if o.desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+// compare orders values of the same type; nulls sort first and mismatched
+// types compare by their text form
+func compare(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	switch a := a.(type) {
+	case float64:
+		if b, ok := b.(float64); ok {
+			switch {
+			case a < b:
+				return -1
+			case a > b:
+				return 1
+			}
+			return 0
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			switch {
+			case a == b:
+				return 0
+			case !a:
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
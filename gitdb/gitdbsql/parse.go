@@ -0,0 +1,624 @@
+package gitdbsql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokParam
+	tokSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// param is the zero-based argument index of a placeholder
+	param int
+}
+
+// lex splits a statement into tokens. Positional placeholders ? are numbered
+// in order; $N placeholders refer to argument N.
+func lex(sql string) ([]token, error) {
+	var tokens []token
+	next := 0
+	runes := []rune(sql)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'':
+			var sb strings.Builder
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("unterminated string")
+				}
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						sb.WriteRune('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, token{kind: tokString, text: sb.String()})
+		case r == '"' || r == '`' || unicode.IsLetter(r) || r == '_':
+			// An identifier is one or more dot separated parts, each bare or
+			// quoted, so "users"."address.city" and users.address.city lex
+			// the same way
+			var parts []string
+			for {
+				part, end, err := lexIdentPart(runes, i)
+				if err != nil {
+					return nil, err
+				}
+				parts = append(parts, part)
+				i = end
+				if i+1 < len(runes) && runes[i] == '.' && isIdentStart(runes[i+1]) {
+					i++
+					continue
+				}
+				break
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: strings.Join(parts, ".")})
+		case r == '?':
+			tokens = append(tokens, token{kind: tokParam, param: next})
+			next++
+			i++
+		case r == '$' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			end := i + 1
+			for end < len(runes) && unicode.IsDigit(runes[end]) {
+				end++
+			}
+			n, _ := strconv.Atoi(string(runes[i+1 : end]))
+			if n < 1 {
+				return nil, fmt.Errorf("invalid placeholder $%d", n)
+			}
+			tokens = append(tokens, token{kind: tokParam, param: n - 1})
+			i = end
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			end := i + 1
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.' || runes[end] == 'e' || runes[end] == 'E') {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: string(runes[i:end])})
+			i = end
+		default:
+			sym := string(r)
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case "<=", ">=", "!=", "<>":
+					sym = two
+				}
+			}
+			if !strings.Contains("*,()=<>!=;", sym[:1]) {
+				return nil, fmt.Errorf("unexpected character %q", r)
+			}
+			tokens = append(tokens, token{kind: tokSymbol, text: sym})
+			i += len(sym)
+		}
+	}
+	return append(tokens, token{kind: tokEOF}), nil
+}
+
+func isIdentStart(r rune) bool {
+	return r == '"' || r == '`' || unicode.IsLetter(r) || r == '_'
+}
+
+// lexIdentPart reads one bare or quoted identifier starting at i and returns
+// it with the index just past it
+func lexIdentPart(runes []rune, i int) (string, int, error) {
+	if q := runes[i]; q == '"' || q == '`' {
+		end := i + 1
+		for end < len(runes) && runes[end] != q {
+			end++
+		}
+		if end >= len(runes) {
+			return "", 0, fmt.Errorf("unterminated identifier")
+		}
+		return string(runes[i+1 : end]), end + 1, nil
+	}
+
+	end := i + 1
+	for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_' || runes[end] == '-') {
+		end++
+	}
+	return string(runes[i:end]), end, nil
+}
+
+// value is a literal or a placeholder
+type value struct {
+	literal interface{}
+	param   int
+	isParam bool
+}
+
+func (v value) resolve(args []interface{}) (interface{}, error) {
+	if !v.isParam {
+		return v.literal, nil
+	}
+	if v.param >= len(args) {
+		return nil, fmt.Errorf("missing argument %d", v.param+1)
+	}
+	return args[v.param], nil
+}
+
+// assignment is a field = value pair of an UPDATE
+type assignment struct {
+	field string
+	value value
+}
+
+// expr is a WHERE expression: a *comparison or a *logical
+type expr interface{}
+
+type comparison struct {
+	field string
+	op    string
+	value value
+	// list holds the operands of IN and NOT IN
+	list []value
+}
+
+type logical struct {
+	op    string // AND or OR
+	terms []expr
+}
+
+type order struct {
+	field string
+	desc  bool
+}
+
+// statement is a parsed SQL statement
+type statement struct {
+	kind       string // SELECT, INSERT, UPDATE or DELETE
+	collection string
+	columns    []string
+	count      bool
+	// countField is the field of COUNT(field), which counts the rows
+	// where it is not NULL
+	countField string
+	rows       [][]value
+	set        []assignment
+	where      expr
+	orderBy    []order
+	limit      *value
+	offset     *value
+	params     int
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func parse(sql string) (*statement, error) {
+	tokens, err := lex(sql)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+
+	stmt := &statement{}
+	for _, t := range tokens {
+		if t.kind == tokParam && t.param+1 > stmt.params {
+			stmt.params = t.param + 1
+		}
+	}
+
+	keyword := strings.ToUpper(p.peek().text)
+	p.pos++
+	switch keyword {
+	case "SELECT":
+		err = p.parseSelect(stmt)
+	case "INSERT":
+		err = p.parseInsert(stmt)
+	case "UPDATE":
+		err = p.parseUpdate(stmt)
+	case "DELETE":
+		err = p.parseDelete(stmt)
+	default:
+		return nil, fmt.Errorf("unsupported statement %q", keyword)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p.acceptSymbol(";")
+	if p.peek().kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	stmt.kind = keyword
+	stmt.unqualify()
+	return stmt, nil
+}
+
+// unqualify strips the collection qualifier from field names, turning
+// users.name into name when selecting from users
+func (stmt *statement) unqualify() {
+	prefix := stmt.collection + "."
+	strip := func(field string) string {
+		return strings.TrimPrefix(field, prefix)
+	}
+
+	for i := range stmt.columns {
+		stmt.columns[i] = strip(stmt.columns[i])
+	}
+	stmt.countField = strip(stmt.countField)
+	for i := range stmt.set {
+		stmt.set[i].field = strip(stmt.set[i].field)
+	}
+	for i := range stmt.orderBy {
+		stmt.orderBy[i].field = strip(stmt.orderBy[i].field)
+	}
+
+	var walk func(e expr)
+	walk = func(e expr) {
+		switch e := e.(type) {
+		case *comparison:
+			e.field = strip(e.field)
+		case *logical:
+			for _, term := range e.terms {
+				walk(term)
+			}
+		}
+	}
+	walk(stmt.where)
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) acceptKeyword(kw string) bool {
+	if t := p.peek(); t.kind == tokIdent && strings.EqualFold(t.text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(kw string) error {
+	if !p.acceptKeyword(kw) {
+		return fmt.Errorf("expected %s, found %q", kw, p.peek().text)
+	}
+	return nil
+}
+
+func (p *parser) acceptSymbol(sym string) bool {
+	if t := p.peek(); t.kind == tokSymbol && t.text == sym {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectSymbol(sym string) error {
+	if !p.acceptSymbol(sym) {
+		return fmt.Errorf("expected %q, found %q", sym, p.peek().text)
+	}
+	return nil
+}
+
+func (p *parser) ident() (string, error) {
+	t := p.peek()
+	if t.kind != tokIdent {
+		return "", fmt.Errorf("expected identifier, found %q", t.text)
+	}
+	p.pos++
+	return t.text, nil
+}
+
+func (p *parser) identList() ([]string, error) {
+	var names []string
+	for {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		if !p.acceptSymbol(",") {
+			return names, nil
+		}
+	}
+}
+
+func (p *parser) value() (value, error) {
+	t := p.peek()
+	p.pos++
+	switch t.kind {
+	case tokParam:
+		return value{param: t.param, isParam: true}, nil
+	case tokString:
+		return value{literal: t.text}, nil
+	case tokNumber:
+		if n, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return value{literal: n}, nil
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return value{}, fmt.Errorf("invalid number %q", t.text)
+		}
+		return value{literal: f}, nil
+	case tokIdent:
+		switch strings.ToUpper(t.text) {
+		case "TRUE":
+			return value{literal: true}, nil
+		case "FALSE":
+			return value{literal: false}, nil
+		case "NULL":
+			return value{literal: nil}, nil
+		}
+	}
+	return value{}, fmt.Errorf("expected value, found %q", t.text)
+}
+
+func (p *parser) parseSelect(stmt *statement) error {
+	switch {
+	case p.acceptSymbol("*"):
+	case p.acceptKeyword("COUNT"):
+		if err := p.expectSymbol("("); err != nil {
+			return err
+		}
+		if !p.acceptSymbol("*") {
+			field, err := p.ident()
+			if err != nil {
+				return err
+			}
+			stmt.countField = field
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return err
+		}
+		stmt.count = true
+	default:
+		columns, err := p.identList()
+		if err != nil {
+			return err
+		}
+		stmt.columns = columns
+	}
+
+	if err := p.expectKeyword("FROM"); err != nil {
+		return err
+	}
+	var err error
+	if stmt.collection, err = p.ident(); err != nil {
+		return err
+	}
+	if err := p.parseWhere(stmt); err != nil {
+		return err
+	}
+
+	if p.acceptKeyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return err
+		}
+		for {
+			field, err := p.ident()
+			if err != nil {
+				return err
+			}
+			o := order{field: field}
+			if p.acceptKeyword("DESC") {
+				o.desc = true
+			} else {
+				p.acceptKeyword("ASC")
+			}
+			stmt.orderBy = append(stmt.orderBy, o)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+
+	if p.acceptKeyword("LIMIT") {
+		v, err := p.value()
+		if err != nil {
+			return fmt.Errorf("invalid LIMIT: %w", err)
+		}
+		stmt.limit = &v
+	}
+	if p.acceptKeyword("OFFSET") {
+		v, err := p.value()
+		if err != nil {
+			return fmt.Errorf("invalid OFFSET: %w", err)
+		}
+		stmt.offset = &v
+	}
+	return nil
+}
+
+func (p *parser) parseInsert(stmt *statement) error {
+	if err := p.expectKeyword("INTO"); err != nil {
+		return err
+	}
+	var err error
+	if stmt.collection, err = p.ident(); err != nil {
+		return err
+	}
+	if err := p.expectSymbol("("); err != nil {
+		return err
+	}
+	if stmt.columns, err = p.identList(); err != nil {
+		return err
+	}
+	if err := p.expectSymbol(")"); err != nil {
+		return err
+	}
+	if err := p.expectKeyword("VALUES"); err != nil {
+		return err
+	}
+	for {
+		row, err := p.valueList()
+		if err != nil {
+			return err
+		}
+		if len(row) != len(stmt.columns) {
+			return fmt.Errorf("%d columns but %d values", len(stmt.columns), len(row))
+		}
+		stmt.rows = append(stmt.rows, row)
+		if !p.acceptSymbol(",") {
+			return nil
+		}
+	}
+}
+
+// valueList reads a parenthesized, comma separated list of values
+func (p *parser) valueList() ([]value, error) {
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	var values []value
+	for {
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+	return values, p.expectSymbol(")")
+}
+
+func (p *parser) parseUpdate(stmt *statement) error {
+	var err error
+	if stmt.collection, err = p.ident(); err != nil {
+		return err
+	}
+	if err := p.expectKeyword("SET"); err != nil {
+		return err
+	}
+	for {
+		field, err := p.ident()
+		if err != nil {
+			return err
+		}
+		if err := p.expectSymbol("="); err != nil {
+			return err
+		}
+		v, err := p.value()
+		if err != nil {
+			return err
+		}
+		stmt.set = append(stmt.set, assignment{field: field, value: v})
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+	return p.parseWhere(stmt)
+}
+
+func (p *parser) parseDelete(stmt *statement) error {
+	if err := p.expectKeyword("FROM"); err != nil {
+		return err
+	}
+	var err error
+	if stmt.collection, err = p.ident(); err != nil {
+		return err
+	}
+	return p.parseWhere(stmt)
+}
+
+// parseWhere reads an optional WHERE clause
+func (p *parser) parseWhere(stmt *statement) error {
+	if !p.acceptKeyword("WHERE") {
+		return nil
+	}
+	where, err := p.parseOr()
+	stmt.where = where
+	return err
+}
+
+func (p *parser) parseOr() (expr, error) {
+	return p.parseLogical("OR", p.parseAnd)
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	return p.parseLogical("AND", p.parseTerm)
+}
+
+func (p *parser) parseLogical(op string, next func() (expr, error)) (expr, error) {
+	first, err := next()
+	if err != nil {
+		return nil, err
+	}
+	terms := []expr{first}
+	for p.acceptKeyword(op) {
+		term, err := next()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 1 {
+		return first, nil
+	}
+	return &logical{op: op, terms: terms}, nil
+}
+
+// parseTerm reads a parenthesized expression or a single comparison
+func (p *parser) parseTerm() (expr, error) {
+	if p.acceptSymbol("(") {
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return e, p.expectSymbol(")")
+	}
+
+	field, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.acceptKeyword("IS") {
+		op := "="
+		if p.acceptKeyword("NOT") {
+			op = "!="
+		}
+		return &comparison{field: field, op: op}, p.expectKeyword("NULL")
+	}
+
+	if p.acceptKeyword("NOT") {
+		if err := p.expectKeyword("IN"); err != nil {
+			return nil, err
+		}
+		list, err := p.valueList()
+		return &comparison{field: field, op: "NOT IN", list: list}, err
+	}
+	if p.acceptKeyword("IN") {
+		list, err := p.valueList()
+		return &comparison{field: field, op: "IN", list: list}, err
+	}
+
+	t := p.peek()
+	switch t.text {
+	case "=", "!=", "<>", "<", "<=", ">", ">=":
+	default:
+		return nil, fmt.Errorf("unsupported operator %q", t.text)
+	}
+	p.pos++
+	v, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	return &comparison{field: field, op: t.text, value: v}, nil
+}
@@ -0,0 +1,342 @@
+package gitdbsql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb"
+)
+
+func TestParseSelect(t *testing.T) {
+	tests := []struct {
+		name       string
+		sql        string
+		args       []interface{}
+		columns    []string
+		count      bool
+		countField string
+		query      gitdb.Query
+		orderBy    []order
+		limit      int
+		offset     int
+	}{
+		{
+			name:  "all columns",
+			sql:   "SELECT * FROM users",
+			query: gitdb.Query{},
+			limit: -1, offset: -1,
+		},
+		{
+			name:    "qualified columns",
+			sql:     "SELECT users.name, `address.city` FROM users",
+			columns: []string{"name", "address.city"},
+			query:   gitdb.Query{},
+			limit:   -1, offset: -1,
+		},
+		{
+			name:  "count rows",
+			sql:   "SELECT COUNT(*) FROM users WHERE active = TRUE",
+			count: true,
+			query: gitdb.Query{"active": true},
+			limit: -1, offset: -1,
+		},
+		{
+			name:       "count field",
+			sql:        "SELECT COUNT(`users`.`email`) FROM `users`",
+			count:      true,
+			countField: "email",
+			query:      gitdb.Query{},
+			limit:      -1, offset: -1,
+		},
+		{
+			name:  "comparisons merged under AND",
+			sql:   "SELECT * FROM users WHERE age >= 18 AND age < 65 AND name != 'x'",
+			query: gitdb.Query{"age": map[string]interface{}{"$gte": int64(18), "$lt": int64(65)}, "name": map[string]interface{}{"$ne": "x"}},
+			limit: -1, offset: -1,
+		},
+		{
+			name: "OR with parentheses",
+			sql:  "SELECT * FROM users WHERE (plan = 'pro' OR plan = 'team') AND deleted IS NULL",
+			query: gitdb.Query{
+				"$or":     []interface{}{map[string]interface{}{"plan": "pro"}, map[string]interface{}{"plan": "team"}},
+				"deleted": nil,
+			},
+			limit: -1, offset: -1,
+		},
+		{
+			name:  "IN, NOT IN and IS NOT NULL",
+			sql:   "SELECT * FROM users WHERE role IN ('a', 'b') AND id NOT IN (1) AND email IS NOT NULL",
+			query: gitdb.Query{"role": map[string]interface{}{"$in": []interface{}{"a", "b"}}, "id": map[string]interface{}{"$nin": []interface{}{int64(1)}}, "email": map[string]interface{}{"$ne": nil}},
+			limit: -1, offset: -1,
+		},
+		{
+			name:    "order, limit and offset",
+			sql:     "SELECT name FROM users ORDER BY age DESC, name LIMIT 10 OFFSET 20;",
+			columns: []string{"name"},
+			query:   gitdb.Query{},
+			orderBy: []order{{field: "age", desc: true}, {field: "name"}},
+			limit:   10, offset: 20,
+		},
+		{
+			name:  "positional placeholders",
+			sql:   "SELECT * FROM users WHERE name = ? AND age > ? LIMIT ?",
+			args:  []interface{}{"Ada", int64(30), int64(5)},
+			query: gitdb.Query{"name": "Ada", "age": map[string]interface{}{"$gt": int64(30)}},
+			limit: 5, offset: -1,
+		},
+		{
+			name:  "numbered placeholders",
+			sql:   "SELECT * FROM users WHERE name = $2 OR nick = $2 OR id = $1",
+			args:  []interface{}{int64(7), "ada"},
+			query: gitdb.Query{"$or": []interface{}{map[string]interface{}{"name": "ada"}, map[string]interface{}{"nick": "ada"}, map[string]interface{}{"id": int64(7)}}},
+			limit: -1, offset: -1,
+		},
+		{
+			name:  "escaped quote and negative float",
+			sql:   "SELECT * FROM notes WHERE text = 'it''s' AND score > -1.5",
+			query: gitdb.Query{"text": "it's", "score": map[string]interface{}{"$gt": -1.5}},
+			limit: -1, offset: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := parse(tt.sql)
+			if err != nil {
+				t.Fatalf("parse(%q): %v", tt.sql, err)
+			}
+			if stmt.kind != "SELECT" {
+				t.Errorf("kind = %q, want SELECT", stmt.kind)
+			}
+			if stmt.params != len(tt.args) {
+				t.Errorf("params = %d, want %d", stmt.params, len(tt.args))
+			}
+			if !reflect.DeepEqual(stmt.columns, tt.columns) {
+				t.Errorf("columns = %q, want %q", stmt.columns, tt.columns)
+			}
+			if stmt.count != tt.count || stmt.countField != tt.countField {
+				t.Errorf("count = %v %q, want %v %q", stmt.count, stmt.countField, tt.count, tt.countField)
+			}
+			if !reflect.DeepEqual(stmt.orderBy, tt.orderBy) {
+				t.Errorf("orderBy = %v, want %v", stmt.orderBy, tt.orderBy)
+			}
+
+			query, err := buildQuery(stmt.where, tt.args)
+			if err != nil {
+				t.Fatalf("buildQuery: %v", err)
+			}
+			if !reflect.DeepEqual(query, tt.query) {
+				t.Errorf("query = %#v, want %#v", query, tt.query)
+			}
+
+			for _, bound := range []struct {
+				name string
+				v    *value
+				want int
+			}{{"limit", stmt.limit, tt.limit}, {"offset", stmt.offset, tt.offset}} {
+				got := -1
+				if bound.v != nil {
+					if got, err = count(*bound.v, tt.args); err != nil {
+						t.Fatalf("%s: %v", bound.name, err)
+					}
+				}
+				if got != bound.want {
+					t.Errorf("%s = %d, want %d", bound.name, got, bound.want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseInsert(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		args    []interface{}
+		columns []string
+		rows    [][]interface{}
+	}{
+		{
+			name:    "literals",
+			sql:     "INSERT INTO users (name, age, admin, note) VALUES ('Ada', 36, FALSE, NULL)",
+			columns: []string{"name", "age", "admin", "note"},
+			rows:    [][]interface{}{{"Ada", int64(36), false, nil}},
+		},
+		{
+			name:    "several rows of placeholders",
+			sql:     "INSERT INTO `users` (`users`.`name`, `age`) VALUES (?, ?), (?, ?)",
+			args:    []interface{}{"Ada", int64(36), "Alan", int64(41)},
+			columns: []string{"name", "age"},
+			rows:    [][]interface{}{{"Ada", int64(36)}, {"Alan", int64(41)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := parse(tt.sql)
+			if err != nil {
+				t.Fatalf("parse(%q): %v", tt.sql, err)
+			}
+			if stmt.kind != "INSERT" || stmt.collection != "users" {
+				t.Errorf("got %s into %q, want INSERT into users", stmt.kind, stmt.collection)
+			}
+			if !reflect.DeepEqual(stmt.columns, tt.columns) {
+				t.Errorf("columns = %q, want %q", stmt.columns, tt.columns)
+			}
+
+			rows := make([][]interface{}, len(stmt.rows))
+			for i, row := range stmt.rows {
+				for _, v := range row {
+					resolved, err := v.resolve(tt.args)
+					if err != nil {
+						t.Fatalf("row %d: %v", i, err)
+					}
+					rows[i] = append(rows[i], resolved)
+				}
+			}
+			if !reflect.DeepEqual(rows, tt.rows) {
+				t.Errorf("rows = %#v, want %#v", rows, tt.rows)
+			}
+		})
+	}
+}
+
+func TestParseUpdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		sql    string
+		args   []interface{}
+		update gitdb.Update
+		query  gitdb.Query
+	}{
+		{
+			name:   "set fields",
+			sql:    "UPDATE users SET plan = 'pro', seats = 5 WHERE id = 1",
+			update: gitdb.Update{"$set": map[string]interface{}{"plan": "pro", "seats": int64(5)}},
+			query:  gitdb.Query{"id": int64(1)},
+		},
+		{
+			name:   "NULL literal unsets",
+			sql:    "UPDATE users SET users.note = NULL, plan = 'free'",
+			update: gitdb.Update{"$set": map[string]interface{}{"plan": "free"}, "$unset": map[string]interface{}{"note": ""}},
+			query:  gitdb.Query{},
+		},
+		{
+			name:   "nil argument unsets",
+			sql:    "UPDATE users SET note = ? WHERE name = ?",
+			args:   []interface{}{nil, "Ada"},
+			update: gitdb.Update{"$unset": map[string]interface{}{"note": ""}},
+			query:  gitdb.Query{"name": "Ada"},
+		},
+		{
+			name:   "byte argument becomes a string",
+			sql:    "UPDATE users SET avatar = $1",
+			args:   []interface{}{[]byte("png")},
+			update: gitdb.Update{"$set": map[string]interface{}{"avatar": "png"}},
+			query:  gitdb.Query{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := parse(tt.sql)
+			if err != nil {
+				t.Fatalf("parse(%q): %v", tt.sql, err)
+			}
+			if stmt.kind != "UPDATE" || stmt.collection != "users" {
+				t.Errorf("got %s of %q, want UPDATE of users", stmt.kind, stmt.collection)
+			}
+
+			update, err := buildUpdate(stmt.set, tt.args)
+			if err != nil {
+				t.Fatalf("buildUpdate: %v", err)
+			}
+			if !reflect.DeepEqual(update, tt.update) {
+				t.Errorf("update = %#v, want %#v", update, tt.update)
+			}
+			query, err := buildQuery(stmt.where, tt.args)
+			if err != nil {
+				t.Fatalf("buildQuery: %v", err)
+			}
+			if !reflect.DeepEqual(query, tt.query) {
+				t.Errorf("query = %#v, want %#v", query, tt.query)
+			}
+		})
+	}
+}
+
+func TestParseDelete(t *testing.T) {
+	tests := []struct {
+		sql   string
+		args  []interface{}
+		query gitdb.Query
+	}{
+		{sql: "DELETE FROM sessions", query: gitdb.Query{}},
+		{sql: "delete from sessions where expires <= ?", args: []interface{}{int64(100)}, query: gitdb.Query{"expires": map[string]interface{}{"$lte": int64(100)}}},
+		{sql: `DELETE FROM "sessions" WHERE "sessions"."user" = 'u1' OR "user" <> 'u2'`, query: gitdb.Query{"$or": []interface{}{map[string]interface{}{"user": "u1"}, map[string]interface{}{"user": map[string]interface{}{"$ne": "u2"}}}}},
+	}
+
+	for _, tt := range tests {
+		stmt, err := parse(tt.sql)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", tt.sql, err)
+		}
+		if stmt.kind != "DELETE" || stmt.collection != "sessions" {
+			t.Errorf("%q: got %s from %q, want DELETE from sessions", tt.sql, stmt.kind, stmt.collection)
+		}
+		query, err := buildQuery(stmt.where, tt.args)
+		if err != nil {
+			t.Fatalf("%q: buildQuery: %v", tt.sql, err)
+		}
+		if !reflect.DeepEqual(query, tt.query) {
+			t.Errorf("%q: query = %#v, want %#v", tt.sql, query, tt.query)
+		}
+	}
+}
+
+func TestParseMissingArgument(t *testing.T) {
+	stmt, err := parse("SELECT * FROM users WHERE a = ? AND b = ?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := buildQuery(stmt.where, []interface{}{1}); err == nil || !strings.Contains(err.Error(), "missing argument 2") {
+		t.Errorf("buildQuery with one argument = %v, want missing argument 2", err)
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"", "unsupported statement"},
+		{"DROP TABLE users", "unsupported statement"},
+		{"SELECT * users", "expected FROM"},
+		{"SELECT * FROM users WHERE", "expected identifier"},
+		{"SELECT * FROM users WHERE name LIKE 'a%'", "unsupported operator"},
+		{"SELECT * FROM users WHERE (a = 1", `expected ")"`},
+		{"SELECT * FROM users WHERE name = 'open", "unterminated string"},
+		{"SELECT * FROM `users", "unterminated identifier"},
+		{"SELECT * FROM users WHERE a = $0", "invalid placeholder"},
+		{"SELECT * FROM users LIMIT x", "invalid LIMIT"},
+		{"SELECT * FROM users; SELECT 1", "unexpected"},
+		{"SELECT * FROM users WHERE a = 1 + 2", "unexpected character"},
+		{"SELECT COUNT(* FROM users", `expected ")"`},
+		{"INSERT INTO users (a, b) VALUES (1)", "2 columns but 1 values"},
+		{"INSERT INTO users VALUES (1)", `expected "("`},
+		{"UPDATE users name = 'x'", "expected SET"},
+		{"DELETE users", "expected FROM"},
+	}
+
+	for _, tt := range tests {
+		_, err := parse(tt.sql)
+		if err == nil {
+			t.Errorf("parse(%q) succeeded, want an error containing %q", tt.sql, tt.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parse(%q) = %v, want an error containing %q", tt.sql, err, tt.want)
+		}
+	}
+}
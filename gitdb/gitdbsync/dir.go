@@ -0,0 +1,342 @@
+package gitdbsync
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb"
+)
+
+// StateFile is the file in a mirrored directory recording what was last
+// synced, so local and remote edits can be told apart
+const StateFile = ".gitdb-sync.json"
+
+// conflictSuffix names the file holding the remote version of a conflicting
+// document, next to the local one; it holds null for a remote deletion
+const conflictSuffix = ".remote.json"
+
+// dirState is the content of StateFile
+type dirState struct {
+	Collection string `json:"collection"`
+	// Watermark is the remote commit the directory was last synced at
+	Watermark string `json:"watermark"`
+	// Files maps document IDs to the hash of their file as last synced
+	Files map[string]string `json:"files"`
+}
+
+// DirReport summarises a directory sync
+type DirReport struct {
+	// Written and Removed count local files updated from remote changes
+	Written int
+	Removed int
+	// Uploaded and Deleted count local edits pushed to the collection
+	Uploaded int
+	Deleted  int
+	// Conflicts lists documents changed both locally and remotely. The
+	// remote version is saved next to the local file as <id>.remote.json
+	// and the document is not pushed until that file is removed: merge it
+	// into the local file and delete it, or rename it over the local file
+	// to take the remote version.
+	Conflicts []string
+}
+
+// Pull mirrors a collection into dir as one indented JSON file per
+// document, named after its ID, so documents can be edited in any editor
+// and diffed with git. Only the documents changed since the last sync are
+// fetched. Local edits are never overwritten: documents changed on both
+// sides are reported as conflicts.
+func Pull(ctx context.Context, client *gitdb.Client, collection, dir string) (*DirReport, error) {
+	st, err := loadDirState(dir, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DirReport{}
+	if err := pullChanges(ctx, client, collection, dir, st, report); err != nil {
+		return report, err
+	}
+	return report, saveDirState(dir, st)
+}
+
+// Push uploads the documents edited, added or deleted in dir since the last
+// sync, after pulling remote changes as Pull does. Files that do not hold a
+// valid JSON object are reported as errors before anything is uploaded.
+func Push(ctx context.Context, client *gitdb.Client, collection, dir string) (*DirReport, error) {
+	st, err := loadDirState(dir, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DirReport{}
+	if err := pullChanges(ctx, client, collection, dir, st, report); err != nil {
+		return report, err
+	}
+
+	local, conflicted, err := readDir(dir)
+	if err != nil {
+		return report, err
+	}
+	report.Conflicts = report.Conflicts[:0]
+	for id := range conflicted {
+		report.Conflicts = append(report.Conflicts, id)
+	}
+	sort.Strings(report.Conflicts)
+
+	var changes []gitdb.Change
+	for id, file := range local {
+		if conflicted[id] || st.Files[id] == file.hash {
+			continue
+		}
+		var doc gitdb.Document
+		if err := json.Unmarshal(file.data, &doc); err != nil || doc == nil {
+			return report, fmt.Errorf("%s: not a JSON document", filepath.Join(dir, id+".json"))
+		}
+		delete(doc, "_id")
+		changes = append(changes, gitdb.Change{Op: gitdb.ChangeUpsert, ID: id, Document: doc})
+		report.Uploaded++
+	}
+	for id := range st.Files {
+		if _, ok := local[id]; !ok && !conflicted[id] {
+			changes = append(changes, gitdb.Change{Op: gitdb.ChangeDelete, ID: id})
+			report.Deleted++
+		}
+	}
+	if len(changes) == 0 {
+		return report, saveDirState(dir, st)
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ID < changes[j].ID })
+	if _, err := client.ApplyChanges(ctx, collection, changes); err != nil {
+		return report, err
+	}
+	for _, change := range changes {
+		if change.Op == gitdb.ChangeDelete {
+			delete(st.Files, change.ID)
+		} else {
+			st.Files[change.ID] = local[change.ID].hash
+		}
+	}
+
+	// Catch up past our own commit, normalising the formatting of the
+	// uploaded files, and pick up anything written meanwhile
+	if err := pullChanges(ctx, client, collection, dir, st, &DirReport{}); err != nil {
+		return report, err
+	}
+	return report, saveDirState(dir, st)
+}
+
+// pullChanges applies the remote changes since the directory's watermark
+func pullChanges(ctx context.Context, client *gitdb.Client, collection, dir string, st *dirState, report *DirReport) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	for {
+		changes, err := client.Changes(ctx, collection, st.Watermark)
+		if err != nil {
+			return err
+		}
+
+		for _, change := range changes.Changes {
+			if err := pullChange(dir, st, change, report); err != nil {
+				return err
+			}
+		}
+
+		if changes.Head != "" {
+			st.Watermark = changes.Head
+		}
+		if !changes.More {
+			return nil
+		}
+	}
+}
+
+// pullChange applies one remote change to the directory
+func pullChange(dir string, st *dirState, change gitdb.Change, report *DirReport) error {
+	if err := validateFileID(change.ID); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, change.ID+".json")
+	current, err := os.ReadFile(path)
+	exists := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var incoming []byte
+	if change.Op == gitdb.ChangeUpsert {
+		if incoming, err = encodeFile(change.Document); err != nil {
+			return err
+		}
+	}
+
+	if change.Op == gitdb.ChangeDelete && !exists {
+		// Deleted on both sides
+		delete(st.Files, change.ID)
+		return nil
+	}
+
+	currentHash := ""
+	if exists {
+		currentHash = hashFile(current)
+	}
+	editedLocally := currentHash != st.Files[change.ID]
+	if editedLocally && !(exists && bytes.Equal(current, incoming)) {
+		// Keep the remote version aside and make it the new base, so the
+		// local file is pushed over it once the conflict is resolved
+		remote := incoming
+		if change.Op == gitdb.ChangeDelete {
+			remote = []byte("null\n")
+			delete(st.Files, change.ID)
+		} else {
+			st.Files[change.ID] = hashFile(incoming)
+		}
+		if err := os.WriteFile(filepath.Join(dir, change.ID+conflictSuffix), remote, 0o644); err != nil {
+			return err
+		}
+		report.Conflicts = append(report.Conflicts, change.ID)
+		return nil
+	}
+
+	if change.Op == gitdb.ChangeDelete {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		report.Removed++
+		delete(st.Files, change.ID)
+		return nil
+	}
+
+	if !exists || !bytes.Equal(current, incoming) {
+		if err := os.WriteFile(path, incoming, 0o644); err != nil {
+			return err
+		}
+		report.Written++
+	}
+	st.Files[change.ID] = hashFile(incoming)
+	return nil
+}
+
+// validateFileID checks that a document ID names a file directly inside
+// the mirrored directory, so a change from the server can never write
+// outside it, over the state file or over a conflict file
+func validateFileID(id string) error {
+	if err := gitdb.ValidateDocumentID(id); err != nil {
+		return fmt.Errorf("cannot mirror document: %w", err)
+	}
+	invalid := func(reason string) error {
+		return fmt.Errorf("cannot mirror document %q: %s", id, reason)
+	}
+	if filepath.Base(id) != id || strings.ContainsAny(id, `/\`) || strings.Contains(id, "..") {
+		return invalid(`ID must be a plain file name without ".."`)
+	}
+	if strings.HasPrefix(id, ".") {
+		return invalid("ID names a hidden file")
+	}
+	if strings.HasSuffix(id+".json", conflictSuffix) {
+		return invalid("ID names a conflict file")
+	}
+	return nil
+}
+
+// localFile is a document file read from a mirrored directory
+type localFile struct {
+	data []byte
+	hash string
+}
+
+// readDir reads the document files of dir, keyed by document ID, and the
+// IDs of unresolved conflicts
+func readDir(dir string) (map[string]localFile, map[string]bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files := make(map[string]localFile)
+	conflicts := make(map[string]bool)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if strings.HasSuffix(name, conflictSuffix) {
+			conflicts[strings.TrimSuffix(name, conflictSuffix)] = true
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, nil, err
+		}
+		files[strings.TrimSuffix(name, ".json")] = localFile{data: data, hash: hashFile(data)}
+	}
+	return files, conflicts, nil
+}
+
+// encodeFile formats a document the way it is stored on disk, without its
+// ID, which is the file name
+func encodeFile(doc gitdb.Document) ([]byte, error) {
+	fields := make(map[string]interface{}, len(doc))
+	for key, value := range doc {
+		if key != "_id" {
+			fields[key] = value
+		}
+	}
+	data, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func hashFile(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// loadDirState reads the sync state of dir, starting afresh if there is
+// none
+func loadDirState(dir, collection string) (*dirState, error) {
+	st := &dirState{Collection: collection, Files: make(map[string]string)}
+
+	data, err := os.ReadFile(filepath.Join(dir, StateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("invalid sync state in %s: %w", dir, err)
+	}
+	if st.Collection != collection {
+		return nil, fmt.Errorf("%s mirrors collection %s, not %s", dir, st.Collection, collection)
+	}
+	if st.Files == nil {
+		st.Files = make(map[string]string)
+	}
+	return st, nil
+}
+
+// saveDirState writes the sync state of dir atomically
+func saveDirState(dir string, st *dirState) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, StateFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, StateFile))
+}
@@ -0,0 +1,170 @@
+// Package gitdbsync copies collections between GitDB instances and keeps
+// them in step, for migrations and disaster-recovery drills.
+package gitdbsync
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb"
+)
+
+// Options configures Sync
+type Options struct {
+	// Collections to copy; all collections of the source by default
+	Collections []string
+
+	// Watermarks maps collections to the source commit they were last
+	// copied at, so an interrupted sync resumes where it stopped.
+	// Collections without a watermark are copied in full.
+	Watermarks map[string]string
+
+	// OnCheckpoint, when set, is called after each batch of changes is
+	// applied with the new watermark of the collection; persist it and pass
+	// it back in Watermarks to resume
+	OnCheckpoint func(collection, commit string)
+
+	// Continuous keeps polling the source for changes until ctx is done
+	// instead of returning after one pass
+	Continuous bool
+	// Interval between passes in continuous mode, 10 seconds by default
+	Interval time.Duration
+	// OnError, when set, receives the errors of continuous passes, which
+	// are otherwise retried silently on the next pass
+	OnError func(collection string, err error)
+}
+
+// CollectionReport counts the changes copied for a collection
+type CollectionReport struct {
+	Upserted  int
+	Deleted   int
+	Watermark string
+}
+
+// Report summarises a sync
+type Report struct {
+	Collections map[string]*CollectionReport
+}
+
+// Sync incrementally copies collections from src to dst. Each collection's
+// position is tracked as a source commit SHA, so every pass only copies the
+// documents changed since the last one, and deletions are carried over.
+// In one-shot mode Sync returns after a single pass; in continuous mode it
+// runs passes until ctx is done and then returns the report with ctx's
+// error.
+func Sync(ctx context.Context, src, dst *gitdb.Client, opts Options) (*Report, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = 10 * time.Second
+	}
+
+	watermarks := make(map[string]string, len(opts.Watermarks))
+	for collection, commit := range opts.Watermarks {
+		watermarks[collection] = commit
+	}
+	report := &Report{Collections: make(map[string]*CollectionReport)}
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		collections, err := syncCollections(ctx, src, opts.Collections)
+		if err == nil {
+			for _, collection := range collections {
+				if err = ctx.Err(); err != nil {
+					break
+				}
+				if err = syncCollection(ctx, src, dst, collection, watermarks, report, opts.OnCheckpoint); err != nil {
+					err = fmt.Errorf("failed to sync %s: %w", collection, err)
+					if !opts.Continuous {
+						break
+					}
+					if opts.OnError != nil {
+						opts.OnError(collection, err)
+					}
+					err = nil
+				}
+			}
+		} else if opts.Continuous {
+			if opts.OnError != nil {
+				opts.OnError("", err)
+			}
+			err = nil
+		}
+
+		if !opts.Continuous || err != nil {
+			return report, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return report, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncCollections resolves the collections to copy
+func syncCollections(ctx context.Context, src *gitdb.Client, selected []string) ([]string, error) {
+	if len(selected) > 0 {
+		return selected, nil
+	}
+
+	collections, err := src.ListCollections(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source collections: %w", err)
+	}
+	names := make([]string, len(collections))
+	for i, collection := range collections {
+		names[i] = collection.Name
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// syncCollection copies the changes to one collection since its watermark
+func syncCollection(ctx context.Context, src, dst *gitdb.Client, collection string, watermarks map[string]string, report *Report, checkpoint func(string, string)) error {
+	stats := report.Collections[collection]
+	if stats == nil {
+		stats = &CollectionReport{}
+		report.Collections[collection] = stats
+	}
+
+	if watermarks[collection] == "" {
+		if err := dst.EnsureCollection(ctx, collection); err != nil {
+			return err
+		}
+	}
+
+	for {
+		changes, err := src.Changes(ctx, collection, watermarks[collection])
+		if err != nil {
+			return err
+		}
+
+		if len(changes.Changes) > 0 {
+			if _, err := dst.ApplyChanges(ctx, collection, changes.Changes); err != nil {
+				return err
+			}
+			for _, change := range changes.Changes {
+				if change.Op == gitdb.ChangeDelete {
+					stats.Deleted++
+				} else {
+					stats.Upserted++
+				}
+			}
+		}
+
+		if changes.Head != "" && changes.Head != watermarks[collection] {
+			watermarks[collection] = changes.Head
+			stats.Watermark = changes.Head
+			if checkpoint != nil {
+				checkpoint(collection, changes.Head)
+			}
+		}
+		if !changes.More {
+			return nil
+		}
+	}
+}
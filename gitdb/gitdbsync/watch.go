@@ -0,0 +1,131 @@
+package gitdbsync
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb"
+)
+
+// WatchOptions configures Watch
+type WatchOptions struct {
+	// PollInterval is how often the directory is checked for edits, half a
+	// second by default
+	PollInterval time.Duration
+	// RemoteInterval is how often the collection is checked for changes
+	// when nothing was edited locally, 5 seconds by default
+	RemoteInterval time.Duration
+
+	// OnSync, when set, is called after every sync that changed something
+	// on either side or found conflicts
+	OnSync func(report *DirReport)
+	// OnError, when set, receives sync errors, which are otherwise retried
+	// silently on the next change
+	OnError func(err error)
+}
+
+// Watch keeps dir and a collection in step until ctx is done, for a
+// live-reload workflow: local edits are pushed as soon as they are saved,
+// and remote changes are pulled into the directory as they land. It pushes
+// once on start and returns ctx's error. Conflicts are handled as in Push.
+//
+// The directory is polled for changes to file sizes and modification
+// times, which needs no platform-specific watcher.
+func Watch(ctx context.Context, client *gitdb.Client, collection, dir string, opts WatchOptions) error {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 500 * time.Millisecond
+	}
+	if opts.RemoteInterval <= 0 {
+		opts.RemoteInterval = 5 * time.Second
+	}
+
+	sync := func(fn func(context.Context, *gitdb.Client, string, string) (*DirReport, error)) {
+		report, err := fn(ctx, client, collection, dir)
+		if err != nil {
+			if opts.OnError != nil {
+				opts.OnError(err)
+			}
+			return
+		}
+		if opts.OnSync != nil && !report.empty() {
+			opts.OnSync(report)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	sync(Push)
+	seen, err := dirFingerprint(dir)
+	if err != nil {
+		return err
+	}
+	lastRemote := time.Now()
+
+	ticker := time.NewTicker(opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		current, err := dirFingerprint(dir)
+		if err != nil {
+			if opts.OnError != nil {
+				opts.OnError(err)
+			}
+			continue
+		}
+
+		switch {
+		case current != seen:
+			sync(Push)
+		case time.Since(lastRemote) >= opts.RemoteInterval:
+			sync(Pull)
+		default:
+			continue
+		}
+		lastRemote = time.Now()
+
+		// Take the fingerprint after syncing, so files written by the
+		// sync itself are not mistaken for local edits
+		if seen, err = dirFingerprint(dir); err != nil && opts.OnError != nil {
+			opts.OnError(err)
+		}
+	}
+}
+
+// empty reports whether a sync changed nothing and found no conflicts
+func (r *DirReport) empty() bool {
+	return r.Written == 0 && r.Removed == 0 && r.Uploaded == 0 && r.Deleted == 0 && len(r.Conflicts) == 0
+}
+
+// dirFingerprint summarises the names, sizes and modification times of the
+// document files in dir
+func dirFingerprint(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	var fingerprint []byte
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Name() == StateFile {
+			continue
+		}
+		info, err := entry.Info()
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		fingerprint = fmt.Appendf(fingerprint, "%s %d %d\n", entry.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+	return string(fingerprint), nil
+}
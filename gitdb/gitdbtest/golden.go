@@ -0,0 +1,189 @@
+// Package gitdbtest provides helpers for testing code that talks to GitDB.
+package gitdbtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb"
+)
+
+var updateGolden = flag.Bool("update-golden", false, "rewrite gitdbtest golden files instead of comparing against them")
+
+// SnapshotOptions controls how collections are normalized
+type SnapshotOptions struct {
+	// Ignore lists fields removed from every document before comparison,
+	// such as timestamps. Dotted paths reach into nested objects.
+	Ignore []string
+}
+
+// Snapshot is a normalized view of one or more collections. Documents are
+// sorted by content and every document ID is replaced by a stable
+// placeholder such as "users#1", including where the ID appears as a value
+// in another document.
+type Snapshot map[string][]gitdb.Document
+
+// Take reads every document of the given collections and normalizes them
+func Take(ctx context.Context, client *gitdb.Client, opts SnapshotOptions, collections ...string) (Snapshot, error) {
+	raw := make(Snapshot, len(collections))
+	for _, collection := range collections {
+		docs, err := client.Find(ctx, collection, gitdb.Query{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to snapshot %s: %w", collection, err)
+		}
+		for _, doc := range docs {
+			for _, path := range opts.Ignore {
+				deletePath(doc, strings.Split(path, "."))
+			}
+		}
+		raw[collection] = docs
+	}
+	return normalize(raw), nil
+}
+
+// JSON renders the snapshot as indented JSON with sorted keys
+func (s Snapshot) JSON() []byte {
+	data, _ := json.MarshalIndent(s, "", "  ")
+	return append(data, '\n')
+}
+
+// AssertGolden snapshots the collections and compares them to
+// testdata/<name>.golden.json. Run the tests with -update-golden to write the
+// file from the current state.
+func AssertGolden(t testing.TB, client *gitdb.Client, name string, collections ...string) {
+	t.Helper()
+	AssertGoldenWith(t, client, name, SnapshotOptions{}, collections...)
+}
+
+// AssertGoldenWith is AssertGolden with normalization options
+func AssertGoldenWith(t testing.TB, client *gitdb.Client, name string, opts SnapshotOptions, collections ...string) {
+	t.Helper()
+
+	snapshot, err := Take(context.Background(), client, opts, collections...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := snapshot.JSON()
+
+	path := filepath.Join("testdata", name+".golden.json")
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update-golden to create it): %v", err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Errorf("collections differ from %s (-want +got):\n%s", path, Diff(string(want), string(got)))
+	}
+}
+
+// normalize sorts documents and replaces IDs with stable placeholders
+func normalize(raw Snapshot) Snapshot {
+	ids := make(map[string]bool)
+	for _, docs := range raw {
+		for _, doc := range docs {
+			if id, ok := doc["_id"].(string); ok {
+				ids[id] = true
+			}
+		}
+	}
+
+	// Sort on content with every ID masked, so the order does not depend on
+	// the random IDs the server assigned
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	placeholders := make(map[string]string)
+	for _, name := range names {
+		docs := raw[name]
+		keys := make([]string, len(docs))
+		for i, doc := range docs {
+			data, _ := json.Marshal(replaceIDs(doc, ids, nil))
+			keys[i] = string(data)
+		}
+		sort.Stable(byKey{docs, keys})
+
+		for i, doc := range docs {
+			if id, ok := doc["_id"].(string); ok {
+				placeholders[id] = fmt.Sprintf("%s#%d", name, i+1)
+			}
+		}
+	}
+
+	out := make(Snapshot, len(raw))
+	for name, docs := range raw {
+		normalized := make([]gitdb.Document, len(docs))
+		for i, doc := range docs {
+			normalized[i] = replaceIDs(doc, ids, placeholders).(map[string]interface{})
+		}
+		out[name] = normalized
+	}
+	return out
+}
+
+type byKey struct {
+	docs []gitdb.Document
+	keys []string
+}
+
+func (b byKey) Len() int           { return len(b.docs) }
+func (b byKey) Less(i, j int) bool { return b.keys[i] < b.keys[j] }
+func (b byKey) Swap(i, j int) {
+	b.docs[i], b.docs[j] = b.docs[j], b.docs[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
+
+// replaceIDs copies v, substituting every string that is a known ID. With a
+// nil placeholder map IDs are masked.
+func replaceIDs(v interface{}, ids map[string]bool, placeholders map[string]string) interface{} {
+	switch v := v.(type) {
+	case gitdb.Document:
+		return replaceIDs(map[string]interface{}(v), ids, placeholders)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			out[key] = replaceIDs(value, ids, placeholders)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, value := range v {
+			out[i] = replaceIDs(value, ids, placeholders)
+		}
+		return out
+	case string:
+		if ids[v] {
+			return placeholders[v]
+		}
+	}
+	return v
+}
+
+func deletePath(doc map[string]interface{}, path []string) {
+	if len(path) == 1 {
+		delete(doc, path[0])
+		return
+	}
+	if nested, ok := doc[path[0]].(map[string]interface{}); ok {
+		deletePath(nested, path[1:])
+	}
+}
@@ -0,0 +1,290 @@
+package gitdbtest
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb"
+)
+
+// DefaultImage is the server image started when neither ServerOptions.Image
+// nor GITDB_TEST_IMAGE is set
+const DefaultImage = "gitdb/server:latest"
+
+// ServerOptions configures StartServer. Zero values fall back to the
+// GITDB_TEST_* environment variables and then to defaults.
+type ServerOptions struct {
+	// URL points at an already running server instead of starting a
+	// container (GITDB_TEST_URL)
+	URL string
+	// Image is the container image to run (GITDB_TEST_IMAGE)
+	Image string
+	// Port is the port the server listens on inside the container
+	Port int
+	// Env is passed to the container
+	Env map[string]string
+	// Token and Owner authenticate the client (GITDB_TEST_TOKEN,
+	// GITDB_TEST_OWNER)
+	Token string
+	Owner string
+	// StartupTimeout bounds the wait for the server to become healthy
+	StartupTimeout time.Duration
+}
+
+func (o *ServerOptions) defaults() {
+	if o.URL == "" {
+		o.URL = os.Getenv("GITDB_TEST_URL")
+	}
+	if o.Image == "" {
+		o.Image = os.Getenv("GITDB_TEST_IMAGE")
+	}
+	if o.Image == "" {
+		o.Image = DefaultImage
+	}
+	if o.Port == 0 {
+		o.Port = 7896
+	}
+	if o.Token == "" {
+		o.Token = os.Getenv("GITDB_TEST_TOKEN")
+	}
+	if o.Owner == "" {
+		o.Owner = os.Getenv("GITDB_TEST_OWNER")
+	}
+	if o.Owner == "" {
+		o.Owner = "gitdbtest"
+	}
+	if o.StartupTimeout <= 0 {
+		o.StartupTimeout = time.Minute
+	}
+}
+
+// Server is a GitDB server started for a test
+type Server struct {
+	// URL is the server's base URL
+	URL string
+	// Repo is the isolated repository created for the test
+	Repo string
+	// Client is scoped to Repo
+	Client *gitdb.Client
+
+	container string
+	// created records the collections the test created on a shared server
+	created *collectionTracker
+}
+
+// StartServer starts a GitDB server container, waits for it to become
+// healthy and returns a client scoped to a fresh repository. The container
+// is removed when the test ends. On a shared server given by URL, only the
+// collections the test created through Client, or clients derived from it,
+// are deleted; collections that existed before the test are left alone.
+// The test is skipped when docker is unavailable and no server URL is
+// configured.
+func StartServer(t testing.TB, opts ServerOptions) *Server {
+	t.Helper()
+	opts.defaults()
+
+	s := &Server{URL: opts.URL, Repo: "gitdbtest-" + randomSuffix()}
+	if s.URL == "" {
+		if _, err := exec.LookPath("docker"); err != nil {
+			t.Skip("gitdbtest: docker not found and GITDB_TEST_URL not set")
+		}
+		if err := s.startContainer(opts); err != nil {
+			t.Fatalf("gitdbtest: %v", err)
+		}
+		t.Cleanup(s.removeContainer)
+	}
+
+	s.Client = gitdb.NewClient(opts.Token, opts.Owner, s.Repo)
+	s.Client.SetBaseURL(s.URL)
+	if err := s.waitHealthy(opts.StartupTimeout); err != nil {
+		if s.container != "" {
+			logs, _ := exec.Command("docker", "logs", "--tail", "50", s.container).CombinedOutput()
+			t.Logf("gitdbtest: container logs:\n%s", logs)
+		}
+		t.Fatalf("gitdbtest: %v", err)
+	}
+
+	// A shared server outlives the test, so clear out what it created
+	if s.container == "" {
+		existing, err := s.collectionNames(context.Background())
+		if err != nil {
+			t.Fatalf("gitdbtest: %v", err)
+		}
+		s.created = &collectionTracker{base: s.Client.HTTPClient.Transport, existing: existing, created: make(map[string]bool)}
+		s.Client.HTTPClient.Transport = s.created
+		t.Cleanup(func() {
+			if err := s.dropCreated(context.Background()); err != nil {
+				t.Logf("gitdbtest: cleanup of %s failed: %v", s.Repo, err)
+			}
+		})
+	}
+	return s
+}
+
+func (s *Server) startContainer(opts ServerOptions) error {
+	args := []string{"run", "-d", "--rm", "-p", fmt.Sprintf("127.0.0.1::%d", opts.Port)}
+	for key, value := range opts.Env {
+		args = append(args, "-e", key+"="+value)
+	}
+	args = append(args, opts.Image)
+
+	out, err := docker(args...)
+	if err != nil {
+		return fmt.Errorf("failed to start %s: %w", opts.Image, err)
+	}
+	s.container = out
+
+	hostPort, err := docker("port", s.container, fmt.Sprintf("%d/tcp", opts.Port))
+	if err != nil {
+		s.removeContainer()
+		return fmt.Errorf("failed to find mapped port: %w", err)
+	}
+	// docker may list an IPv6 binding too; the first line is enough
+	s.URL = "http://" + strings.SplitN(hostPort, "\n", 2)[0]
+	return nil
+}
+
+func (s *Server) removeContainer() {
+	docker("rm", "-f", s.container)
+}
+
+func (s *Server) waitHealthy(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var err error
+	for {
+		if err = s.Client.Health(ctx); err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("server at %s not healthy after %s: %w", s.URL, timeout, err)
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+func (s *Server) collectionNames(ctx context.Context) (map[string]bool, error) {
+	collections, err := s.Client.ListCollections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(collections))
+	for _, c := range collections {
+		names[c.Name] = true
+	}
+	return names, nil
+}
+
+// dropCreated deletes the collections the test created that still exist
+func (s *Server) dropCreated(ctx context.Context) error {
+	current, err := s.collectionNames(ctx)
+	if err != nil {
+		return err
+	}
+	for _, name := range s.created.names() {
+		if !current[name] {
+			continue
+		}
+		if err := s.Client.DeleteCollection(ctx, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectionTracker records the collections that requests created: those a
+// 201 response was returned for, by creating the collection or a document
+// in it, and that did not exist when the test started
+type collectionTracker struct {
+	base     http.RoundTripper
+	existing map[string]bool
+
+	mu      sync.Mutex
+	created map[string]bool
+}
+
+func (ct *collectionTracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	name := requestCollection(req)
+	base := ct.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusCreated && name != "" && !ct.existing[name] {
+		ct.mu.Lock()
+		ct.created[name] = true
+		ct.mu.Unlock()
+	}
+	return resp, err
+}
+
+func (ct *collectionTracker) names() []string {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	names := make([]string, 0, len(ct.created))
+	for name := range ct.created {
+		names = append(names, name)
+	}
+	return names
+}
+
+// requestCollection returns the collection a request addresses: the path
+// segment after "collections", or the name in the body of a create
+// collection request
+func requestCollection(req *http.Request) string {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	for i, segment := range segments {
+		if segment != "collections" {
+			continue
+		}
+		if i+1 < len(segments) {
+			return segments[i+1]
+		}
+		if req.Method != http.MethodPost || req.GetBody == nil {
+			return ""
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return ""
+		}
+		defer body.Close()
+		var created struct {
+			Name string `json:"name"`
+		}
+		data, _ := io.ReadAll(body)
+		json.Unmarshal(data, &created)
+		return created.Name
+	}
+	return ""
+}
+
+func docker(args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("docker", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("docker %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func randomSuffix() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,158 @@
+package gitdb
+
+import "context"
+
+// CollectionHandle is a client bound to one collection, so the collection
+// name need not be repeated on every call. It also gathers the
+// collection's client-side settings, such as field aliases and computed
+// fields, in one place. Handles are cheap; get one with Client.Collection.
+type CollectionHandle struct {
+	client *Client
+	name   string
+}
+
+// Collection returns a handle for the named collection. The name is
+// validated when the handle is first used.
+func (c *Client) Collection(name string) *CollectionHandle {
+	return &CollectionHandle{client: c, name: name}
+}
+
+// Name returns the collection name as the application sees it
+func (h *CollectionHandle) Name() string {
+	return h.name
+}
+
+// Client returns the client the handle was created from
+func (h *CollectionHandle) Client() *Client {
+	return h.client
+}
+
+// Insert inserts a document into the collection
+func (h *CollectionHandle) Insert(ctx context.Context, document Document) (string, error) {
+	return h.client.Insert(ctx, h.name, document)
+}
+
+// InsertWithResult inserts a document into the collection and returns its
+// ID with the commit and time it was stored at
+func (h *CollectionHandle) InsertWithResult(ctx context.Context, document Document) (*InsertResult, error) {
+	return h.client.InsertWithResult(ctx, h.name, document)
+}
+
+// Find finds documents in the collection
+func (h *CollectionHandle) Find(ctx context.Context, query Query) ([]Document, error) {
+	return h.client.Find(ctx, h.name, query)
+}
+
+// FindWithOptions finds documents in the collection, sorted, paged and
+// compared as opts specifies
+func (h *CollectionHandle) FindWithOptions(ctx context.Context, query Query, opts FindOptions) ([]Document, error) {
+	return h.client.FindWithOptions(ctx, h.name, query, opts)
+}
+
+// FindAll returns a cursor over all documents in the collection matching
+// query
+func (h *CollectionHandle) FindAll(query Query) *Cursor {
+	return h.client.FindAll(h.name, query)
+}
+
+// FindCursor returns a cursor over the documents matching query, shaped by
+// opts
+func (h *CollectionHandle) FindCursor(query Query, opts FindOptions) *Cursor {
+	return h.client.FindCursor(h.name, query, opts)
+}
+
+// FindOne finds a single document in the collection
+func (h *CollectionHandle) FindOne(ctx context.Context, query Query) (Document, error) {
+	return h.client.FindOne(ctx, h.name, query)
+}
+
+// FindByID finds a document by ID
+func (h *CollectionHandle) FindByID(ctx context.Context, id string) (Document, error) {
+	return h.client.FindByID(ctx, h.name, id)
+}
+
+// Update updates a document by ID
+func (h *CollectionHandle) Update(ctx context.Context, id string, update Update) (*UpdateResult, error) {
+	return h.client.Update(ctx, h.name, id, update)
+}
+
+// UpdateMany updates the documents matching query
+func (h *CollectionHandle) UpdateMany(ctx context.Context, query Query, update Update) (*UpdateResult, error) {
+	return h.client.UpdateMany(ctx, h.name, query, update)
+}
+
+// Delete deletes a document by ID
+func (h *CollectionHandle) Delete(ctx context.Context, id string) error {
+	return h.client.Delete(ctx, h.name, id)
+}
+
+// DeleteMany deletes the documents matching query
+func (h *CollectionHandle) DeleteMany(ctx context.Context, query Query) (int, error) {
+	return h.client.DeleteMany(ctx, h.name, query)
+}
+
+// FindOneAndUpdate updates the first document matching query and returns
+// it as it was before or after
+func (h *CollectionHandle) FindOneAndUpdate(ctx context.Context, query Query, update Update, opts FindOneAndOptions) (Document, error) {
+	return h.client.FindOneAndUpdate(ctx, h.name, query, update, opts)
+}
+
+// FindOneAndReplace replaces the first document matching query and returns
+// it as it was before or after
+func (h *CollectionHandle) FindOneAndReplace(ctx context.Context, query Query, replacement Document, opts FindOneAndOptions) (Document, error) {
+	return h.client.FindOneAndReplace(ctx, h.name, query, replacement, opts)
+}
+
+// FindOneAndDelete deletes the first document matching query and returns it
+func (h *CollectionHandle) FindOneAndDelete(ctx context.Context, query Query, opts FindOneAndOptions) (Document, error) {
+	return h.client.FindOneAndDelete(ctx, h.name, query, opts)
+}
+
+// BulkWrite runs a batch of mixed write operations against the collection
+func (h *CollectionHandle) BulkWrite(ctx context.Context, models []WriteModel, opts BulkWriteOptions) (*BulkWriteResult, error) {
+	return h.client.BulkWrite(ctx, h.name, models, opts)
+}
+
+// Scan walks the collection calling fn for each document
+func (h *CollectionHandle) Scan(ctx context.Context, fn ScanFunc, opts ScanOptions) (*ScanResult, error) {
+	return h.client.Scan(ctx, h.name, fn, opts)
+}
+
+// Count counts the documents matching query
+func (h *CollectionHandle) Count(ctx context.Context, query Query) (int, error) {
+	return h.client.Count(ctx, h.name, query)
+}
+
+// Aggregate runs an aggregation pipeline over the collection
+func (h *CollectionHandle) Aggregate(ctx context.Context, pipeline Pipeline) ([]Document, error) {
+	return h.client.Aggregate(ctx, h.name, pipeline)
+}
+
+// History returns the revisions of a document, newest first
+func (h *CollectionHandle) History(ctx context.Context, id string) ([]Revision, error) {
+	return h.client.DocumentHistory(ctx, h.name, id)
+}
+
+// SetFieldAliases registers the collection's field alias table
+func (h *CollectionHandle) SetFieldAliases(aliases FieldAliases) *CollectionHandle {
+	h.client.SetFieldAliases(h.name, aliases)
+	return h
+}
+
+// SetComputedField registers a field computed when documents are read
+func (h *CollectionHandle) SetComputedField(field string, fn ComputeFunc) *CollectionHandle {
+	h.client.SetComputedField(h.name, field, fn)
+	return h
+}
+
+// RegisterMigration registers a migration upgrading the collection's
+// documents from fromVersion to fromVersion+1
+func (h *CollectionHandle) RegisterMigration(fromVersion int, fn MigrateFunc) error {
+	return h.client.RegisterMigration(h.name, fromVersion, fn)
+}
+
+// SetOptions registers the defaults applied to every operation on the
+// collection
+func (h *CollectionHandle) SetOptions(opts *CollectionOptions) error {
+	return h.client.SetCollectionOptions(h.name, opts)
+}
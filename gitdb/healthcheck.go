@@ -0,0 +1,213 @@
+package gitdb
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthCheckOptions configures background endpoint health probes
+type HealthCheckOptions struct {
+	// Interval between probe rounds, 10 seconds by default
+	Interval time.Duration
+
+	// Timeout of each probe, 2 seconds by default
+	Timeout time.Duration
+
+	// UnhealthyThreshold is the number of consecutive failed probes after
+	// which an endpoint is evicted, 3 by default
+	UnhealthyThreshold int
+
+	// HealthyThreshold is the number of consecutive successful probes after
+	// which an evicted endpoint is routed to again, 2 by default
+	HealthyThreshold int
+}
+
+// HealthChecker probes the client's endpoints in the background
+type HealthChecker struct {
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+
+	mu     sync.Mutex
+	states map[string]*probeState
+}
+
+type probeState struct {
+	healthy   bool
+	failures  int
+	successes int
+}
+
+// StartHealthChecks probes the client's read endpoints and failover
+// endpoints in the background and stops routing to the ones that fail
+// UnhealthyThreshold probes in a row, until they pass HealthyThreshold
+// probes in a row. When the failover primary is evicted, requests move to
+// the first healthy secondary, and back once the primary is readmitted.
+// This detects failures before requests hit them, rather than through
+// request errors.
+func (c *Client) StartHealthChecks(opts HealthCheckOptions) *HealthChecker {
+	if opts.Interval <= 0 {
+		opts.Interval = 10 * time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 2 * time.Second
+	}
+	if opts.UnhealthyThreshold <= 0 {
+		opts.UnhealthyThreshold = 3
+	}
+	if opts.HealthyThreshold <= 0 {
+		opts.HealthyThreshold = 2
+	}
+
+	h := &HealthChecker{
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		states: make(map[string]*probeState),
+	}
+
+	c.shared().lifecycle.onClose(func(ctx context.Context) error {
+		return waitContext(ctx, h.Stop)
+	})
+
+	go func() {
+		defer close(h.done)
+
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+
+		for {
+			h.round(c, opts)
+
+			select {
+			case <-h.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return h
+}
+
+// Stop stops probing. Endpoints keep their last health state.
+func (h *HealthChecker) Stop() {
+	h.once.Do(func() { close(h.stop) })
+	<-h.done
+}
+
+// Healthy reports whether the last probes found the endpoint healthy.
+// Endpoints that have not been probed are reported healthy.
+func (h *HealthChecker) Healthy(base string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if state, ok := h.states[base]; ok {
+		return state.healthy
+	}
+	return true
+}
+
+// round probes every endpoint once and applies the results
+func (h *HealthChecker) round(c *Client, opts HealthCheckOptions) {
+	state := c.shared()
+	state.mu.RLock()
+	pool := state.readEndpoints
+	fo := state.failover
+	state.mu.RUnlock()
+
+	var bases []string
+	if pool != nil {
+		for _, ep := range pool.endpoints {
+			bases = append(bases, ep.base)
+		}
+	}
+	if fo != nil {
+		bases = append(bases, fo.primary)
+		bases = append(bases, fo.secondaries...)
+	}
+
+	var wg sync.WaitGroup
+	results := make([]bool, len(bases))
+	for i, base := range bases {
+		wg.Add(1)
+		go func(i int, base string) {
+			defer wg.Done()
+			results[i] = c.probe(base, opts.Timeout)
+		}(i, base)
+	}
+	wg.Wait()
+
+	for i, base := range bases {
+		h.update(base, results[i], opts)
+	}
+
+	if pool != nil {
+		pool.mu.Lock()
+		for _, ep := range pool.endpoints {
+			ep.evicted = !h.Healthy(ep.base)
+		}
+		pool.mu.Unlock()
+	}
+
+	if fo != nil {
+		target := ""
+		if h.Healthy(fo.primary) {
+			target = fo.primary
+		} else {
+			for _, secondary := range fo.secondaries {
+				if h.Healthy(secondary) {
+					target = secondary
+					break
+				}
+			}
+		}
+		if target != "" {
+			fo.switchTo(target, "health probe")
+		}
+	}
+}
+
+// update applies one probe result with hysteresis
+func (h *HealthChecker) update(base string, ok bool, opts HealthCheckOptions) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	state, exists := h.states[base]
+	if !exists {
+		state = &probeState{healthy: true}
+		h.states[base] = state
+	}
+
+	if ok {
+		state.failures = 0
+		state.successes++
+		if !state.healthy && state.successes >= opts.HealthyThreshold {
+			state.healthy = true
+		}
+	} else {
+		state.successes = 0
+		state.failures++
+		if state.healthy && state.failures >= opts.UnhealthyThreshold {
+			state.healthy = false
+		}
+	}
+}
+
+// probe runs a health check against an endpoint base URL
+func (c *Client) probe(base string, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", base+"/health", nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
@@ -0,0 +1,165 @@
+package gitdb
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// defaultHedgeDelay is used for P99-based hedging until an operation has
+// enough samples
+const defaultHedgeDelay = 100 * time.Millisecond
+
+// minHedgeSamples is the number of requests an operation needs before its
+// observed P99 latency is trusted as a hedge delay
+const minHedgeSamples = 100
+
+// HedgePolicy configures hedged reads: when a read has not completed after
+// the hedge delay, a duplicate request is sent and whichever succeeds first
+// is used. This cuts tail latency when the server occasionally stalls on git
+// operations, at the cost of some extra load.
+type HedgePolicy struct {
+	// Delay before the duplicate is sent. When zero, the operation's observed
+	// P99 latency is used, so roughly 1% of reads are hedged.
+	Delay time.Duration
+
+	// MinDelay is the lower bound of a P99-based delay
+	MinDelay time.Duration
+}
+
+// unhedgedOperations are reads that change server-side state: a duplicate
+// open would leak the losing cursor, and a duplicate fetch would consume a
+// batch the caller never sees
+var unhedgedOperations = map[string]bool{
+	"open cursor":  true,
+	"fetch cursor": true,
+}
+
+// SetHedging enables hedged reads on the client and every client derived
+// from it. A nil policy disables hedging, which is the default. Writes and
+// cursor requests are never hedged.
+func (c *Client) SetHedging(policy *HedgePolicy) {
+	state := c.shared()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.hedge = policy
+}
+
+// hedgeDelay returns the delay after which op should be hedged, or false if
+// hedging does not apply to req
+func (c *Client) hedgeDelay(op string, req *http.Request) (time.Duration, bool) {
+	state := c.shared()
+	state.mu.RLock()
+	policy := state.hedge
+	state.mu.RUnlock()
+
+	if policy == nil || !readOperations[op] || unhedgedOperations[op] {
+		return 0, false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return 0, false
+	}
+
+	if policy.Delay > 0 {
+		return policy.Delay, true
+	}
+
+	delay, ok := state.metrics.percentile(op, 0.99, minHedgeSamples)
+	if !ok {
+		delay = defaultHedgeDelay
+	}
+	if delay < policy.MinDelay {
+		delay = policy.MinDelay
+	}
+	return delay, true
+}
+
+type hedgeResult struct {
+	index  int
+	info   *RequestInfo
+	resp   *http.Response
+	err    error
+	cancel context.CancelFunc
+}
+
+// hedgedAttempt sends req and, if it has not completed after delay, a
+// duplicate of it, returning the first successful response. The losing
+// request is cancelled.
+func (c *Client) hedgedAttempt(op string, req *http.Request, attempt int, delay time.Duration) (*RequestInfo, *http.Response, error) {
+	results := make(chan hedgeResult, 2)
+	cancels := make([]context.CancelFunc, 0, 2)
+
+	launch := func(r *http.Request) {
+		ctx, cancel := context.WithCancel(r.Context())
+		index := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			info, resp, err := c.attempt(op, r.WithContext(ctx), attempt)
+			results <- hedgeResult{index, info, resp, err, cancel}
+		}()
+	}
+
+	launch(req)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	pending := 1
+	for {
+		select {
+		case <-timer.C:
+			if hedge, err := rewindRequest(req); err == nil {
+				launch(hedge)
+				pending++
+			}
+
+		case res := <-results:
+			pending--
+			if res.err != nil || res.resp.StatusCode >= 500 {
+				if pending > 0 {
+					// The other request may still succeed
+					discardHedge(res)
+					continue
+				}
+			}
+
+			// res is the answer: cancel the other request and clean up
+			// whatever it returns
+			for i, cancel := range cancels {
+				if i != res.index {
+					cancel()
+				}
+			}
+			if pending > 0 {
+				go func() { discardHedge(<-results) }()
+			}
+
+			if res.resp != nil {
+				res.resp.Body = &cancelOnClose{ReadCloser: res.resp.Body, cancel: res.cancel}
+			} else {
+				res.cancel()
+			}
+			return res.info, res.resp, res.err
+		}
+	}
+}
+
+func discardHedge(res hedgeResult) {
+	if res.resp != nil {
+		io.Copy(io.Discard, res.resp.Body)
+		res.resp.Body.Close()
+	}
+	res.cancel()
+}
+
+// cancelOnClose releases a request context once its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,98 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Revision is a version of a document recorded by a commit
+type Revision struct {
+	Commit    string    `json:"commit"`
+	Author    string    `json:"author"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+	Deleted   bool      `json:"deleted"`
+	Document  Document  `json:"document"`
+}
+
+// DocumentHistory returns the revisions of a document, newest first
+func (c *Client) DocumentHistory(ctx context.Context, collection, id string) ([]Revision, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ValidateDocumentID(id); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/documents/%s/history", c.BaseURL, name, pathSegment(id))
+
+	var revisions []Revision
+	if err := c.doJSON(ctx, "GET", url, nil, &revisions, http.StatusOK, "get document history"); err != nil {
+		return nil, err
+	}
+
+	for i := range revisions {
+		doc, err := c.decodeDocument(collection, revisions[i].Document)
+		if err != nil {
+			return nil, err
+		}
+		revisions[i].Document = doc
+	}
+
+	return revisions, nil
+}
+
+// CompactOptions configures CompactHistory
+type CompactOptions struct {
+	// Archive keeps the squashed commits reachable under an archive ref
+	// instead of letting garbage collection reclaim them
+	Archive bool
+	// ArchiveRef names the archive ref, refs/archive/<collection>/<date>
+	// by default
+	ArchiveRef string
+}
+
+// CompactResult reports what CompactHistory did
+type CompactResult struct {
+	// Squashed is the number of commits folded into the baseline
+	Squashed int `json:"squashed"`
+	// Baseline is the commit that now holds the collection's state as of
+	// the cutoff
+	Baseline string `json:"baseline"`
+	// ArchiveRef is where the old history was kept, if archived
+	ArchiveRef string `json:"archiveRef"`
+}
+
+// CompactHistory squashes the collection's commits older than before into a
+// single baseline commit. Document revisions before the cutoff are lost
+// unless archived, trading auditability for repository size on high-churn
+// collections; run Maintenance afterwards to reclaim the space.
+func (c *Client) CompactHistory(ctx context.Context, collection string, before time.Time, opts CompactOptions) (*CompactResult, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return nil, err
+	}
+	if before.IsZero() {
+		return nil, fmt.Errorf("compaction cutoff must be set")
+	}
+
+	request := map[string]interface{}{
+		"before":  before.UTC().Format(time.RFC3339Nano),
+		"archive": opts.Archive,
+	}
+	if opts.ArchiveRef != "" {
+		request["archiveRef"] = opts.ArchiveRef
+	}
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/history/compact", c.BaseURL, name)
+
+	var result CompactResult
+	if err := c.doJSON(ctx, "POST", url, request, &result, http.StatusOK, "compact history"); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,123 @@
+package gitdb
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ImportProgress is a progress event the server sends while importing
+type ImportProgress struct {
+	// Documents is the number of documents imported so far
+	Documents int64 `json:"documents"`
+	// Bytes is the number of bytes of input consumed so far
+	Bytes int64 `json:"bytes"`
+	// Failed is the number of lines rejected so far
+	Failed int64 `json:"failed"`
+}
+
+// ImportLineError reports a line of the input the server rejected
+type ImportLineError struct {
+	Line    int64  `json:"line"`
+	Message string `json:"message"`
+}
+
+// ImportResult summarises a finished import
+type ImportResult struct {
+	Imported int64             `json:"imported"`
+	Failed   int64             `json:"failed"`
+	Errors   []ImportLineError `json:"errors"`
+	Commit   string            `json:"commit"`
+}
+
+// importEvent is one line of the server's import event stream
+type importEvent struct {
+	Type      string            `json:"type"`
+	Documents int64             `json:"documents"`
+	Bytes     int64             `json:"bytes"`
+	Imported  int64             `json:"imported"`
+	Failed    int64             `json:"failed"`
+	Errors    []ImportLineError `json:"errors"`
+	Commit    string            `json:"commit"`
+	Error     string            `json:"error"`
+}
+
+// ImportStream streams newline-delimited JSON documents from r into a
+// collection through the server's bulk import endpoint. The input is sent
+// with chunked transfer encoding as it is read, so multi-gigabyte loads
+// never have to fit in memory, and progress, when non-nil, is called with
+// each progress event the server reports. Lines the server rejects are
+// counted in the result rather than failing the import.
+//
+// Streamed imports cannot be retried or signed, as the body is only read
+// once.
+func (c *Client) ImportStream(ctx context.Context, collection string, r io.Reader, progress func(ImportProgress)) (*ImportResult, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	state := c.shared()
+	state.mu.RLock()
+	signed := state.signer != nil || state.requestKey != nil
+	state.mu.RUnlock()
+	if signed {
+		return nil, fmt.Errorf("streamed imports cannot be signed; insert the documents instead")
+	}
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/import", c.BaseURL, name)
+
+	// Hide any Len method of r so the body is always sent chunked
+	req, err := http.NewRequestWithContext(ctx, "POST", url, io.MultiReader(r))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("Accept", "application/x-ndjson")
+
+	resp, err := c.do("import documents", req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import documents: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to import documents: %w", newAPIError(resp))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var event importEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("failed to decode import event: %w", err)
+		}
+
+		switch event.Type {
+		case "progress":
+			if progress != nil {
+				progress(ImportProgress{Documents: event.Documents, Bytes: event.Bytes, Failed: event.Failed})
+			}
+		case "done":
+			return &ImportResult{
+				Imported: event.Imported,
+				Failed:   event.Failed,
+				Errors:   event.Errors,
+				Commit:   event.Commit,
+			}, nil
+		case "error":
+			return nil, fmt.Errorf("failed to import documents: %s", event.Error)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read import events: %w", err)
+	}
+	return nil, fmt.Errorf("failed to import documents: event stream ended before the import finished")
+}
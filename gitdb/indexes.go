@@ -0,0 +1,305 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Index types
+const (
+	// IndexGeoSphere indexes GeoJSON points on a sphere, for $near and
+	// $geoWithin queries in meters
+	IndexGeoSphere = "2dsphere"
+	// IndexGeoPlanar indexes [x, y] pairs on a flat plane
+	IndexGeoPlanar = "2d"
+	// IndexText indexes words of string fields for $text search
+	IndexText = "text"
+	// IndexSorted orders documents by the values of its fields, for
+	// equality, range and sort queries
+	IndexSorted = "sorted"
+)
+
+// textLanguages are the languages text indexes can stem and filter stop
+// words for; "none" tokenizes without either
+var textLanguages = map[string]bool{
+	"none": true, "danish": true, "dutch": true, "english": true,
+	"finnish": true, "french": true, "german": true, "hungarian": true,
+	"italian": true, "norwegian": true, "portuguese": true, "romanian": true,
+	"russian": true, "spanish": true, "swedish": true, "turkish": true,
+}
+
+// Index is a secondary index of a collection
+type Index struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Fields []string `json:"fields"`
+	// Options holds the type-specific settings the index was created with
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// GeoIndexOptions configures CreateGeoIndex
+type GeoIndexOptions struct {
+	// Name of the index, the field and index type by default, such as
+	// location_2dsphere
+	Name string
+	// Planar indexes flat [x, y] coordinates instead of GeoJSON on a sphere
+	Planar bool
+	// Min and Max bound planar coordinates, -180 and 180 by default
+	Min, Max float64
+	// Bits is the precision of planar index cells, 26 by default
+	Bits int
+	// Sparse leaves documents without the field out of the index
+	Sparse bool
+}
+
+// IndexOptions configures CreateIndex
+type IndexOptions struct {
+	// Name of the index, the fields joined with their type by default,
+	// such as lastName_firstName_sorted
+	Name string
+	// Unique rejects writes that would give two documents the same values
+	Unique bool
+	// Sparse leaves documents without the fields out of the index
+	Sparse bool
+	// Collation orders and compares the indexed strings; queries must use
+	// the same collation to use the index
+	Collation *Collation
+}
+
+// TextIndexOptions configures the analyzer of a text index
+type TextIndexOptions struct {
+	// Name of the index, the fields joined with their type by default,
+	// such as title_body_text
+	Name string
+	// Language selects stemming rules and stop words, english by default.
+	// "none" indexes words exactly as they appear.
+	Language string
+	// DisableStemming indexes words without reducing them to their stem,
+	// while still dropping the language's stop words
+	DisableStemming bool
+	// StopWords are ignored in addition to those of the language
+	StopWords []string
+	// Weights ranks matches in some fields above others; fields default to
+	// a weight of 1
+	Weights map[string]int
+}
+
+// ListIndexes lists the indexes of a collection
+func (c *Client) ListIndexes(ctx context.Context, collection string) ([]Index, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/indexes", c.BaseURL, name)
+
+	var indexes []Index
+	if err := c.doJSON(ctx, "GET", url, nil, &indexes, http.StatusOK, "list indexes"); err != nil {
+		return nil, err
+	}
+	for i := range indexes {
+		c.localizeIndex(collection, &indexes[i])
+	}
+	return indexes, nil
+}
+
+// DropIndex removes an index by name
+func (c *Client) DropIndex(ctx context.Context, collection, index string) error {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return err
+	}
+	if err := ValidateDocumentID(index); err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/indexes/%s", c.BaseURL, name, pathSegment(index))
+	return c.doJSON(ctx, "DELETE", url, nil, nil, http.StatusOK, "drop index")
+}
+
+// CreateIndex indexes fields for equality, range and sort queries. opts
+// may be nil.
+func (c *Client) CreateIndex(ctx context.Context, collection string, fields []string, opts *IndexOptions) (*Index, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("index needs at least one field")
+	}
+	if opts == nil {
+		opts = &IndexOptions{}
+	}
+	if err := validateCollation(opts.Collation); err != nil {
+		return nil, err
+	}
+
+	stored := make([]string, len(fields))
+	for i, field := range fields {
+		if err := ValidateFieldPath(field); err != nil {
+			return nil, err
+		}
+		stored[i] = c.storedField(collection, field)
+	}
+
+	options := map[string]interface{}{}
+	if opts.Unique {
+		options["unique"] = true
+	}
+	if opts.Sparse {
+		options["sparse"] = true
+	}
+	if opts.Collation != nil {
+		options["collation"] = opts.Collation
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = strings.Join(fields, "_") + "_" + IndexSorted
+	}
+
+	index := Index{Name: name, Type: IndexSorted, Fields: stored, Options: options}
+	return c.createIndex(ctx, collection, index, "create index")
+}
+
+// CreateGeoIndex indexes the coordinates in field so that $near and
+// $geoWithin queries on it use the index instead of scanning the
+// collection. opts may be nil for a 2dsphere index over GeoJSON points.
+func (c *Client) CreateGeoIndex(ctx context.Context, collection, field string, opts *GeoIndexOptions) (*Index, error) {
+	if err := ValidateFieldPath(field); err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = &GeoIndexOptions{}
+	}
+
+	index := Index{Type: IndexGeoSphere, Fields: []string{c.storedField(collection, field)}, Options: map[string]interface{}{}}
+	if opts.Planar {
+		index.Type = IndexGeoPlanar
+		min, max := opts.Min, opts.Max
+		if min == 0 && max == 0 {
+			min, max = -180, 180
+		}
+		if min >= max {
+			return nil, fmt.Errorf("invalid planar bounds: min %v must be below max %v", min, max)
+		}
+		index.Options["min"], index.Options["max"] = min, max
+		if opts.Bits != 0 {
+			if opts.Bits < 1 || opts.Bits > 32 {
+				return nil, fmt.Errorf("invalid precision %d bits: must be between 1 and 32", opts.Bits)
+			}
+			index.Options["bits"] = opts.Bits
+		}
+	}
+	if opts.Sparse {
+		index.Options["sparse"] = true
+	}
+	index.Name = opts.Name
+	if index.Name == "" {
+		index.Name = field + "_" + index.Type
+	}
+
+	return c.createIndex(ctx, collection, index, "create geo index")
+}
+
+// DropGeoIndex removes the geospatial index on field
+func (c *Client) DropGeoIndex(ctx context.Context, collection, field string) error {
+	indexes, err := c.ListIndexes(ctx, collection)
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		if (index.Type == IndexGeoSphere || index.Type == IndexGeoPlanar) && len(index.Fields) == 1 && index.Fields[0] == field {
+			return c.DropIndex(ctx, collection, index.Name)
+		}
+	}
+	return fmt.Errorf("failed to drop geo index: no geo index on %s", field)
+}
+
+// CreateTextIndex indexes the words of fields for full-text search, with
+// the analyzer configured by opts, which may be nil for English text with
+// equal field weights. A collection has at most one text index.
+func (c *Client) CreateTextIndex(ctx context.Context, collection string, fields []string, opts *TextIndexOptions) (*Index, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("text index needs at least one field")
+	}
+	if opts == nil {
+		opts = &TextIndexOptions{}
+	}
+
+	stored := make([]string, len(fields))
+	indexed := make(map[string]bool, len(fields))
+	for i, field := range fields {
+		if err := ValidateFieldPath(field); err != nil {
+			return nil, err
+		}
+		stored[i] = c.storedField(collection, field)
+		indexed[field] = true
+	}
+
+	language := opts.Language
+	if language == "" {
+		language = "english"
+	}
+	if !textLanguages[language] {
+		return nil, fmt.Errorf("unsupported text index language %q", language)
+	}
+
+	options := map[string]interface{}{
+		"language": language,
+		"stemming": !opts.DisableStemming && language != "none",
+	}
+	if len(opts.StopWords) > 0 {
+		options["stopWords"] = opts.StopWords
+	}
+	if len(opts.Weights) > 0 {
+		weights := make(map[string]interface{}, len(opts.Weights))
+		for field, weight := range opts.Weights {
+			if !indexed[field] {
+				return nil, fmt.Errorf("weighted field %s is not indexed", field)
+			}
+			if weight < 1 {
+				return nil, fmt.Errorf("invalid weight %d for %s: must be at least 1", weight, field)
+			}
+			weights[c.storedField(collection, field)] = weight
+		}
+		options["weights"] = weights
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = strings.Join(fields, "_") + "_" + IndexText
+	}
+
+	index := Index{Name: name, Type: IndexText, Fields: stored, Options: options}
+	return c.createIndex(ctx, collection, index, "create text index")
+}
+
+// createIndex creates index on a collection
+func (c *Client) createIndex(ctx context.Context, collection string, index Index, action string) (*Index, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateDocumentID(index.Name); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/indexes", c.BaseURL, name)
+
+	var created Index
+	if err := c.doJSON(ctx, "POST", url, index, &created, http.StatusCreated, action); err != nil {
+		return nil, err
+	}
+	c.localizeIndex(collection, &created)
+	return &created, nil
+}
+
+// localizeIndex reports an index's fields under their aliases
+func (c *Client) localizeIndex(collection string, index *Index) {
+	aliases := c.fieldAliases(collection)
+	if len(aliases) == 0 {
+		return
+	}
+	for i, field := range index.Fields {
+		index.Fields[i] = renamePath(field, aliases)
+	}
+}
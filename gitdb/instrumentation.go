@@ -0,0 +1,63 @@
+package gitdb
+
+import (
+	"net/http"
+	"time"
+)
+
+// Instrumentation receives low-level events for every request the client
+// sends, so APM agents and tracing libraries can hook in without the client
+// choosing a telemetry stack. Methods are called synchronously on the
+// request path and must be safe for concurrent use.
+type Instrumentation interface {
+	// OnRequestStart is called before each attempt is sent. Headers added to
+	// info.Request are sent with it, e.g. trace propagation headers.
+	OnRequestStart(info *RequestInfo)
+
+	// OnRequestEnd is called when an attempt completes or fails
+	OnRequestEnd(info *RequestInfo, result RequestResult)
+
+	// OnRetry is called when a failed attempt is about to be retried after
+	// delay
+	OnRetry(info *RequestInfo, delay time.Duration, cause error)
+}
+
+// RequestInfo describes a single request attempt. The same pointer is passed
+// to every event of the attempt.
+type RequestInfo struct {
+	Operation string
+	Method    string
+	URL       string
+	Attempt   int
+	Start     time.Time
+	Request   *http.Request
+	// Tags are the tags of the operation, set with WithTags
+	Tags map[string]string
+
+	// Value is free for the instrumentation to carry state, such as a span,
+	// from OnRequestStart to OnRequestEnd
+	Value interface{}
+}
+
+// RequestResult describes the outcome of a request attempt
+type RequestResult struct {
+	StatusCode int // 0 if no response was received
+	Duration   time.Duration
+	Err        error
+}
+
+// SetInstrumentation installs instrumentation hooks on the client and every
+// client derived from it. Passing nil removes them.
+func (c *Client) SetInstrumentation(i Instrumentation) {
+	state := c.shared()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.instrumentation = i
+}
+
+func (c *Client) instrumentation() Instrumentation {
+	state := c.shared()
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+	return state.instrumentation
+}
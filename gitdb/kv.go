@@ -0,0 +1,127 @@
+package gitdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrKeyNotFound is returned by KV reads when a key is missing or expired
+var ErrKeyNotFound = errors.New("key not found")
+
+// KV is a string key/value store backed by a collection, for settings and
+// other simple values that do not need documents or queries. Each key is
+// stored as a document with the key as its ID, so keys must be valid
+// document IDs, and values stay readable in the repository and its diffs.
+//
+// Unlike Cache, which holds opaque bytes, KV values are strings or JSON.
+// Expired keys are ignored by reads and removed lazily.
+type KV struct {
+	client     *Client
+	collection string
+}
+
+// KV returns a key/value store in collection
+func (c *Client) KV(collection string) *KV {
+	return &KV{client: c, collection: collection}
+}
+
+// Get returns the value stored under key, or ErrKeyNotFound
+func (kv *KV) Get(ctx context.Context, key string) (string, error) {
+	doc, err := kv.get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	value, ok := doc["value"].(string)
+	if !ok {
+		return "", fmt.Errorf("failed to get key %s: value is not a string", key)
+	}
+	return value, nil
+}
+
+// GetJSON decodes the JSON value stored under key into v
+func (kv *KV) GetJSON(ctx context.Context, key string, v interface{}) error {
+	doc, err := kv.get(ctx, key)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(doc["value"])
+	if err != nil {
+		return fmt.Errorf("failed to get key %s: %w", key, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode key %s: %w", key, err)
+	}
+	return nil
+}
+
+// Set stores value under key until it is deleted
+func (kv *KV) Set(ctx context.Context, key, value string) error {
+	return kv.set(ctx, key, value, 0)
+}
+
+// SetWithTTL stores value under key for ttl
+func (kv *KV) SetWithTTL(ctx context.Context, key, value string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("invalid ttl %v for key %s", ttl, key)
+	}
+	return kv.set(ctx, key, value, ttl)
+}
+
+// SetJSON stores v under key as JSON, nested as it is rather than as an
+// encoded string, until it is deleted
+func (kv *KV) SetJSON(ctx context.Context, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode key %s: %w", key, err)
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("failed to encode key %s: %w", key, err)
+	}
+	return kv.set(ctx, key, value, 0)
+}
+
+// Delete removes key. Deleting a missing key is not an error.
+func (kv *KV) Delete(ctx context.Context, key string) error {
+	if _, err := kv.client.ApplyChanges(ctx, kv.collection, []Change{{Op: ChangeDelete, ID: key}}); err != nil {
+		return fmt.Errorf("failed to delete key %s: %w", key, err)
+	}
+	return nil
+}
+
+// get returns the unexpired document of key
+func (kv *KV) get(ctx context.Context, key string) (Document, error) {
+	if err := ValidateDocumentID(key); err != nil {
+		return nil, err
+	}
+
+	docs, err := kv.client.Find(ctx, kv.collection, Query{"_id": key})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get key %s: %w", key, err)
+	}
+	if len(docs) == 0 {
+		return nil, ErrKeyNotFound
+	}
+
+	doc := docs[0]
+	if expiresAt, ok := doc["expiresAt"].(float64); ok && int64(expiresAt) <= time.Now().UnixMilli() {
+		kv.client.DeleteMany(ctx, kv.collection, Query{"_id": key, "expiresAt": Query{"$lte": time.Now().UnixMilli()}})
+		return nil, ErrKeyNotFound
+	}
+	return doc, nil
+}
+
+// set replaces the document of key in a single write
+func (kv *KV) set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	doc := Document{"value": value}
+	if ttl > 0 {
+		doc["expiresAt"] = time.Now().Add(ttl).UnixMilli()
+	}
+	if _, err := kv.client.ApplyChanges(ctx, kv.collection, []Change{{Op: ChangeUpsert, ID: key, Document: doc}}); err != nil {
+		return fmt.Errorf("failed to set key %s: %w", key, err)
+	}
+	return nil
+}
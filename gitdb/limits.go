@@ -0,0 +1,143 @@
+package gitdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrResponseTooLarge is returned when a response exceeds the client's
+// MaxResponseBytes or MaxDocuments limit
+var ErrResponseTooLarge = errors.New("response too large")
+
+// ErrDocumentTooLarge is returned when a document written exceeds the
+// client's MaxDocumentBytes limit
+var ErrDocumentTooLarge = errors.New("document too large")
+
+// WithMaxResponseBytes fails requests whose response body is longer than n
+// bytes with ErrResponseTooLarge instead of reading it all into memory
+func WithMaxResponseBytes(n int64) Option {
+	return func(o *options) {
+		o.maxResponseBytes = n
+	}
+}
+
+// WithMaxDocuments fails reads returning more than n documents with
+// ErrResponseTooLarge, stopping decoding as soon as the limit is passed so
+// an unbounded query cannot exhaust memory
+func WithMaxDocuments(n int) Option {
+	return func(o *options) {
+		o.maxDocuments = n
+	}
+}
+
+// WithMaxDocumentBytes fails inserts and upserts of documents whose
+// encoding, after the collection's codec and field aliases, is longer than
+// n bytes with ErrDocumentTooLarge before they are sent, rather than
+// leaving the server or the git host to reject the commit
+func WithMaxDocumentBytes(n int) Option {
+	return func(o *options) {
+		o.maxDocumentBytes = n
+	}
+}
+
+// EstimateSize returns the size in bytes of doc's canonical JSON encoding,
+// the form documents are sent in. A collection's codec and field aliases
+// change the size of what is actually sent.
+func EstimateSize(doc Document) (int, error) {
+	data, err := CanonicalJSON(doc)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal document: %w", err)
+	}
+	return len(data), nil
+}
+
+// checkDocumentSize applies the client's document size limit to a
+// document written to collection, in the form it is sent
+func (c *Client) checkDocumentSize(collection string, doc Document) error {
+	limit := c.shared().maxDocumentBytes
+	if limit <= 0 {
+		return nil
+	}
+	size, err := EstimateSize(doc)
+	if err != nil || size <= limit {
+		return err
+	}
+	return fmt.Errorf("%w: document for %s is %d bytes, over the limit of %d; store large payloads in a Bucket and reference them from the document",
+		ErrDocumentTooLarge, collection, size, limit)
+}
+
+// limitedBody is a response body that fails once more than its limit has
+// been read
+type limitedBody struct {
+	io.ReadCloser
+	limit     int64
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, fmt.Errorf("%w: more than %d bytes", ErrResponseTooLarge, b.limit)
+	}
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.remaining {
+		n = int(b.remaining)
+		b.remaining = -1
+		return n, fmt.Errorf("%w: more than %d bytes", ErrResponseTooLarge, b.limit)
+	}
+	b.remaining -= int64(n)
+	return n, err
+}
+
+// limitBody applies the client's response size limit to body
+func (c *Client) limitBody(body io.ReadCloser) io.ReadCloser {
+	limit := c.shared().maxResponseBytes
+	if limit <= 0 {
+		return body
+	}
+	return &limitedBody{ReadCloser: body, limit: limit, remaining: limit}
+}
+
+// decodeDocumentList decodes a JSON array of documents, stopping with
+// ErrResponseTooLarge once it holds more than the client's MaxDocuments
+func (c *Client) decodeDocumentList(r io.Reader) ([]Document, error) {
+	limit := c.shared().maxDocuments
+	dec := json.NewDecoder(r)
+	if limit <= 0 {
+		var documents []Document
+		err := dec.Decode(&documents)
+		return documents, err
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok == nil {
+		return nil, nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("expected an array of documents, got %v", tok)
+	}
+
+	documents := []Document{}
+	for dec.More() {
+		if len(documents) == limit {
+			return nil, fmt.Errorf("%w: more than %d documents", ErrResponseTooLarge, limit)
+		}
+		var doc Document
+		if err := dec.Decode(&doc); err != nil {
+			return nil, err
+		}
+		documents = append(documents, doc)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return documents, nil
+}
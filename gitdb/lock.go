@@ -0,0 +1,132 @@
+package gitdb
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// locksCollection stores one document per held lock
+const locksCollection = "_locks"
+
+var (
+	// ErrLockHeld is returned by AcquireLock when another owner holds an
+	// unexpired lease on the lock
+	ErrLockHeld = errors.New("lock is held by another owner")
+
+	// ErrLockLost is returned by Renew and Release when the lease expired and
+	// the lock was taken over or removed
+	ErrLockLost = errors.New("lock lease was lost")
+)
+
+// Lock is a lease on a named lock. Leases expire after their TTL unless
+// renewed, so a crashed holder never blocks other workers forever.
+type Lock struct {
+	Name      string
+	Token     string
+	ExpiresAt time.Time
+
+	client *Client
+}
+
+// AcquireLock tries to take the named lock for ttl without waiting. It
+// returns ErrLockHeld if another owner holds an unexpired lease.
+//
+// Locks are built on conditional writes: an expired lease is taken over with
+// an update conditioned on its expiry, and a free lock is created with an
+// insert that fails if the lock document already exists. Expiry is compared
+// using client clocks, so ttl should comfortably exceed the clock skew
+// between workers.
+func (c *Client) AcquireLock(ctx context.Context, name string, ttl time.Duration) (*Lock, error) {
+	if err := ValidateDocumentID(name); err != nil {
+		return nil, err
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("lock ttl must be positive")
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	// Take over an expired lease
+	taken, err := c.UpdateMany(ctx, locksCollection,
+		Query{"_id": name, "expiresAt": Query{"$lte": now.UnixMilli()}},
+		Update{"$set": Document{"owner": token, "expiresAt": expiresAt.UnixMilli()}},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+
+	if taken.MatchedCount == 0 {
+		// Create the lock if nobody holds it
+		_, insertErr := c.Insert(ctx, locksCollection, Document{
+			"_id":       name,
+			"owner":     token,
+			"expiresAt": expiresAt.UnixMilli(),
+		})
+		if insertErr != nil {
+			current, err := c.FindByID(ctx, locksCollection, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to acquire lock %s: %w", name, insertErr)
+			}
+			if current["owner"] != token {
+				return nil, ErrLockHeld
+			}
+		}
+	}
+
+	return &Lock{Name: name, Token: token, ExpiresAt: expiresAt, client: c}, nil
+}
+
+// Renew extends the lease to ttl from now. It returns ErrLockLost if the
+// lease is no longer held.
+func (l *Lock) Renew(ctx context.Context, ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("lock ttl must be positive")
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	renewed, err := l.client.UpdateMany(ctx, locksCollection,
+		Query{"_id": l.Name, "owner": l.Token},
+		Update{"$set": Document{"expiresAt": expiresAt.UnixMilli()}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to renew lock %s: %w", l.Name, err)
+	}
+	if renewed.MatchedCount == 0 {
+		return ErrLockLost
+	}
+
+	l.ExpiresAt = expiresAt
+	return nil
+}
+
+// Release gives up the lock. It returns ErrLockLost if the lease had already
+// been taken over.
+func (l *Lock) Release(ctx context.Context) error {
+	released, err := l.client.DeleteMany(ctx, locksCollection, Query{"_id": l.Name, "owner": l.Token})
+	if err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", l.Name, err)
+	}
+	if released == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// newToken returns a random hex token identifying a lock owner
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
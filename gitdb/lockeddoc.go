@@ -0,0 +1,198 @@
+package gitdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LockedDocument is a document held under a lock for a read-modify-write
+// cycle, such as Terraform-style state or a singleton config shared by
+// concurrent CI jobs. The lease is renewed in the background until Release.
+type LockedDocument struct {
+	Collection string
+	ID         string
+
+	client *Client
+	lock   *Lock
+	ttl    time.Duration
+	doc    Document
+
+	stop chan struct{}
+	done chan struct{}
+
+	mu      sync.Mutex
+	lostErr error
+}
+
+// LockDocument waits until it holds the lock on the document, then reads
+// it. The document does not have to exist yet; Document returns nil until
+// the first Write. The lease lasts ttl and is renewed every ttl/3, so a
+// crashed holder releases the document after at most ttl.
+func (c *Client) LockDocument(ctx context.Context, collection, id string, ttl time.Duration) (*LockedDocument, error) {
+	if _, err := c.collectionName(collection); err != nil {
+		return nil, err
+	}
+	if err := ValidateDocumentID(id); err != nil {
+		return nil, err
+	}
+
+	name := "doc:" + collection + ":" + id
+	wait := 100 * time.Millisecond
+	var lock *Lock
+	for {
+		var err error
+		lock, err = c.AcquireLock(ctx, name, ttl)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, ErrLockHeld) {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to lock %s/%s: %w", collection, id, ctx.Err())
+		case <-time.After(wait):
+		}
+		if wait < 2*time.Second {
+			wait *= 2
+		}
+	}
+
+	ld := &LockedDocument{
+		Collection: collection,
+		ID:         id,
+		client:     c,
+		lock:       lock,
+		ttl:        ttl,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	docs, err := c.Find(ctx, collection, Query{"_id": id})
+	if err != nil {
+		lock.Release(context.Background())
+		return nil, fmt.Errorf("failed to read %s/%s: %w", collection, id, err)
+	}
+	if len(docs) > 0 {
+		ld.doc = docs[0]
+	}
+
+	go ld.renew()
+	return ld, nil
+}
+
+func (ld *LockedDocument) renew() {
+	defer close(ld.done)
+
+	ticker := time.NewTicker(ld.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ld.stop:
+			return
+		case <-ticker.C:
+			if err := ld.lock.Renew(context.Background(), ld.ttl); err != nil {
+				ld.mu.Lock()
+				ld.lostErr = err
+				ld.mu.Unlock()
+				if errors.Is(err, ErrLockLost) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Document returns the document as read when the lock was taken, or as last
+// written. It is nil if the document did not exist.
+func (ld *LockedDocument) Document() Document {
+	return ld.doc
+}
+
+// Err reports whether the lease was lost or could not be renewed
+func (ld *LockedDocument) Err() error {
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+	return ld.lostErr
+}
+
+// Write replaces the document with doc, creating it if needed. It renews
+// the lease first and refuses to write with ErrLockLost if the lease has
+// been taken over.
+func (ld *LockedDocument) Write(ctx context.Context, doc Document) error {
+	if err := ld.lock.Renew(ctx, ld.ttl); err != nil {
+		return err
+	}
+
+	fields := make(Document, len(doc))
+	for k, v := range doc {
+		if k != "_id" {
+			fields[k] = v
+		}
+	}
+
+	if ld.doc == nil {
+		created := Document{"_id": ld.ID}
+		for k, v := range fields {
+			created[k] = v
+		}
+		if _, err := ld.client.Insert(ctx, ld.Collection, created); err != nil {
+			return fmt.Errorf("failed to write %s/%s: %w", ld.Collection, ld.ID, err)
+		}
+		ld.doc = created
+		return nil
+	}
+
+	update := Update{"$set": fields}
+	unset := Document{}
+	for k := range ld.doc {
+		if _, ok := fields[k]; !ok && k != "_id" {
+			unset[k] = ""
+		}
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if _, err := ld.client.Update(ctx, ld.Collection, ld.ID, update); err != nil {
+		return fmt.Errorf("failed to write %s/%s: %w", ld.Collection, ld.ID, err)
+	}
+
+	fields["_id"] = ld.ID
+	ld.doc = fields
+	return nil
+}
+
+// Release stops renewing the lease and releases the lock
+func (ld *LockedDocument) Release(ctx context.Context) error {
+	select {
+	case <-ld.stop:
+		return nil
+	default:
+		close(ld.stop)
+	}
+	<-ld.done
+	return ld.lock.Release(ctx)
+}
+
+// UpdateLocked locks the document, passes it to fn and writes what fn
+// returns, releasing the lock afterwards. fn receives nil if the document
+// does not exist. Returning a nil document leaves it unchanged.
+func (c *Client) UpdateLocked(ctx context.Context, collection, id string, ttl time.Duration, fn func(doc Document) (Document, error)) error {
+	ld, err := c.LockDocument(ctx, collection, id, ttl)
+	if err != nil {
+		return err
+	}
+	// Release the lock even if ctx ends while fn runs
+	defer ld.Release(context.Background())
+
+	updated, err := fn(ld.Document())
+	if err != nil || updated == nil {
+		return err
+	}
+	return ld.Write(ctx, updated)
+}
@@ -0,0 +1,103 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// MaintenanceOptions selects the repository maintenance to run
+type MaintenanceOptions struct {
+	// Repack packs loose objects into packfiles
+	Repack bool
+	// GC runs git gc, which also repacks and prunes unreachable objects
+	GC bool
+	// Aggressive spends more time to produce smaller packs
+	Aggressive bool
+	// PruneOlderThan limits pruning to unreachable objects older than this,
+	// two weeks by default on the server
+	PruneOlderThan time.Duration
+
+	// PollInterval is how often to check on the running job, 2 seconds by
+	// default
+	PollInterval time.Duration
+}
+
+// MaintenanceResult reports the outcome of a maintenance run
+type MaintenanceResult struct {
+	ID             string    `json:"id"`
+	State          string    `json:"state"`
+	SizeBefore     int64     `json:"sizeBefore"`
+	SizeAfter      int64     `json:"sizeAfter"`
+	LooseObjects   int       `json:"looseObjects"`
+	DurationMillis float64   `json:"durationMillis"`
+	Error          string    `json:"error"`
+	StartedAt      time.Time `json:"startedAt"`
+}
+
+// Reclaimed returns the number of bytes the run freed
+func (r *MaintenanceResult) Reclaimed() int64 {
+	return r.SizeBefore - r.SizeAfter
+}
+
+// Duration returns how long the run took
+func (r *MaintenanceResult) Duration() time.Duration {
+	return time.Duration(r.DurationMillis * float64(time.Millisecond))
+}
+
+// Maintenance asks the server to garbage collect and repack the repository
+// and waits for it to finish, so long-lived databases do not degrade from
+// loose-object bloat. It is cheap to call from a cron job; the server runs
+// one job at a time and joins concurrent requests to the running job.
+// Maintenance affects the whole repository and is refused on namespaced
+// clients.
+func (c *Client) Maintenance(ctx context.Context, opts MaintenanceOptions) (*MaintenanceResult, error) {
+	if c.namespace != "" {
+		return nil, fmt.Errorf("maintenance is not available on namespaced clients")
+	}
+	if !opts.Repack && !opts.GC {
+		return nil, fmt.Errorf("maintenance needs Repack or GC")
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 2 * time.Second
+	}
+
+	request := map[string]interface{}{
+		"repack":     opts.Repack,
+		"gc":         opts.GC,
+		"aggressive": opts.Aggressive,
+	}
+	if opts.PruneOlderThan > 0 {
+		request["pruneOlderThanSeconds"] = int64(opts.PruneOlderThan.Seconds())
+	}
+
+	var job MaintenanceResult
+	url := fmt.Sprintf("%s/api/v1/maintenance", c.BaseURL)
+	if err := c.doJSON(ctx, "POST", url, request, &job, http.StatusAccepted, "start maintenance"); err != nil {
+		return nil, err
+	}
+
+	ticker := time.NewTicker(opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		switch job.State {
+		case "succeeded":
+			return &job, nil
+		case "failed":
+			return &job, fmt.Errorf("maintenance failed: %s", job.Error)
+		}
+
+		select {
+		case <-ctx.Done():
+			return &job, ctx.Err()
+		case <-ticker.C:
+		}
+
+		url := fmt.Sprintf("%s/api/v1/maintenance/%s", c.BaseURL, pathSegment(job.ID))
+		if err := c.doJSON(ctx, "GET", url, nil, &job, http.StatusOK, "get maintenance status"); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// MapReduceOptions configures a server-side map-reduce run
+type MapReduceOptions struct {
+	// Query restricts the documents fed to the map function
+	Query Query `json:"query,omitempty"`
+
+	// Finalize is an optional function applied to each reduced value
+	Finalize string `json:"finalize,omitempty"`
+
+	// Scope holds variables made available to the map, reduce and finalize
+	// functions
+	Scope map[string]interface{} `json:"scope,omitempty"`
+
+	// Out names a collection to write the results to instead of returning
+	// them inline
+	Out string `json:"out,omitempty"`
+}
+
+// MapReduceResult is one reduced key
+type MapReduceResult struct {
+	ID    interface{} `json:"_id"`
+	Value interface{} `json:"value"`
+}
+
+// MapReduce evaluates mapFn and reduceFn (JavaScript source) on the server
+// over the documents of a collection. Results are returned inline unless
+// opts.Out names an output collection, in which case none are returned.
+func (c *Client) MapReduce(ctx context.Context, collection, mapFn, reduceFn string, opts MapReduceOptions) ([]MapReduceResult, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	if mapFn == "" || reduceFn == "" {
+		return nil, fmt.Errorf("map and reduce functions are required")
+	}
+
+	if opts.Out != "" {
+		if opts.Out, err = c.collectionName(opts.Out); err != nil {
+			return nil, err
+		}
+	}
+	opts.Query = c.encodeQuery(collection, opts.Query)
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/mapreduce", c.BaseURL, name)
+
+	data := struct {
+		Map    string `json:"map"`
+		Reduce string `json:"reduce"`
+		MapReduceOptions
+	}{mapFn, reduceFn, opts}
+
+	var response struct {
+		Results []MapReduceResult `json:"results"`
+	}
+	if err := c.doJSON(ctx, "POST", url, data, &response, http.StatusOK, "run map-reduce"); err != nil {
+		return nil, err
+	}
+
+	return response.Results, nil
+}
@@ -0,0 +1,56 @@
+package gitdb
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MaxTimeHeader carries the server-side execution time limit of a query,
+// in milliseconds
+const MaxTimeHeader = "X-GitDB-Max-Time-MS"
+
+// ErrQueryTimeout is returned when the server stopped a query that ran
+// longer than the client's MaxTime
+var ErrQueryTimeout = errors.New("query exceeded its time limit")
+
+// queryOperations are the operations the server enforces MaxTime on
+var queryOperations = map[string]bool{
+	"find documents":      true,
+	"count documents":     true,
+	"aggregate documents": true,
+	"open cursor":         true,
+	"fetch cursor":        true,
+}
+
+// WithMaxTime returns a copy of the client whose finds, counts and
+// aggregations are stopped by the server once they have run for d, failing
+// with ErrQueryTimeout, so a single pathological query cannot tie up the
+// server. Unlike a context deadline, the limit frees the server too. A zero
+// d removes the limit.
+func (c *Client) WithMaxTime(d time.Duration) *Client {
+	nc := *c
+	nc.maxTime = d
+	return &nc
+}
+
+// maxTimeRequest sets the execution time limit of a query
+func (c *Client) maxTimeRequest(op string, req *http.Request) {
+	if c.maxTime > 0 && queryOperations[op] {
+		req.Header.Set(MaxTimeHeader, strconv.FormatInt(c.maxTime.Milliseconds(), 10))
+	}
+}
+
+// maxTimeResponse turns the server's refusal to run a query any longer
+// into ErrQueryTimeout
+func (c *Client) maxTimeResponse(req *http.Request, resp *http.Response) (*http.Response, error) {
+	if resp.StatusCode != http.StatusRequestTimeout || req.Header.Get(MaxTimeHeader) == "" {
+		return resp, nil
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return nil, fmt.Errorf("%w after %s", ErrQueryTimeout, c.maxTime)
+}
@@ -0,0 +1,295 @@
+package gitdb
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds of the latency histogram buckets
+var latencyBuckets = []time.Duration{
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	60 * time.Second,
+}
+
+// Metrics is a snapshot of the client's request statistics
+type Metrics struct {
+	// Since is when the client started collecting
+	Since time.Time
+
+	// Operations holds the statistics of each operation, keyed by name
+	// (e.g. "insert document")
+	Operations map[string]OperationStats
+
+	// Tagged holds the statistics of each operation per combination of
+	// the values of the tag keys given to WithMetricTags, sorted by
+	// operation and tags. Requests carrying none of the keys are left out.
+	Tagged []TaggedOperationStats
+}
+
+// TaggedOperationStats summarises the requests made for one operation with
+// one combination of tag values
+type TaggedOperationStats struct {
+	Operation string
+	// Tags holds the value of each metric tag key, "" for keys the
+	// requests did not carry
+	Tags map[string]string
+	OperationStats
+}
+
+// OperationStats summarises the requests made for one operation. Latency
+// percentiles are estimated from a histogram and report the upper bound of
+// the bucket the percentile falls in.
+type OperationStats struct {
+	Requests     int64
+	Errors       int64 // transport failures and 5xx responses
+	ClientErrors int64 // 4xx responses
+	Retries      int64
+	Mean         time.Duration
+	P50          time.Duration
+	P90          time.Duration
+	P99          time.Duration
+	Max          time.Duration
+
+	// Buckets holds cumulative request counts per latency upper bound, in
+	// the order of LatencyBuckets
+	Buckets []int64
+	Sum     time.Duration
+}
+
+// ErrorRate returns the fraction of requests that failed
+func (s OperationStats) ErrorRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Requests)
+}
+
+// LatencyBuckets returns the upper bounds of the latency histogram used in
+// OperationStats.Buckets
+func LatencyBuckets() []time.Duration {
+	return append([]time.Duration(nil), latencyBuckets...)
+}
+
+// Metrics returns per-operation latency percentiles, error rates and retry
+// counts collected by the client and every client derived from it
+func (c *Client) Metrics() Metrics {
+	return c.shared().metrics.snapshot()
+}
+
+type metrics struct {
+	mu    sync.Mutex
+	since time.Time
+	ops   map[string]*opMetrics
+
+	// tagKeys are the tag keys metrics are split by, fixed when the client
+	// is created
+	tagKeys []string
+	tagged  map[taggedKey]*taggedMetrics
+}
+
+type opMetrics struct {
+	requests     int64
+	errors       int64
+	clientErrors int64
+	retries      int64
+	sum          time.Duration
+	max          time.Duration
+	buckets      []int64 // one per latency bucket plus overflow
+}
+
+// taggedKey identifies an operation and a combination of tag values
+type taggedKey struct {
+	op   string
+	tags string
+}
+
+type taggedMetrics struct {
+	tags map[string]string
+	*opMetrics
+}
+
+func newMetrics() *metrics {
+	return &metrics{since: time.Now(), ops: make(map[string]*opMetrics), tagged: make(map[taggedKey]*taggedMetrics)}
+}
+
+func newOpMetrics() *opMetrics {
+	return &opMetrics{buckets: make([]int64, len(latencyBuckets)+1)}
+}
+
+func (m *metrics) op(name string) *opMetrics {
+	op, ok := m.ops[name]
+	if !ok {
+		op = newOpMetrics()
+		m.ops[name] = op
+	}
+	return op
+}
+
+// taggedOp returns the metrics of an operation for the values tags has for
+// the metric tag keys, or nil if it has none of them
+func (m *metrics) taggedOp(name string, tags map[string]string) *opMetrics {
+	if len(m.tagKeys) == 0 || len(tags) == 0 {
+		return nil
+	}
+
+	values := make(map[string]string, len(m.tagKeys))
+	found := false
+	for _, key := range m.tagKeys {
+		value, ok := tags[key]
+		found = found || ok
+		values[key] = value
+	}
+	if !found {
+		return nil
+	}
+
+	key := taggedKey{op: name, tags: encodeTags(values)}
+	op, ok := m.tagged[key]
+	if !ok {
+		op = &taggedMetrics{tags: values, opMetrics: newOpMetrics()}
+		m.tagged[key] = op
+	}
+	return op.opMetrics
+}
+
+// record adds a finished request. status is 0 if no response was received.
+func (m *metrics) record(name string, tags map[string]string, latency time.Duration, status int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.op(name).record(latency, status, err)
+	if op := m.taggedOp(name, tags); op != nil {
+		op.record(latency, status, err)
+	}
+}
+
+func (op *opMetrics) record(latency time.Duration, status int, err error) {
+	op.requests++
+	switch {
+	case err != nil || status >= 500:
+		op.errors++
+	case status >= 400:
+		op.clientErrors++
+	}
+
+	op.sum += latency
+	if latency > op.max {
+		op.max = latency
+	}
+	i := sort.Search(len(latencyBuckets), func(i int) bool { return latency <= latencyBuckets[i] })
+	op.buckets[i]++
+}
+
+// retry counts a retried request
+func (m *metrics) retry(name string, tags map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.op(name).retries++
+	if op := m.taggedOp(name, tags); op != nil {
+		op.retries++
+	}
+}
+
+// percentile estimates quantile q of an operation's latency, reporting false
+// until the operation has at least minSamples requests
+func (m *metrics) percentile(name string, q float64, minSamples int64) (time.Duration, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	op, ok := m.ops[name]
+	if !ok || op.requests < minSamples {
+		return 0, false
+	}
+	return op.percentile(q), true
+}
+
+func (m *metrics) snapshot() Metrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := Metrics{Since: m.since, Operations: make(map[string]OperationStats, len(m.ops))}
+	for name, op := range m.ops {
+		snapshot.Operations[name] = op.stats()
+	}
+
+	keys := make([]taggedKey, 0, len(m.tagged))
+	for key := range m.tagged {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].op != keys[j].op {
+			return keys[i].op < keys[j].op
+		}
+		return keys[i].tags < keys[j].tags
+	})
+	for _, key := range keys {
+		op := m.tagged[key]
+		tags := make(map[string]string, len(op.tags))
+		for k, v := range op.tags {
+			tags[k] = v
+		}
+		snapshot.Tagged = append(snapshot.Tagged, TaggedOperationStats{Operation: key.op, Tags: tags, OperationStats: op.stats()})
+	}
+	return snapshot
+}
+
+// stats summarises the operation's requests
+func (op *opMetrics) stats() OperationStats {
+	stats := OperationStats{
+		Requests:     op.requests,
+		Errors:       op.errors,
+		ClientErrors: op.clientErrors,
+		Retries:      op.retries,
+		Max:          op.max,
+		Sum:          op.sum,
+		Buckets:      make([]int64, len(latencyBuckets)),
+	}
+
+	var cumulative int64
+	for i := range latencyBuckets {
+		cumulative += op.buckets[i]
+		stats.Buckets[i] = cumulative
+	}
+
+	if op.requests > 0 {
+		stats.Mean = op.sum / time.Duration(op.requests)
+		stats.P50 = op.percentile(0.50)
+		stats.P90 = op.percentile(0.90)
+		stats.P99 = op.percentile(0.99)
+	}
+	return stats
+}
+
+// percentile estimates the latency below which fraction q of requests fall
+func (op *opMetrics) percentile(q float64) time.Duration {
+	rank := int64(q*float64(op.requests) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+
+	var cumulative int64
+	for i, count := range op.buckets {
+		cumulative += count
+		if cumulative >= rank {
+			if i < len(latencyBuckets) && latencyBuckets[i] < op.max {
+				return latencyBuckets[i]
+			}
+			return op.max
+		}
+	}
+	return op.max
+}
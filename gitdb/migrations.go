@@ -0,0 +1,213 @@
+package gitdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SchemaVersionField holds the version of the schema a document was
+// written under. Documents without it are at version 0. It must be stored
+// under its own name, so field aliases and codecs must leave it alone.
+const SchemaVersionField = "schemaVersion"
+
+// MigrateFunc upgrades a document by one schema version and returns it. It
+// may instead modify doc in place and return nil.
+type MigrateFunc func(doc Document) Document
+
+// RegisterMigration registers fn to upgrade the collection's documents
+// from fromVersion to fromVersion+1. Documents read from the collection
+// are passed through each migration from their version up to the latest,
+// after field aliases and the codec are applied and before computed
+// fields, so old documents always reach the application in the current
+// shape. Inserted documents without a version get the latest. Set
+// CollectionOptions.WriteBackMigrations to store upgraded documents.
+// Passing a nil fn removes the migration.
+func (c *Client) RegisterMigration(collection string, fromVersion int, fn MigrateFunc) error {
+	if _, err := c.collectionName(collection); err != nil {
+		return err
+	}
+	if fromVersion < 0 {
+		return fmt.Errorf("invalid migration for %s: version %d is negative", collection, fromVersion)
+	}
+
+	r := c.registry()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if fn == nil {
+		delete(r.migrations[collection], fromVersion)
+		if len(r.migrations[collection]) == 0 {
+			delete(r.migrations, collection)
+		}
+		return nil
+	}
+	if r.migrations[collection] == nil {
+		r.migrations[collection] = make(map[int]MigrateFunc)
+	}
+	r.migrations[collection][fromVersion] = fn
+	return nil
+}
+
+// SchemaVersion returns the latest schema version of a collection, one
+// past the highest version a migration upgrades from, or 0 if it has none
+func (c *Client) SchemaVersion(collection string) int {
+	return latestVersion(c.migrations(collection))
+}
+
+func (c *Client) migrations(collection string) map[int]MigrateFunc {
+	if c.collections == nil {
+		return nil
+	}
+	c.collections.mu.RLock()
+	defer c.collections.mu.RUnlock()
+	return c.collections.migrations[collection]
+}
+
+func latestVersion(migrations map[int]MigrateFunc) int {
+	latest := 0
+	for from := range migrations {
+		if from+1 > latest {
+			latest = from + 1
+		}
+	}
+	return latest
+}
+
+// stampSchemaVersion sets the latest schema version on a copy of doc
+// unless it has one
+func (c *Client) stampSchemaVersion(collection string, doc Document) Document {
+	migrations := c.migrations(collection)
+	if len(migrations) == 0 {
+		return doc
+	}
+	if _, ok := doc[SchemaVersionField]; ok {
+		return doc
+	}
+	stamped := make(Document, len(doc)+1)
+	for key, value := range doc {
+		stamped[key] = value
+	}
+	stamped[SchemaVersionField] = latestVersion(migrations)
+	return stamped
+}
+
+// migrateDocument upgrades doc to the latest schema version, reporting
+// whether any migration ran
+func (c *Client) migrateDocument(collection string, doc Document) (Document, bool) {
+	migrations := c.migrations(collection)
+	if len(migrations) == 0 || doc == nil {
+		return doc, false
+	}
+
+	migrated := false
+	for version := documentVersion(doc); ; version++ {
+		fn, ok := migrations[version]
+		if !ok {
+			return doc, migrated
+		}
+		if upgraded := fn(doc); upgraded != nil {
+			doc = upgraded
+		}
+		doc[SchemaVersionField] = version + 1
+		migrated = true
+	}
+}
+
+// documentVersion returns the schema version of doc
+func documentVersion(doc Document) int {
+	switch v := doc[SchemaVersionField].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case json.Number:
+		n, _ := v.Int64()
+		return int(n)
+	}
+	return 0
+}
+
+// readDocuments decodes documents read from a collection in place and, if
+// the collection writes back migrations, stores the ones that were
+// upgraded. Write-back is best effort: a failure leaves the stored document
+// to be upgraded again on its next read. Partial documents, read with a
+// projection, are migrated but never written back, since the fields left
+// out would keep their old shape under the latest version.
+func (c *Client) readDocuments(ctx context.Context, collection string, docs []Document, partial bool) error {
+	opts := c.collectionOptions(collection)
+	writeBack := opts != nil && opts.WriteBackMigrations && !partial
+
+	for i, doc := range docs {
+		// Migrations may modify the document in place, so keep what was
+		// stored to tell which fields they removed
+		var stored Document
+		if writeBack && doc != nil {
+			stored = make(Document, len(doc))
+			for key, value := range doc {
+				stored[key] = value
+			}
+		}
+		decoded, migrated, err := c.decodeStoredDocument(collection, doc)
+		if err != nil {
+			return err
+		}
+		if migrated && stored != nil {
+			c.writeBackMigration(ctx, collection, stored, decoded)
+		}
+		docs[i] = decoded
+	}
+	return nil
+}
+
+// writeBackMigration replaces a stored document with its upgraded form,
+// unless its fields changed since it was read
+func (c *Client) writeBackMigration(ctx context.Context, collection string, stored, doc Document) {
+	id, ok := stored["_id"].(string)
+	if !ok {
+		return
+	}
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return
+	}
+	upgraded, err := c.encodeDocument(collection, copyDocument(doc))
+	if err != nil {
+		return
+	}
+
+	// Underscored fields, such as _id and _verified, are not document data
+	set := make(map[string]interface{}, len(upgraded))
+	for key, value := range upgraded {
+		if !strings.HasPrefix(key, "_") {
+			set[key] = value
+		}
+	}
+	update := map[string]interface{}{"$set": set}
+	unset := make(map[string]interface{})
+	for key := range stored {
+		if _, ok := upgraded[key]; !ok && !strings.HasPrefix(key, "_") {
+			unset[key] = ""
+		}
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	// Match the fields as read, so a document written meanwhile is left
+	// for its next read to upgrade
+	query := Query{"_id": id, SchemaVersionField: stored[SchemaVersionField]}
+	for key, value := range stored {
+		if !strings.HasPrefix(key, "_") {
+			query[key] = value
+		}
+	}
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/documents/update-many", c.BaseURL, name)
+	data := map[string]interface{}{"query": query, "update": update}
+	c.doJSON(ctx, "POST", url, data, nil, http.StatusOK, "update documents")
+}
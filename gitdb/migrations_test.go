@@ -0,0 +1,154 @@
+package gitdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// migrationServer holds one collection and answers finds, projected
+// queries and conditional updates, which is all reads with write-back need
+func migrationServer(t *testing.T, docs ...Document) (*httptest.Server, func() []Document) {
+	t.Helper()
+	var mu sync.Mutex
+	stored := docs
+
+	matches := func(doc, query map[string]interface{}) bool {
+		for field, want := range query {
+			if !reflect.DeepEqual(doc[field], want) {
+				return false
+			}
+		}
+		return true
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("%s %s: bad body: %v", r.Method, r.URL.Path, err)
+		}
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/documents/find"):
+			json.NewEncoder(w).Encode(stored)
+
+		case strings.HasSuffix(r.URL.Path, "/documents/query"):
+			projection, _ := body["projection"].(map[string]interface{})
+			found := []Document{}
+			for _, doc := range stored {
+				projected := Document{"_id": doc["_id"]}
+				for field, value := range doc {
+					if projection[field] == float64(1) {
+						projected[field] = value
+					}
+				}
+				found = append(found, projected)
+			}
+			json.NewEncoder(w).Encode(found)
+
+		case strings.HasSuffix(r.URL.Path, "/documents/update-many"):
+			query, _ := body["query"].(map[string]interface{})
+			update, _ := body["update"].(map[string]interface{})
+			matched := 0
+			for _, doc := range stored {
+				if !matches(doc, query) {
+					continue
+				}
+				matched++
+				set, _ := update["$set"].(map[string]interface{})
+				for field, value := range set {
+					doc[field] = value
+				}
+				unset, _ := update["$unset"].(map[string]interface{})
+				for field := range unset {
+					delete(doc, field)
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"matchedCount": matched, "modifiedCount": matched})
+
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return srv, func() []Document {
+		mu.Lock()
+		defer mu.Unlock()
+		return stored
+	}
+}
+
+func TestProjectedReadDoesNotWriteBackMigration(t *testing.T) {
+	srv, stored := migrationServer(t, Document{"_id": "u1", "name": "Ada", "city": "London"})
+	defer srv.Close()
+
+	c := NewClient("token", "owner", "repo")
+	c.BaseURL = srv.URL
+	ctx := context.Background()
+
+	c.RegisterMigration("users", 0, func(doc Document) Document {
+		if name, ok := doc["name"]; ok {
+			doc["fullName"] = name
+			delete(doc, "name")
+		}
+		if city, ok := doc["city"]; ok {
+			doc["location"] = city
+			delete(doc, "city")
+		}
+		return doc
+	})
+	c.SetCollectionOptions("users", &CollectionOptions{WriteBackMigrations: true})
+
+	projected, err := c.FindWithOptions(ctx, "users", Query{}, FindOptions{Projection: Include("name")})
+	if err != nil {
+		t.Fatalf("projected find: %v", err)
+	}
+	if len(projected) != 1 || projected[0]["fullName"] != "Ada" {
+		t.Fatalf("projected read = %v, want the migrated fullName", projected)
+	}
+	if doc := stored()[0]; documentVersion(doc) != 0 || doc["name"] != "Ada" {
+		t.Fatalf("projected read wrote back %v", doc)
+	}
+
+	full, err := c.Find(ctx, "users", Query{})
+	if err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	want := Document{"fullName": "Ada", "location": "London"}
+	if got := signedContent(full[0]); got["fullName"] != want["fullName"] || got["location"] != want["location"] || got["city"] != nil {
+		t.Errorf("full read = %v, want %v", full[0], want)
+	}
+	doc := stored()[0]
+	if documentVersion(doc) != 1 || doc["location"] != "London" || doc["fullName"] != "Ada" || doc["city"] != nil || doc["name"] != nil {
+		t.Errorf("stored after full read = %v, want every field migrated at version 1", doc)
+	}
+}
+
+func TestProjectionKeepsSchemaVersion(t *testing.T) {
+	c := NewClient("token", "owner", "repo")
+	c.RegisterMigration("users", 0, func(doc Document) Document { return doc })
+
+	included, err := c.encodeProjection("users", Include("name"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if included[SchemaVersionField] != 1 {
+		t.Errorf("inclusion = %v, want %s included", included, SchemaVersionField)
+	}
+
+	excluded, err := c.encodeProjection("users", Exclude("payload", SchemaVersionField))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := excluded[SchemaVersionField]; ok {
+		t.Errorf("exclusion = %v, want %s kept", excluded, SchemaVersionField)
+	}
+}
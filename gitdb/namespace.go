@@ -0,0 +1,118 @@
+package gitdb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// namespaceSeparator joins a tenant namespace and a collection name
+const namespaceSeparator = "__"
+
+// WithNamespace returns a copy of the client scoped to a tenant.
+//
+// Every collection name used through the returned client is transparently
+// prefixed with the tenant ID, ListCollections only reports the tenant's own
+// collections, and raw GraphQL queries and Traverse (which could reach any
+// collection) are refused. Calling WithNamespace on an already namespaced client nests
+// the namespaces, so a tenant-scoped client can never reach a sibling tenant.
+// An invalid tenant ID, including an empty one, makes every request of the
+// returned client fail rather than leave it unscoped.
+func (c *Client) WithNamespace(tenantID string) *Client {
+	nc := *c
+	if c.namespace == "" {
+		nc.namespace = tenantID
+	} else {
+		nc.namespace = c.namespace + namespaceSeparator + tenantID
+	}
+	if err := validateNamespace(tenantID); err != nil {
+		nc.namespaceErr = err
+	}
+	return &nc
+}
+
+// Namespace returns the tenant namespace the client is scoped to, if any
+func (c *Client) Namespace() string {
+	return c.namespace
+}
+
+// collectionName validates a collection name and resolves it to its
+// server-side name. The client's own collections pass despite their
+// reserved names, as locks and pub/sub address them like any other.
+func (c *Client) collectionName(name string) (string, error) {
+	if c.namespaceErr != nil {
+		return "", c.namespaceErr
+	}
+	if isInternalCollection(name) {
+		if err := validateCollectionName(name); err != nil {
+			return "", err
+		}
+	} else if err := ValidateCollectionName(name); err != nil {
+		return "", err
+	}
+	if c.namespace == "" {
+		return name, nil
+	}
+	if strings.HasPrefix(name, "_") && !isInternalCollection(name) {
+		return "", &ValidationError{Kind: "collection name", Name: name, Reason: "must not start with '_' on a namespaced client"}
+	}
+
+	resolved := c.namespace + namespaceSeparator + name
+	if err := validateCollectionName(resolved); err != nil {
+		return "", err
+	}
+	return resolved, nil
+}
+
+// localCollectionName strips the client's namespace prefix from a
+// server-side collection name
+func (c *Client) localCollectionName(name string) string {
+	if c.namespace == "" {
+		return name
+	}
+	return strings.TrimPrefix(name, c.namespace+namespaceSeparator)
+}
+
+// filterNamespace keeps the collections belonging to the client's namespace
+// and strips the namespace prefix from their names
+func (c *Client) filterNamespace(collections []Collection) []Collection {
+	if c.namespace == "" {
+		return collections
+	}
+
+	prefix := c.namespace + namespaceSeparator
+	filtered := make([]Collection, 0, len(collections))
+	for _, collection := range collections {
+		name := strings.TrimPrefix(collection.Name, prefix)
+		if name == collection.Name || strings.Contains(name, namespaceSeparator) {
+			continue
+		}
+		collection.Name = name
+		filtered = append(filtered, collection)
+	}
+	return filtered
+}
+
+// isInternalCollection reports whether name is a collection the client
+// keeps its own state in, such as lock and topic collections
+func isInternalCollection(name string) bool {
+	return name == locksCollection || name == offsetsCollection || strings.HasPrefix(name, "_topic-")
+}
+
+// validateNamespace checks that a tenant ID can be used as a name prefix.
+// Tenant IDs must not start or end with '_', so that a prefixed name splits
+// into tenant and collection in exactly one way.
+func validateNamespace(tenantID string) error {
+	if tenantID == "" {
+		return &ValidationError{Kind: "namespace", Name: tenantID, Reason: "must not be empty"}
+	}
+	if strings.HasPrefix(tenantID, "_") || strings.HasSuffix(tenantID, "_") {
+		return &ValidationError{Kind: "namespace", Name: tenantID, Reason: "must not start or end with '_'"}
+	}
+	if strings.Contains(tenantID, namespaceSeparator) {
+		return &ValidationError{Kind: "namespace", Name: tenantID, Reason: fmt.Sprintf("must not contain %q", namespaceSeparator)}
+	}
+	if err := validateCollectionName(tenantID); err != nil {
+		return &ValidationError{Kind: "namespace", Name: tenantID, Reason: err.(*ValidationError).Reason}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package gitdb
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+// Option configures a client created by NewClient
+type Option func(*options)
+
+// DialFunc dials network connections, with the signature of
+// net.Dialer.DialContext
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// options collects the settings of NewClient's options before the client
+// is assembled
+type options struct {
+	dial        DialFunc
+	dnsCache    *dnsCache
+	staticHosts map[string]string
+	signer      Signer
+	requestKey  *RequestKey
+	retryPolicy RetryPolicy
+
+	maxResponseBytes int64
+	maxDocuments     int
+	maxDocumentBytes int
+	maxInValues      int
+	apiNegotiation   bool
+	metricTags       []string
+}
+
+// apply configures c from the collected options
+func (o *options) apply(c *Client) {
+	if o.dial != nil || o.dnsCache != nil || o.staticHosts != nil {
+		dial := o.dial
+		if dial == nil {
+			dial = (&net.Dialer{Timeout: defaultDialTimeout, KeepAlive: defaultKeepAlive}).DialContext
+		}
+		dial = resolvingDialer(dial, o.staticHosts, o.dnsCache)
+		c.shared().resolver = &hostResolver{static: o.staticHosts, cache: o.dnsCache, custom: o.dial != nil}
+
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DialContext = dial
+		c.HTTPClient.Transport = transport
+	}
+	if o.signer != nil {
+		c.shared().signer = o.signer
+	}
+	if o.requestKey != nil {
+		c.shared().requestKey = o.requestKey
+	}
+	if o.retryPolicy != nil {
+		c.shared().retryPolicy = o.retryPolicy
+	}
+	c.shared().maxResponseBytes = o.maxResponseBytes
+	c.shared().maxDocuments = o.maxDocuments
+	c.shared().maxDocumentBytes = o.maxDocumentBytes
+	c.shared().maxInValues = o.maxInValues
+	c.shared().metrics.tagKeys = o.metricTags
+	if o.apiNegotiation {
+		c.shared().api = &apiNegotiation{}
+	}
+}
@@ -0,0 +1,370 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PartitionPeriod is the time span covered by one partition
+type PartitionPeriod int
+
+const (
+	// PartitionMonthly writes into one collection per month, events_2024_05
+	PartitionMonthly PartitionPeriod = iota
+	// PartitionDaily writes into one collection per day, events_2024_05_17
+	PartitionDaily
+	// PartitionYearly writes into one collection per year, events_2024
+	PartitionYearly
+)
+
+// layout returns the name suffix layout of the period
+func (p PartitionPeriod) layout() string {
+	switch p {
+	case PartitionDaily:
+		return "2006_01_02"
+	case PartitionYearly:
+		return "2006"
+	default:
+		return "2006_01"
+	}
+}
+
+// start truncates t to the beginning of its partition
+func (p PartitionPeriod) start(t time.Time) time.Time {
+	t = t.UTC()
+	switch p {
+	case PartitionDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case PartitionYearly:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// next returns the start of the partition after the one starting at start
+func (p PartitionPeriod) next(start time.Time) time.Time {
+	switch p {
+	case PartitionDaily:
+		return start.AddDate(0, 0, 1)
+	case PartitionYearly:
+		return start.AddDate(1, 0, 0)
+	default:
+		return start.AddDate(0, 1, 0)
+	}
+}
+
+// Partition is one time bucket of a partitioned collection
+type Partition struct {
+	// Collection is the sub-collection holding the bucket
+	Collection string
+	// Start and End bound the bucket, End being exclusive
+	Start time.Time
+	End   time.Time
+}
+
+// PartitionedCollection writes documents into time-bucketed sub-collections
+// named after a base collection, such as events_2024_05, based on a
+// timestamp field, and fans queries out across the partitions a query's
+// time range touches. Timestamps are time.Time values or RFC3339 strings
+// and are bucketed in UTC.
+type PartitionedCollection struct {
+	client *Client
+	base   string
+	field  string
+	period PartitionPeriod
+
+	mu    sync.Mutex
+	known map[string]bool
+}
+
+// Partitioned returns a collection partitioned by the timestamp in field
+func (c *Client) Partitioned(base, field string, period PartitionPeriod) *PartitionedCollection {
+	return &PartitionedCollection{client: c, base: base, field: field, period: period}
+}
+
+// PartitionFor returns the name of the partition holding timestamp t
+func (pc *PartitionedCollection) PartitionFor(t time.Time) string {
+	return pc.base + "_" + pc.period.start(t).Format(pc.period.layout())
+}
+
+// Partitions lists the existing partitions, oldest first
+func (pc *PartitionedCollection) Partitions(ctx context.Context) ([]Partition, error) {
+	collections, err := pc.client.ListCollections(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var partitions []Partition
+	known := make(map[string]bool)
+	for _, collection := range collections {
+		suffix := strings.TrimPrefix(collection.Name, pc.base+"_")
+		if suffix == collection.Name || len(suffix) != len(pc.period.layout()) {
+			continue
+		}
+		start, err := time.Parse(pc.period.layout(), suffix)
+		if err != nil {
+			continue
+		}
+		known[collection.Name] = true
+		partitions = append(partitions, Partition{
+			Collection: collection.Name,
+			Start:      start,
+			End:        pc.period.next(start),
+		})
+	}
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].Start.Before(partitions[j].Start)
+	})
+
+	pc.mu.Lock()
+	pc.known = known
+	pc.mu.Unlock()
+
+	return partitions, nil
+}
+
+// Insert writes document into the partition for its timestamp, creating
+// the partition on first use
+func (pc *PartitionedCollection) Insert(ctx context.Context, document Document) (string, error) {
+	t, err := partitionTime(document[pc.field])
+	if err != nil {
+		return "", fmt.Errorf("failed to partition document: field %s: %w", pc.field, err)
+	}
+
+	name := pc.PartitionFor(t)
+	if err := pc.ensure(ctx, name); err != nil {
+		return "", err
+	}
+	return pc.client.Insert(ctx, name, document)
+}
+
+// ensure creates the named partition unless it is known to exist
+func (pc *PartitionedCollection) ensure(ctx context.Context, name string) error {
+	pc.mu.Lock()
+	loaded, exists := pc.known != nil, pc.known[name]
+	pc.mu.Unlock()
+	if exists {
+		return nil
+	}
+
+	if !loaded {
+		if _, err := pc.Partitions(ctx); err != nil {
+			return err
+		}
+		pc.mu.Lock()
+		exists = pc.known[name]
+		pc.mu.Unlock()
+		if exists {
+			return nil
+		}
+	}
+
+	if err := pc.client.CreateCollection(ctx, name); err != nil {
+		// Another writer may have created it in the meantime
+		if _, listErr := pc.Partitions(ctx); listErr != nil {
+			return err
+		}
+		pc.mu.Lock()
+		exists = pc.known[name]
+		pc.mu.Unlock()
+		if !exists {
+			return err
+		}
+		return nil
+	}
+
+	pc.mu.Lock()
+	pc.known[name] = true
+	pc.mu.Unlock()
+	return nil
+}
+
+// Find runs query against every partition its time range on the timestamp
+// field touches and concatenates the results, oldest partition first. A
+// query without a range on the timestamp field searches all partitions.
+func (pc *PartitionedCollection) Find(ctx context.Context, query Query) ([]Document, error) {
+	partitions, err := pc.partitionsFor(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var documents []Document
+	for _, p := range partitions {
+		docs, err := pc.client.Find(ctx, p.Collection, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find documents in %s: %w", p.Collection, err)
+		}
+		documents = append(documents, docs...)
+	}
+	return documents, nil
+}
+
+// Count counts the documents matching query across the relevant partitions
+func (pc *PartitionedCollection) Count(ctx context.Context, query Query) (int, error) {
+	partitions, err := pc.partitionsFor(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, p := range partitions {
+		n, err := pc.client.Count(ctx, p.Collection, query)
+		if err != nil {
+			return total, fmt.Errorf("failed to count documents in %s: %w", p.Collection, err)
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// UpdateMany applies update to the matching documents across the relevant
+// partitions. Updates must not move the timestamp field into another
+// partition's range.
+func (pc *PartitionedCollection) UpdateMany(ctx context.Context, query Query, update Update) (int, error) {
+	partitions, err := pc.partitionsFor(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, p := range partitions {
+		result, err := pc.client.UpdateMany(ctx, p.Collection, query, update)
+		if err != nil {
+			return total, fmt.Errorf("failed to update documents in %s: %w", p.Collection, err)
+		}
+		total += result.ModifiedCount
+	}
+	return total, nil
+}
+
+// DeleteMany deletes the matching documents across the relevant partitions
+func (pc *PartitionedCollection) DeleteMany(ctx context.Context, query Query) (int, error) {
+	partitions, err := pc.partitionsFor(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, p := range partitions {
+		n, err := pc.client.DeleteMany(ctx, p.Collection, query)
+		if err != nil {
+			return total, fmt.Errorf("failed to delete documents in %s: %w", p.Collection, err)
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// DropBefore deletes the partitions that end at or before t, the cheap way
+// to expire old time-series data
+func (pc *PartitionedCollection) DropBefore(ctx context.Context, t time.Time) ([]string, error) {
+	partitions, err := pc.Partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var dropped []string
+	for _, p := range partitions {
+		if p.End.After(t) {
+			break
+		}
+		if err := pc.client.DeleteCollection(ctx, p.Collection); err != nil {
+			return dropped, err
+		}
+		dropped = append(dropped, p.Collection)
+	}
+
+	pc.mu.Lock()
+	for _, name := range dropped {
+		delete(pc.known, name)
+	}
+	pc.mu.Unlock()
+
+	return dropped, nil
+}
+
+// partitionsFor returns the existing partitions overlapping the query's
+// time range
+func (pc *PartitionedCollection) partitionsFor(ctx context.Context, query Query) ([]Partition, error) {
+	from, to, err := partitionRange(query[pc.field])
+	if err != nil {
+		return nil, fmt.Errorf("failed to partition query: field %s: %w", pc.field, err)
+	}
+
+	partitions, err := pc.Partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := partitions[:0]
+	for _, p := range partitions {
+		if !from.IsZero() && !p.End.After(from) {
+			continue
+		}
+		if !to.IsZero() && p.Start.After(to) {
+			continue
+		}
+		selected = append(selected, p)
+	}
+	return selected, nil
+}
+
+// partitionRange extracts the inclusive time bounds a query condition puts
+// on the timestamp field; zero bounds are open
+func partitionRange(condition interface{}) (from, to time.Time, err error) {
+	var operators map[string]interface{}
+	switch v := condition.(type) {
+	case nil:
+		return from, to, nil
+	case Query:
+		operators = v
+	case map[string]interface{}:
+		operators = v
+	default:
+		t, err := partitionTime(v)
+		return t, t, err
+	}
+
+	for op, value := range operators {
+		switch op {
+		case "$eq":
+			t, err := partitionTime(value)
+			if err != nil {
+				return from, to, err
+			}
+			from, to = t, t
+		case "$gt", "$gte":
+			if from, err = partitionTime(value); err != nil {
+				return from, to, err
+			}
+		case "$lt", "$lte":
+			if to, err = partitionTime(value); err != nil {
+				return from, to, err
+			}
+		}
+	}
+	return from, to, nil
+}
+
+// partitionTime reads a timestamp from a document or query value
+func partitionTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q", v)
+		}
+		return t, nil
+	case nil:
+		return time.Time{}, fmt.Errorf("missing timestamp")
+	default:
+		return time.Time{}, fmt.Errorf("unsupported timestamp type %T", value)
+	}
+}
@@ -0,0 +1,130 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+)
+
+// Ref builds a reference to a document in another collection, stored as
+// {"$ref": collection, "$id": id}
+func Ref(collection, id string) Document {
+	return Document{"$ref": collection, "$id": id}
+}
+
+// FindPopulated finds documents like Find and then resolves the references
+// held in the given fields, see Populate
+func (c *Client) FindPopulated(ctx context.Context, collection string, query Query, fields ...string) ([]Document, error) {
+	documents, err := c.Find(ctx, collection, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Populate(ctx, documents, fields...); err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
+
+// Populate replaces references held in the given top-level fields with the
+// documents they point to. A field may hold a single reference or an array
+// of references. References are batched so that each referenced collection
+// is queried once, regardless of how many documents point into it.
+// References to missing documents are left in place.
+func (c *Client) Populate(ctx context.Context, documents []Document, fields ...string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	// Collect the IDs to resolve, grouped by referenced collection
+	wanted := make(map[string][]interface{})
+	seen := make(map[string]map[string]bool)
+	for _, doc := range documents {
+		for _, field := range fields {
+			forEachRef(doc[field], func(collection, id string) {
+				if seen[collection] == nil {
+					seen[collection] = make(map[string]bool)
+				}
+				if !seen[collection][id] {
+					seen[collection][id] = true
+					wanted[collection] = append(wanted[collection], id)
+				}
+			})
+		}
+	}
+
+	resolved := make(map[string]map[string]Document, len(wanted))
+	for collection, ids := range wanted {
+		referenced, err := c.Find(ctx, collection, Query{"_id": Query{"$in": ids}})
+		if err != nil {
+			return fmt.Errorf("failed to populate references to %s: %w", collection, err)
+		}
+
+		byID := make(map[string]Document, len(referenced))
+		for _, doc := range referenced {
+			if id, ok := doc["_id"].(string); ok {
+				byID[id] = doc
+			}
+		}
+		resolved[collection] = byID
+	}
+
+	for _, doc := range documents {
+		for _, field := range fields {
+			if value, ok := doc[field]; ok {
+				doc[field] = replaceRefs(value, resolved)
+			}
+		}
+	}
+
+	return nil
+}
+
+// asRef reports whether v is a reference and returns its target
+func asRef(v interface{}) (collection, id string, ok bool) {
+	m, isMap := asMap(v)
+	if !isMap {
+		return "", "", false
+	}
+	collection, okRef := m["$ref"].(string)
+	id, okID := m["$id"].(string)
+	return collection, id, okRef && okID
+}
+
+// isRef reports whether m is exactly a reference, {"$ref": collection,
+// "$id": id} with no other keys
+func isRef(m map[string]interface{}) bool {
+	_, _, ok := asRef(m)
+	return ok && len(m) == 2
+}
+
+func forEachRef(value interface{}, fn func(collection, id string)) {
+	if collection, id, ok := asRef(value); ok {
+		fn(collection, id)
+		return
+	}
+	if items, ok := value.([]interface{}); ok {
+		for _, item := range items {
+			if collection, id, ok := asRef(item); ok {
+				fn(collection, id)
+			}
+		}
+	}
+}
+
+func replaceRefs(value interface{}, resolved map[string]map[string]Document) interface{} {
+	if collection, id, ok := asRef(value); ok {
+		if doc, found := resolved[collection][id]; found {
+			return doc
+		}
+		return value
+	}
+	if items, ok := value.([]interface{}); ok {
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			out[i] = replaceRefs(item, resolved)
+		}
+		return out
+	}
+	return value
+}
@@ -0,0 +1,116 @@
+package gitdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// refServer stores inserted documents per collection and answers finds
+// by _id, which is all Insert and FindPopulated need
+func refServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	stored := make(map[string][]Document)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		path := strings.TrimPrefix(r.URL.Path, "/api/v1/collections/")
+		collection, action, _ := strings.Cut(path, "/documents")
+
+		var body Document
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("%s %s: bad body: %v", r.Method, r.URL.Path, err)
+		}
+
+		switch action {
+		case "":
+			id := fmt.Sprintf("%s-%d", collection, len(stored[collection])+1)
+			body["_id"] = id
+			stored[collection] = append(stored[collection], body)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(Document{"_id": id})
+
+		case "/find":
+			var ids []interface{}
+			if in, ok := body["_id"].(map[string]interface{}); ok {
+				ids, _ = in["$in"].([]interface{})
+			}
+			found := []Document{}
+			for _, doc := range stored[collection] {
+				if ids == nil || containsValue(ids, doc["_id"]) {
+					found = append(found, doc)
+				}
+			}
+			json.NewEncoder(w).Encode(found)
+
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func containsValue(values []interface{}, v interface{}) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func TestInsertAndPopulateRef(t *testing.T) {
+	srv := refServer(t)
+	defer srv.Close()
+
+	c := NewClient("token", "owner", "repo")
+	c.BaseURL = srv.URL
+	ctx := context.Background()
+
+	authorID, err := c.Insert(ctx, "authors", Document{"name": "Ada"})
+	if err != nil {
+		t.Fatalf("insert author: %v", err)
+	}
+	if _, err := c.Insert(ctx, "posts", Document{
+		"title":  "Notes",
+		"author": Ref("authors", authorID),
+	}); err != nil {
+		t.Fatalf("insert post with a reference: %v", err)
+	}
+
+	posts, err := c.FindPopulated(ctx, "posts", Query{}, "author")
+	if err != nil {
+		t.Fatalf("find populated: %v", err)
+	}
+	if len(posts) != 1 {
+		t.Fatalf("got %d posts, want 1", len(posts))
+	}
+	author, ok := posts[0]["author"].(Document)
+	if !ok {
+		t.Fatalf("author was not populated: %#v", posts[0]["author"])
+	}
+	if author["_id"] != authorID || author["name"] != "Ada" {
+		t.Errorf("populated author = %v, want %s named Ada", author, authorID)
+	}
+}
+
+func TestInsertRejectsDollarKeysBesideRef(t *testing.T) {
+	c := NewClient("token", "owner", "repo")
+	ref := Ref("authors", "a1")
+	ref["$where"] = "1"
+
+	_, err := c.Insert(context.Background(), "posts", Document{"author": ref})
+	var validation *ValidationError
+	if !errors.As(err, &validation) {
+		t.Fatalf("insert = %v, want a ValidationError", err)
+	}
+}
@@ -0,0 +1,136 @@
+package gitdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ProbeOptions configures the Kubernetes probe handlers
+type ProbeOptions struct {
+	// Timeout of each health check, 2 seconds by default
+	Timeout time.Duration
+
+	// CacheTTL is how long a check result is reused, 5 seconds by default,
+	// so frequent probes from many kubelets do not load the server
+	CacheTTL time.Duration
+
+	// FailureGrace is how long checks must keep failing before the
+	// liveness handler reports the pod dead, 1 minute by default. Readiness
+	// fails immediately.
+	FailureGrace time.Duration
+}
+
+// ReadinessHandler returns an http.Handler for a readiness probe. It responds
+// 200 while the server passes health checks and 503 with the error
+// otherwise, taking the pod out of rotation while GitDB is unreachable.
+//
+//	http.Handle("/readyz", client.ReadinessHandler(gitdb.ProbeOptions{}))
+func (c *Client) ReadinessHandler(opts ProbeOptions) http.Handler {
+	p := newProber(c, opts)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := p.check(r.Context())
+		writeProbe(w, err)
+	})
+}
+
+// LivenessHandler returns an http.Handler for a liveness probe. It only
+// fails once health checks have failed continuously for FailureGrace, or
+// after the client is closed, so a brief GitDB outage does not restart every
+// pod at once.
+//
+//	http.Handle("/livez", client.LivenessHandler(gitdb.ProbeOptions{}))
+func (c *Client) LivenessHandler(opts ProbeOptions) http.Handler {
+	p := newProber(c, opts)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failingSince, err := p.check(r.Context())
+		if err != nil && !errors.Is(err, ErrClientClosed) && time.Since(failingSince) < p.opts.FailureGrace {
+			err = nil
+		}
+		writeProbe(w, err)
+	})
+}
+
+func writeProbe(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "gitdb: %v\n", err)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+// prober caches health check results and collapses concurrent checks into
+// one request
+type prober struct {
+	client *Client
+	opts   ProbeOptions
+
+	mu           sync.Mutex
+	checked      time.Time
+	err          error
+	failingSince time.Time
+	inflight     chan struct{}
+}
+
+func newProber(c *Client, opts ProbeOptions) *prober {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 2 * time.Second
+	}
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = 5 * time.Second
+	}
+	if opts.FailureGrace <= 0 {
+		opts.FailureGrace = time.Minute
+	}
+	return &prober{client: c, opts: opts}
+}
+
+// check returns the latest health result and when checks started failing
+func (p *prober) check(ctx context.Context) (time.Time, error) {
+	p.mu.Lock()
+	for {
+		if !p.checked.IsZero() && time.Since(p.checked) < p.opts.CacheTTL {
+			since, err := p.failingSince, p.err
+			p.mu.Unlock()
+			return since, err
+		}
+		if p.inflight == nil {
+			break
+		}
+
+		wait := p.inflight
+		p.mu.Unlock()
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return time.Now(), ctx.Err()
+		}
+		p.mu.Lock()
+	}
+	done := make(chan struct{})
+	p.inflight = done
+	p.mu.Unlock()
+
+	checkCtx, cancel := context.WithTimeout(context.Background(), p.opts.Timeout)
+	err := p.client.Health(checkCtx)
+	cancel()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.checked = time.Now()
+	p.err = err
+	if err == nil {
+		p.failingSince = time.Time{}
+	} else if p.failingSince.IsZero() {
+		p.failingSince = p.checked
+	}
+	p.inflight = nil
+	close(done)
+	return p.failingSince, p.err
+}
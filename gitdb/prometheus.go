@@ -0,0 +1,129 @@
+package gitdb
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// prometheusLabel escapes a label value for the Prometheus text format
+var prometheusLabel = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// MetricsHandler returns an http.Handler that serves the client's metrics
+// in the Prometheus text exposition format, so services without a metrics
+// library can still have GitDB calls scraped:
+//
+//	http.Handle("/metrics", client.MetricsHandler())
+//
+// Every operation is labelled by name; latencies are exported as the
+// gitdb_client_request_duration_seconds histogram. Metrics split by the
+// keys given to WithMetricTags are exported as gitdb_client_tagged_*, with
+// a tag_<key> label per key.
+func (c *Client) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		bw := bufio.NewWriter(w)
+		writePrometheus(bw, c.Metrics())
+		bw.Flush()
+	})
+}
+
+// promSeries is the statistics of one set of labels
+type promSeries struct {
+	labels string
+	stats  OperationStats
+}
+
+// writePrometheus writes a metrics snapshot in the Prometheus text format.
+// Statistics split by metric tags go to gitdb_client_tagged_* families of
+// their own, so summing the untagged ones never counts a request twice.
+func writePrometheus(w *bufio.Writer, m Metrics) {
+	fmt.Fprintf(w, "# HELP gitdb_client_start_time_seconds Time the client started collecting metrics, in seconds since the epoch.\n")
+	fmt.Fprintf(w, "# TYPE gitdb_client_start_time_seconds gauge\n")
+	fmt.Fprintf(w, "gitdb_client_start_time_seconds %d\n", m.Since.Unix())
+
+	names := make([]string, 0, len(m.Operations))
+	for name := range m.Operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	series := make([]promSeries, len(names))
+	for i, name := range names {
+		series[i] = promSeries{labels: promLabels(name, nil), stats: m.Operations[name]}
+	}
+	writePromFamilies(w, "gitdb_client", "", series)
+
+	if len(m.Tagged) > 0 {
+		tagged := make([]promSeries, len(m.Tagged))
+		for i, op := range m.Tagged {
+			tagged[i] = promSeries{labels: promLabels(op.Operation, op.Tags), stats: op.OperationStats}
+		}
+		writePromFamilies(w, "gitdb_client_tagged", " and tags", tagged)
+	}
+}
+
+// writePromFamilies writes the counters and latency histogram of series
+func writePromFamilies(w *bufio.Writer, prefix, by string, series []promSeries) {
+	seconds := func(d time.Duration) string {
+		return strconv.FormatFloat(d.Seconds(), 'g', -1, 64)
+	}
+	header := func(name, kind, help string) {
+		fmt.Fprintf(w, "# HELP %s %s, by operation%s.\n# TYPE %s %s\n", name, help, by, name, kind)
+	}
+	counter := func(name, help string, value func(OperationStats) int64) {
+		name = prefix + name
+		header(name, "counter", help)
+		for _, s := range series {
+			fmt.Fprintf(w, "%s{%s} %d\n", name, s.labels, value(s.stats))
+		}
+	}
+
+	counter("_requests_total", "Requests made",
+		func(s OperationStats) int64 { return s.Requests })
+	counter("_request_errors_total", "Requests that failed in transport or with a 5xx response",
+		func(s OperationStats) int64 { return s.Errors })
+	counter("_request_client_errors_total", "Requests rejected with a 4xx response",
+		func(s OperationStats) int64 { return s.ClientErrors })
+	counter("_request_retries_total", "Requests retried",
+		func(s OperationStats) int64 { return s.Retries })
+
+	duration := prefix + "_request_duration_seconds"
+	header(duration, "histogram", "Request latency")
+	for _, s := range series {
+		for i, bound := range latencyBuckets {
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", duration, s.labels, seconds(bound), s.stats.Buckets[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", duration, s.labels, s.stats.Requests)
+		fmt.Fprintf(w, "%s_sum{%s} %s\n", duration, s.labels, seconds(s.stats.Sum))
+		fmt.Fprintf(w, "%s_count{%s} %d\n", duration, s.labels, s.stats.Requests)
+	}
+}
+
+// promLabels formats the labels of an operation and its tags. Tag keys are
+// prefixed with "tag_" and characters not allowed in label names replaced
+// with underscores.
+func promLabels(operation string, tags map[string]string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "operation=\"%s\"", prometheusLabel.Replace(operation))
+
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		name := strings.Map(func(r rune) rune {
+			if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+				return r
+			}
+			return '_'
+		}, key)
+		fmt.Fprintf(&b, ",tag_%s=\"%s\"", name, prometheusLabel.Replace(tags[key]))
+	}
+	return b.String()
+}
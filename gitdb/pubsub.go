@@ -0,0 +1,150 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// offsetsCollection stores the committed offset of each consumer group
+const offsetsCollection = "_offsets"
+
+// topicGapTimeout is how long a consumer waits for a missing offset before
+// assuming its publisher died between reserving and writing the message
+const topicGapTimeout = 30 * time.Second
+
+// Message is a message published to a topic
+type Message struct {
+	Topic       string
+	Offset      int64
+	Payload     Document
+	PublishedAt time.Time
+}
+
+// Subscription reads a topic on behalf of a consumer group. Delivery is
+// at-least-once: messages are returned by Poll until their offset is
+// committed, so a consumer that crashes before committing sees them again.
+type Subscription struct {
+	client *Client
+	topic  string
+	group  string
+}
+
+// Publish appends a payload to a topic and returns its offset
+func (c *Client) Publish(ctx context.Context, topic string, payload Document) (int64, error) {
+	collection := topicCollection(topic)
+
+	offset, err := c.NextSequence(ctx, collection)
+	if err != nil {
+		return 0, fmt.Errorf("failed to publish to %s: %w", topic, err)
+	}
+
+	_, err = c.Insert(ctx, collection, Document{
+		"_id":         fmt.Sprintf("%020d", offset),
+		"offset":      offset,
+		"payload":     payload,
+		"publishedAt": time.Now().UnixMilli(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to publish to %s: %w", topic, err)
+	}
+
+	return offset, nil
+}
+
+// Subscribe returns a subscription to topic for a consumer group. Consumers
+// sharing a group share its committed offset.
+func (c *Client) Subscribe(topic, group string) *Subscription {
+	return &Subscription{client: c, topic: topic, group: group}
+}
+
+// Poll returns up to max uncommitted messages in offset order. It stops at a
+// missing offset until topicGapTimeout has passed, so a message whose
+// publisher is still writing is not skipped.
+func (s *Subscription) Poll(ctx context.Context, max int) ([]Message, error) {
+	committed, err := s.Committed(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	documents, err := s.client.Find(ctx, topicCollection(s.topic), Query{"offset": Query{"$gt": committed}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to poll %s: %w", s.topic, err)
+	}
+
+	messages := make([]Message, 0, len(documents))
+	for _, doc := range documents {
+		offset, _ := doc["offset"].(float64)
+		publishedAt, _ := doc["publishedAt"].(float64)
+		payload, _ := asMap(doc["payload"])
+		messages = append(messages, Message{
+			Topic:       s.topic,
+			Offset:      int64(offset),
+			Payload:     Document(payload),
+			PublishedAt: time.UnixMilli(int64(publishedAt)),
+		})
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].Offset < messages[j].Offset })
+
+	next := committed + 1
+	for i, msg := range messages {
+		if msg.Offset != next && time.Since(msg.PublishedAt) < topicGapTimeout {
+			messages = messages[:i]
+			break
+		}
+		next = msg.Offset + 1
+	}
+
+	if max > 0 && len(messages) > max {
+		messages = messages[:max]
+	}
+
+	return messages, nil
+}
+
+// Commit records that the group has processed every message up to and
+// including msg
+func (s *Subscription) Commit(ctx context.Context, msg Message) error {
+	id := s.offsetID()
+
+	updated, err := s.client.UpdateMany(ctx, offsetsCollection,
+		Query{"_id": id},
+		Update{"$set": Document{"offset": msg.Offset}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to commit offset: %w", err)
+	}
+	if updated.MatchedCount > 0 {
+		return nil
+	}
+
+	if _, err := s.client.Insert(ctx, offsetsCollection, Document{"_id": id, "offset": msg.Offset}); err != nil {
+		return fmt.Errorf("failed to commit offset: %w", err)
+	}
+	return nil
+}
+
+// Committed returns the group's last committed offset, or 0 if it has not
+// committed yet
+func (s *Subscription) Committed(ctx context.Context) (int64, error) {
+	documents, err := s.client.Find(ctx, offsetsCollection, Query{"_id": s.offsetID()})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read committed offset: %w", err)
+	}
+	if len(documents) == 0 {
+		return 0, nil
+	}
+
+	offset, _ := documents[0]["offset"].(float64)
+	return int64(offset), nil
+}
+
+func (s *Subscription) offsetID() string {
+	return s.topic + ":" + s.group
+}
+
+// topicCollection returns the collection holding a topic's messages
+func topicCollection(topic string) string {
+	return "_topic-" + topic
+}
@@ -0,0 +1,175 @@
+package gitdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Param is a placeholder in a query template's values, replaced by the
+// parameter of that name when the query is run
+type Param string
+
+// QueryTemplate is a query registered under a name so that it is defined
+// once and run by name. Any value in Query, including elements of $in
+// lists and $and clauses, may be a Param; field names and operators cannot
+// be.
+type QueryTemplate struct {
+	Collection string
+	Query      Query
+	Options    FindOptions
+}
+
+// registeredQuery is a validated template with the names of its parameters
+type registeredQuery struct {
+	template QueryTemplate
+	params   map[string]bool
+}
+
+// RegisterQuery registers a query template under name, replacing any
+// registered before. Templates are kept by the client and shared with the
+// clients derived from it; each runs them against its own namespace.
+func (c *Client) RegisterQuery(name string, tmpl QueryTemplate) error {
+	if name == "" {
+		return &ValidationError{Kind: "query name", Name: name, Reason: "must not be empty"}
+	}
+	if _, err := c.collectionName(tmpl.Collection); err != nil {
+		return err
+	}
+	if _, err := c.encodeFindOptions(tmpl.Collection, tmpl.Options); err != nil {
+		return err
+	}
+
+	params := make(map[string]bool)
+	query, _ := substituteParams(map[string]interface{}(tmpl.Query), func(p Param) interface{} {
+		params[string(p)] = true
+		return p
+	}).(map[string]interface{})
+	tmpl.Query = Query(query)
+
+	r := c.registry()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queries[name] = registeredQuery{template: tmpl, params: params}
+	return nil
+}
+
+// UnregisterQuery removes the query template registered under name
+func (c *Client) UnregisterQuery(name string) {
+	r := c.registry()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.queries, name)
+}
+
+// RunQuery runs the query template registered under name with params
+// substituted for its placeholders. Every parameter the template uses must
+// be given and no others may be. Parameter values must be scalars or
+// arrays of scalars, so they can never add operators to the query.
+func (c *Client) RunQuery(ctx context.Context, name string, params map[string]interface{}) ([]Document, error) {
+	registered, ok := c.registeredQuery(name)
+	if !ok {
+		return nil, fmt.Errorf("failed to run query: query %q is not registered", name)
+	}
+
+	values := make(map[string]interface{}, len(params))
+	for _, param := range sortedKeys(params) {
+		if !registered.params[param] {
+			return nil, &ValidationError{Kind: "parameter", Name: param, Reason: fmt.Sprintf("not used by query %q", name)}
+		}
+		value, err := paramValue(param, params[param])
+		if err != nil {
+			return nil, err
+		}
+		values[param] = value
+	}
+	for _, param := range sortedKeys(registered.params) {
+		if _, ok := values[param]; !ok {
+			return nil, &ValidationError{Kind: "parameter", Name: param, Reason: fmt.Sprintf("required by query %q", name)}
+		}
+	}
+
+	tmpl := registered.template
+	query, _ := substituteParams(map[string]interface{}(tmpl.Query), func(p Param) interface{} {
+		return values[string(p)]
+	}).(map[string]interface{})
+	return c.FindWithOptions(ctx, tmpl.Collection, Query(query), tmpl.Options)
+}
+
+func (c *Client) registeredQuery(name string) (registeredQuery, bool) {
+	if c.collections == nil {
+		return registeredQuery{}, false
+	}
+	c.collections.mu.RLock()
+	defer c.collections.mu.RUnlock()
+	q, ok := c.collections.queries[name]
+	return q, ok
+}
+
+// substituteParams copies a query value, replacing each Param with the
+// result of fn
+func substituteParams(v interface{}, fn func(Param) interface{}) interface{} {
+	if m, ok := asMap(v); ok {
+		if m == nil {
+			return nil
+		}
+		copied := make(map[string]interface{}, len(m))
+		for key, value := range m {
+			copied[key] = substituteParams(value, fn)
+		}
+		return copied
+	}
+
+	switch v := v.(type) {
+	case Param:
+		return fn(v)
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, value := range v {
+			copied[i] = substituteParams(value, fn)
+		}
+		return copied
+	case []map[string]interface{}:
+		copied := make([]interface{}, len(v))
+		for i, value := range v {
+			copied[i] = substituteParams(value, fn)
+		}
+		return copied
+	}
+	return v
+}
+
+// paramValue returns a parameter value in its JSON form, rejecting objects
+// so that a parameter cannot smuggle operators into a query
+func paramValue(name string, v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, &ValidationError{Kind: "parameter", Name: name, Reason: err.Error()}
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, &ValidationError{Kind: "parameter", Name: name, Reason: err.Error()}
+	}
+
+	elems, isArray := value.([]interface{})
+	if !isArray {
+		elems = []interface{}{value}
+	}
+	for _, elem := range elems {
+		switch elem.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, &ValidationError{Kind: "parameter", Name: name, Reason: "must be a scalar or an array of scalars"}
+		}
+	}
+	return value, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,122 @@
+package gitdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrQueueEmpty is returned by Claim when no job is ready
+	ErrQueueEmpty = errors.New("no job ready in queue")
+
+	// ErrJobLost is returned by Ack and Nack when the job's visibility
+	// timeout expired and it was claimed again by another worker
+	ErrJobLost = errors.New("job claim was lost")
+)
+
+// Queue is a lightweight job queue stored in a collection. Jobs are claimed
+// with a visibility timeout: a job that is neither acked nor nacked in time
+// becomes visible again, giving at-least-once processing.
+type Queue struct {
+	client     *Client
+	collection string
+}
+
+// Job is a claimed queue entry
+type Job struct {
+	ID       string
+	Payload  Document
+	Attempts int
+
+	token string
+}
+
+// Queue returns a job queue backed by the given collection
+func (c *Client) Queue(collection string) *Queue {
+	return &Queue{client: c, collection: collection}
+}
+
+// Enqueue adds a job and returns its ID
+func (q *Queue) Enqueue(ctx context.Context, payload Document) (string, error) {
+	now := time.Now().UnixMilli()
+	return q.client.Insert(ctx, q.collection, Document{
+		"payload":    payload,
+		"visibleAt":  now,
+		"enqueuedAt": now,
+		"attempts":   0,
+		"claimToken": "",
+	})
+}
+
+// Claim takes the oldest ready job and hides it from other workers for
+// visibility. It returns ErrQueueEmpty if no job is ready.
+//
+// Only the oldest ready job is read, and it is claimed with
+// FindOneAndUpdate, whose update is conditioned on the state the job was
+// read in, so concurrent workers never claim the same job twice.
+func (q *Queue) Claim(ctx context.Context, visibility time.Duration) (*Job, error) {
+	if visibility <= 0 {
+		return nil, fmt.Errorf("visibility timeout must be positive")
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	claimed, err := q.client.FindOneAndUpdate(ctx, q.collection,
+		Query{"visibleAt": Query{"$lte": now.UnixMilli()}},
+		Update{
+			"$set": Document{
+				"visibleAt":  now.Add(visibility).UnixMilli(),
+				"claimToken": token,
+			},
+			"$inc": Document{"attempts": 1},
+		},
+		FindOneAndOptions{Sort: []SortField{Asc("enqueuedAt")}, ReturnDocument: ReturnBefore},
+	)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrQueueEmpty
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+
+	id, _ := claimed["_id"].(string)
+	attempts, _ := claimed["attempts"].(float64)
+	payload, _ := asMap(claimed["payload"])
+	return &Job{ID: id, Payload: Document(payload), Attempts: int(attempts) + 1, token: token}, nil
+}
+
+// Ack marks a job as done and removes it from the queue
+func (q *Queue) Ack(ctx context.Context, job *Job) error {
+	deleted, err := q.client.DeleteMany(ctx, q.collection, Query{"_id": job.ID, "claimToken": job.token})
+	if err != nil {
+		return fmt.Errorf("failed to ack job %s: %w", job.ID, err)
+	}
+	if deleted == 0 {
+		return ErrJobLost
+	}
+	return nil
+}
+
+// Nack releases a job so it becomes visible again after delay
+func (q *Queue) Nack(ctx context.Context, job *Job, delay time.Duration) error {
+	requeued, err := q.client.UpdateMany(ctx, q.collection,
+		Query{"_id": job.ID, "claimToken": job.token},
+		Update{"$set": Document{
+			"visibleAt":  time.Now().Add(delay).UnixMilli(),
+			"claimToken": "",
+		}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to nack job %s: %w", job.ID, err)
+	}
+	if requeued.MatchedCount == 0 {
+		return ErrJobLost
+	}
+	return nil
+}
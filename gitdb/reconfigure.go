@@ -0,0 +1,201 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Config holds the client tunables that can be changed at runtime with
+// Reconfigure. The zero value of each field leaves the feature off.
+type Config struct {
+	// Timeout bounds each request, including reading its response, on top
+	// of HTTPClient's own timeout
+	Timeout time.Duration
+
+	// ReadEndpoints and LoadBalancing are as for SetReadEndpoints
+	ReadEndpoints []string
+	LoadBalancing LoadBalancing
+
+	// RetryPolicy and RetryBudget are as for SetRetryPolicy and
+	// SetRetryBudget
+	RetryPolicy RetryPolicy
+	RetryBudget *RetryBudget
+
+	// Hedging is as for SetHedging
+	Hedging *HedgePolicy
+
+	// RateLimit caps the requests per second the client and every client
+	// derived from it start; requests over the limit wait for their turn.
+	// RateBurst is how many requests may start at once, the rate rounded up
+	// by default.
+	RateLimit float64
+	RateBurst int
+}
+
+// Config returns the client's current tunables, for modifying and passing
+// to Reconfigure
+func (c *Client) Config() Config {
+	state := c.shared()
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	cfg := Config{
+		Timeout:     state.timeout,
+		RetryPolicy: state.retryPolicy,
+		RetryBudget: state.retryBudget,
+		Hedging:     state.hedge,
+	}
+	if pool := state.readEndpoints; pool != nil {
+		cfg.ReadEndpoints = pool.bases()
+		cfg.LoadBalancing = pool.strategy
+	}
+	if limiter := state.rateLimit; limiter != nil {
+		cfg.RateLimit = limiter.rate
+		cfg.RateBurst = int(limiter.burst)
+	}
+	return cfg
+}
+
+// Reconfigure replaces the tunables of the client and every client derived
+// from it, so operators can adjust timeouts, endpoints, rate limits and
+// retries without redeploying. cfg is validated first and applied
+// atomically: either every setting changes or, on error, none does.
+// In-flight requests finish with the settings they started with.
+//
+// Reconfigure replaces all the settings in Config; start from Config() to
+// change only some of them. Read endpoints that are kept keep their latency
+// statistics and health.
+func (c *Client) Reconfigure(cfg Config) error {
+	if cfg.Timeout < 0 {
+		return fmt.Errorf("invalid timeout %v", cfg.Timeout)
+	}
+	if cfg.RetryBudget != nil && (cfg.RetryBudget.Ratio < 0 || cfg.RetryBudget.MinPerSecond < 0) {
+		return fmt.Errorf("invalid retry budget: ratio and minimum must not be negative")
+	}
+	if cfg.Hedging != nil && (cfg.Hedging.Delay < 0 || cfg.Hedging.MinDelay < 0) {
+		return fmt.Errorf("invalid hedge policy: delays must not be negative")
+	}
+	if cfg.RateLimit < 0 || math.IsNaN(cfg.RateLimit) || math.IsInf(cfg.RateLimit, 0) {
+		return fmt.Errorf("invalid rate limit %v", cfg.RateLimit)
+	}
+	if cfg.RateBurst < 0 {
+		return fmt.Errorf("invalid rate burst %d", cfg.RateBurst)
+	}
+	pool, err := newEndpointPool(cfg.LoadBalancing, cfg.ReadEndpoints)
+	if err != nil {
+		return err
+	}
+
+	state := c.shared()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if current := state.readEndpoints; current != nil && pool != nil && current.strategy == pool.strategy && equalStrings(current.bases(), pool.bases()) {
+		pool = current
+	}
+
+	var limiter *rateLimiter
+	if cfg.RateLimit > 0 {
+		limiter = newRateLimiter(cfg.RateLimit, cfg.RateBurst)
+		if current := state.rateLimit; current != nil && current.rate == limiter.rate && current.burst == limiter.burst {
+			limiter = current
+		}
+	}
+
+	state.timeout = cfg.Timeout
+	state.readEndpoints = pool
+	state.retryPolicy = cfg.RetryPolicy
+	state.retryBudget = cfg.RetryBudget
+	state.hedge = cfg.Hedging
+	state.rateLimit = limiter
+	return nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// requestTimeout binds req to the configured timeout. The returned cancel
+// func releases it and must be called once the response is done with.
+func (c *Client) requestTimeout(req *http.Request) (*http.Request, context.CancelFunc) {
+	state := c.shared()
+	state.mu.RLock()
+	timeout := state.timeout
+	state.mu.RUnlock()
+
+	if timeout <= 0 {
+		return req, func() {}
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	return req.WithContext(ctx), cancel
+}
+
+// waitRateLimit waits until the rate limit lets req start
+func (c *Client) waitRateLimit(req *http.Request) error {
+	state := c.shared()
+	state.mu.RLock()
+	limiter := state.rateLimit
+	state.mu.RUnlock()
+
+	if limiter == nil {
+		return nil
+	}
+	return limiter.wait(req.Context())
+}
+
+// rateLimiter is a token bucket
+type rateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst <= 0 {
+		burst = int(math.Ceil(rate))
+	}
+	return &rateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait takes a token, waiting for one to accumulate if needed
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give the reserved token back
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,39 @@
+package gitdb
+
+import "sync"
+
+// collectionRegistry holds per-collection client-side settings and query
+// templates. It is shared by a client and every client derived from it, and
+// keyed by the collection name as the application sees it, before any
+// namespace prefix is applied.
+type collectionRegistry struct {
+	mu         sync.RWMutex
+	aliases    map[string]FieldAliases
+	relations  map[string][]Relation
+	computed   map[string][]computedField
+	options    map[string]*CollectionOptions
+	documents  map[string]map[string]cachedDocument
+	queries    map[string]registeredQuery
+	migrations map[string]map[int]MigrateFunc
+}
+
+func newCollectionRegistry() *collectionRegistry {
+	return &collectionRegistry{
+		aliases:    make(map[string]FieldAliases),
+		relations:  make(map[string][]Relation),
+		computed:   make(map[string][]computedField),
+		options:    make(map[string]*CollectionOptions),
+		documents:  make(map[string]map[string]cachedDocument),
+		queries:    make(map[string]registeredQuery),
+		migrations: make(map[string]map[int]MigrateFunc),
+	}
+}
+
+// registry returns the client's collection registry, creating it for
+// clients that were not built with NewClient
+func (c *Client) registry() *collectionRegistry {
+	if c.collections == nil {
+		c.collections = newCollectionRegistry()
+	}
+	return c.collections
+}
@@ -0,0 +1,272 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+)
+
+// RelationKind identifies the direction of a relation
+type RelationKind int
+
+const (
+	// HasManyRelation links a document to the documents of another
+	// collection whose foreign key holds its ID
+	HasManyRelation RelationKind = iota
+
+	// BelongsToRelation links a document to the document of another
+	// collection whose ID its foreign key holds
+	BelongsToRelation
+)
+
+// DeleteAction controls what happens to related documents when a document
+// with a HasMany relation is deleted
+type DeleteAction int
+
+const (
+	// NoAction leaves related documents untouched
+	NoAction DeleteAction = iota
+
+	// Cascade deletes related documents, following their own relations
+	Cascade
+
+	// SetNull clears the foreign key of related documents
+	SetNull
+
+	// Restrict refuses to delete a document that still has related documents
+	Restrict
+)
+
+// Relation describes a named link between two collections
+type Relation struct {
+	Name       string
+	Kind       RelationKind
+	Collection string
+	Target     string
+	ForeignKey string
+	OnDelete   DeleteAction
+}
+
+// HasMany declares that documents of collection have many documents in
+// target, linked by target's foreignKey field holding the parent's _id.
+// onDelete is applied by Delete and DeleteMany on collection.
+func (c *Client) HasMany(collection, name, target, foreignKey string, onDelete DeleteAction) {
+	c.addRelation(Relation{
+		Name:       name,
+		Kind:       HasManyRelation,
+		Collection: collection,
+		Target:     target,
+		ForeignKey: foreignKey,
+		OnDelete:   onDelete,
+	})
+}
+
+// BelongsTo declares that documents of collection reference one document in
+// target through their foreignKey field
+func (c *Client) BelongsTo(collection, name, target, foreignKey string) {
+	c.addRelation(Relation{
+		Name:       name,
+		Kind:       BelongsToRelation,
+		Collection: collection,
+		Target:     target,
+		ForeignKey: foreignKey,
+	})
+}
+
+// Relations returns the relations declared on a collection
+func (c *Client) Relations(collection string) []Relation {
+	if c.collections == nil {
+		return nil
+	}
+	c.collections.mu.RLock()
+	defer c.collections.mu.RUnlock()
+	return append([]Relation(nil), c.collections.relations[collection]...)
+}
+
+// Related lazily loads the documents related to doc through the named
+// relation. A BelongsTo relation yields at most one document.
+func (c *Client) Related(ctx context.Context, collection string, doc Document, relation string) ([]Document, error) {
+	rel, err := c.relation(collection, relation)
+	if err != nil {
+		return nil, err
+	}
+
+	switch rel.Kind {
+	case HasManyRelation:
+		id, ok := doc["_id"].(string)
+		if !ok {
+			return nil, fmt.Errorf("document has no _id to load %s", relation)
+		}
+		return c.Find(ctx, rel.Target, Query{rel.ForeignKey: id})
+	default:
+		id, ok := doc[rel.ForeignKey].(string)
+		if !ok {
+			return nil, nil
+		}
+		return c.Find(ctx, rel.Target, Query{"_id": id})
+	}
+}
+
+// Load eagerly loads the named relations into documents, storing the related
+// documents under the relation name: a []Document for HasMany relations and
+// a Document (or nil) for BelongsTo relations. Each relation costs a single
+// query regardless of the number of documents.
+func (c *Client) Load(ctx context.Context, collection string, documents []Document, relations ...string) error {
+	for _, name := range relations {
+		rel, err := c.relation(collection, name)
+		if err != nil {
+			return err
+		}
+
+		switch rel.Kind {
+		case HasManyRelation:
+			ids := documentIDs(documents)
+			related, err := c.Find(ctx, rel.Target, Query{rel.ForeignKey: Query{"$in": ids}})
+			if err != nil {
+				return fmt.Errorf("failed to load %s: %w", name, err)
+			}
+
+			grouped := make(map[string][]Document)
+			for _, doc := range related {
+				if parent, ok := doc[rel.ForeignKey].(string); ok {
+					grouped[parent] = append(grouped[parent], doc)
+				}
+			}
+			for _, doc := range documents {
+				id, _ := doc["_id"].(string)
+				children := grouped[id]
+				if children == nil {
+					children = []Document{}
+				}
+				doc[name] = children
+			}
+
+		default:
+			var ids []interface{}
+			for _, doc := range documents {
+				if id, ok := doc[rel.ForeignKey].(string); ok {
+					ids = append(ids, id)
+				}
+			}
+
+			byID := make(map[string]Document)
+			if len(ids) > 0 {
+				related, err := c.Find(ctx, rel.Target, Query{"_id": Query{"$in": ids}})
+				if err != nil {
+					return fmt.Errorf("failed to load %s: %w", name, err)
+				}
+				for _, doc := range related {
+					if id, ok := doc["_id"].(string); ok {
+						byID[id] = doc
+					}
+				}
+			}
+			for _, doc := range documents {
+				id, _ := doc[rel.ForeignKey].(string)
+				if parent, ok := byID[id]; ok {
+					doc[name] = parent
+				} else {
+					doc[name] = nil
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Client) addRelation(rel Relation) {
+	r := c.registry()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing := r.relations[rel.Collection]
+	for i, other := range existing {
+		if other.Name == rel.Name {
+			existing[i] = rel
+			return
+		}
+	}
+	r.relations[rel.Collection] = append(existing, rel)
+}
+
+func (c *Client) relation(collection, name string) (Relation, error) {
+	for _, rel := range c.Relations(collection) {
+		if rel.Name == name {
+			return rel, nil
+		}
+	}
+	return Relation{}, fmt.Errorf("no relation %q declared on collection %s", name, collection)
+}
+
+// applyDeleteActions enforces the OnDelete actions of collection's HasMany
+// relations before the documents with the given IDs are deleted. Restrict
+// checks run first so that a refused delete leaves related data untouched;
+// cascades are otherwise not atomic.
+func (c *Client) applyDeleteActions(ctx context.Context, collection string, ids []interface{}) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var actions []Relation
+	for _, rel := range c.Relations(collection) {
+		if rel.Kind == HasManyRelation && rel.OnDelete != NoAction {
+			actions = append(actions, rel)
+		}
+	}
+
+	for _, rel := range actions {
+		if rel.OnDelete != Restrict {
+			continue
+		}
+		count, err := c.Count(ctx, rel.Target, Query{rel.ForeignKey: Query{"$in": ids}})
+		if err != nil {
+			return fmt.Errorf("failed to check %s: %w", rel.Name, err)
+		}
+		if count > 0 {
+			return fmt.Errorf("cannot delete from %s: %d related documents in %s", collection, count, rel.Target)
+		}
+	}
+
+	for _, rel := range actions {
+		filter := Query{rel.ForeignKey: Query{"$in": ids}}
+		switch rel.OnDelete {
+		case Cascade:
+			children, err := c.Find(ctx, rel.Target, filter)
+			if err != nil {
+				return fmt.Errorf("failed to cascade delete to %s: %w", rel.Target, err)
+			}
+			if err := c.applyDeleteActions(ctx, rel.Target, documentIDs(children)); err != nil {
+				return err
+			}
+			if _, err := c.deleteMany(ctx, rel.Target, filter); err != nil {
+				return fmt.Errorf("failed to cascade delete to %s: %w", rel.Target, err)
+			}
+		case SetNull:
+			update := Update{"$set": Document{rel.ForeignKey: nil}}
+			if _, err := c.UpdateMany(ctx, rel.Target, filter, update); err != nil {
+				return fmt.Errorf("failed to clear %s.%s: %w", rel.Target, rel.ForeignKey, err)
+			}
+		}
+	}
+	return nil
+}
+
+// hasDeleteActions reports whether deleting from collection must touch
+// related collections
+func (c *Client) hasDeleteActions(collection string) bool {
+	for _, rel := range c.Relations(collection) {
+		if rel.Kind == HasManyRelation && rel.OnDelete != NoAction {
+			return true
+		}
+	}
+	return false
+}
+
+func documentIDs(documents []Document) []interface{} {
+	ids := make([]interface{}, 0, len(documents))
+	for _, doc := range documents {
+		if id, ok := doc["_id"].(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,300 @@
+package gitdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// readOperations are the operations that only read data, and so are safe to
+// retry even though some of them are sent as POST requests
+var readOperations = map[string]bool{
+	"health check":              true,
+	"list collections":          true,
+	"find documents":            true,
+	"find document":             true,
+	"count documents":           true,
+	"aggregate documents":       true,
+	"traverse documents":        true,
+	"get server status":         true,
+	"get slow queries":          true,
+	"get document history":      true,
+	"get storage usage":         true,
+	"get maintenance status":    true,
+	"list archived collections": true,
+	"list triggers":             true,
+	"get validation rules":      true,
+	"list roles":                true,
+	"get role":                  true,
+	"get principal":             true,
+	"list row filters":          true,
+	"get head commit":           true,
+	"open cursor":               true,
+	"fetch cursor":              true,
+	"get write status":          true,
+	"get changes":               true,
+	"list indexes":              true,
+	"get api versions":          true,
+}
+
+// do sends a request through the client's HTTP client, refusing writes from
+// anonymous clients, routing it to the negotiated API version, signing
+// writes when a signer is configured and every attempt, once routed, when
+// a request signing key is, retrying it according to the client's retry policy,
+// retrying it once with a fresh token after an auth failure, cancelling
+// abandoned queries on the server, applying the configured timeout and rate
+// limit and refusing it once the client is closed. Every attempt is
+// recorded in the client's metrics under the operation name op and reported
+// to its instrumentation.
+func (c *Client) do(op string, req *http.Request) (*http.Response, error) {
+	if c.namespaceErr != nil {
+		return nil, c.namespaceErr
+	}
+	token, err := c.authorize(op, req)
+	if err != nil {
+		return nil, err
+	}
+	c.scopeRequest(req)
+	c.assertPrincipal(req)
+	tagRequest(req)
+	c.sessionRequest(op, req)
+	c.consistencyRequest(op, req)
+	c.maxTimeRequest(op, req)
+	c.writeTokenRequest(op, req)
+	v2, err := c.apiVersionRequest(op, req)
+	if err != nil {
+		return nil, err
+	}
+	opID := c.operationID(op, req)
+	if err := c.sign(op, req); err != nil {
+		return nil, err
+	}
+	c.requestSignatures(op, req)
+
+	state := c.shared()
+	if err := state.lifecycle.begin(); err != nil {
+		return nil, err
+	}
+	req, cancel := c.requestTimeout(req)
+	if err := c.waitRateLimit(req); err != nil {
+		state.lifecycle.end()
+		cancel()
+		return nil, err
+	}
+
+	resp, err := c.doAttempts(op, req)
+	if err != nil && opID != "" && req.Context().Err() != nil {
+		c.cancelOperation(opID)
+	}
+	if err == nil && resp != nil {
+		resp, err = c.retryUnauthorized(op, req, token, resp)
+	}
+	if err == nil && resp != nil {
+		resp, err = c.consistencyResponse(op, req, resp)
+	}
+	if err == nil && resp != nil {
+		resp, err = c.maxTimeResponse(req, resp)
+	}
+	if err != nil || resp == nil {
+		state.lifecycle.end()
+		cancel()
+		return resp, err
+	}
+	c.sessionResponse(op, resp)
+	if v2 {
+		if resp, err = c.apiVersionResponse(resp); err != nil {
+			state.lifecycle.end()
+			cancel()
+			return nil, err
+		}
+	}
+
+	// The request stays in flight until its body has been read and closed
+	resp.Body = &trackedBody{ReadCloser: c.limitBody(resp.Body), lc: state.lifecycle, cancel: cancel}
+	return resp, nil
+}
+
+// doAttempts sends req, retrying it according to the client's retry policy
+func (c *Client) doAttempts(op string, req *http.Request) (*http.Response, error) {
+	state := c.shared()
+	policy, budget := c.retrySettings()
+	retryable := policy != nil && isRetrySafe(op, req)
+	if retryable && budget != nil {
+		budget.deposit()
+	}
+
+	for attempt := 1; ; attempt++ {
+		var (
+			info *RequestInfo
+			resp *http.Response
+			err  error
+		)
+		if delay, hedged := c.hedgeDelay(op, req); hedged {
+			info, resp, err = c.hedgedAttempt(op, req, attempt, delay)
+		} else {
+			info, resp, err = c.attempt(op, req, attempt)
+		}
+		if !retryable {
+			return resp, err
+		}
+
+		delay, retry := policy.ShouldRetry(resp, err, attempt)
+		if !retry || (budget != nil && !budget.withdraw()) {
+			return resp, err
+		}
+
+		next, rewindErr := rewindRequest(req)
+		if rewindErr != nil {
+			return resp, err
+		}
+
+		cause := err
+		if cause == nil {
+			cause = fmt.Errorf("unexpected status: %d", resp.StatusCode)
+		}
+		if instr := c.instrumentation(); instr != nil {
+			instr.OnRetry(info, delay, cause)
+		}
+		state.metrics.retry(op, TagsFromContext(req.Context()))
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		req = next
+	}
+}
+
+// attempt routes, signs and sends a single request attempt
+func (c *Client) attempt(op string, req *http.Request, attempt int) (*RequestInfo, *http.Response, error) {
+	req, ep := c.route(op, req)
+	signed, signErr := c.signRequest(req)
+	if signErr == nil {
+		req = signed
+	}
+
+	instr := c.instrumentation()
+	info := &RequestInfo{
+		Operation: op,
+		Method:    req.Method,
+		URL:       req.URL.String(),
+		Attempt:   attempt,
+		Start:     time.Now(),
+		Request:   req,
+		Tags:      TagsFromContext(req.Context()),
+	}
+	if signErr != nil {
+		return info, nil, signErr
+	}
+	if instr != nil {
+		instr.OnRequestStart(info)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	duration := time.Since(info.Start)
+	c.shared().metrics.record(op, info.Tags, duration, status, err)
+	failed := err != nil || status >= 500
+	if ep != nil {
+		ep.observe(duration, failed)
+	} else if fo := c.failoverState(); fo != nil && failed && req.Context().Err() == nil {
+		fo.check("request to active endpoint failed")
+	}
+	if instr != nil {
+		instr.OnRequestEnd(info, RequestResult{StatusCode: status, Duration: duration, Err: err})
+	}
+
+	return info, resp, err
+}
+
+// isRetrySafe reports whether sending req more than once cannot apply a
+// write twice: it is a read, or a write the server deduplicates by its
+// idempotency key. PUT and DELETE are not enough on their own, since a
+// replayed update can overwrite a newer write and a replayed delete can
+// remove a document recreated in between.
+func isRetrySafe(op string, req *http.Request) bool {
+	return req.Header.Get(IdempotencyKeyHeader) != "" || readOperations[op]
+}
+
+// rewindRequest returns a copy of req with a fresh body for another attempt
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return next, nil
+	}
+	if req.GetBody == nil {
+		return nil, fmt.Errorf("request body cannot be replayed")
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	next.Body = body
+	return next, nil
+}
+
+// doJSON sends a request bound to ctx with an optional JSON body and
+// decodes the JSON response into out when it is non-nil. action describes
+// the operation in error messages, e.g. "traverse documents".
+func (c *Client) doJSON(ctx context.Context, method, url string, in, out interface{}, status int, action string) error {
+	var body io.Reader
+	if in != nil {
+		jsonData, err := CanonicalJSON(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+
+	resp, err := c.do(action, req)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != status {
+		return fmt.Errorf("failed to %s: %w", action, newAPIError(resp))
+	}
+
+	if documents, ok := out.(*[]Document); ok {
+		list, err := c.decodeDocumentList(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		*documents = list
+	} else if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+
+	return nil
+}
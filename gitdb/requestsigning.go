@@ -0,0 +1,312 @@
+package gitdb
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RequestSignatureHeader carries the signature of a request, so that
+// proxies and gateways between the client and the server can check that it
+// was not altered in transit. Its value has the form
+//
+//	keyId="k2",algorithm="hmac-sha256",timestamp="1700000000",signature="..."
+//
+// with the signature base64 encoded.
+const RequestSignatureHeader = "X-GitDB-Request-Signature"
+
+// Request signing algorithms
+const (
+	RequestSigningHMACSHA256 = "hmac-sha256"
+	RequestSigningEd25519    = "ed25519"
+)
+
+// defaultRequestSignatureSkew is how far a request's timestamp may be from
+// the verifier's clock when RequestVerifier.MaxSkew is zero
+const defaultRequestSignatureSkew = 5 * time.Minute
+
+// RequestKey is a key that signs or verifies request signatures. Create one
+// with HMACRequestKey, Ed25519RequestKey or Ed25519VerifyKey.
+type RequestKey struct {
+	// ID names the key in the signature header, so that verifiers holding
+	// several keys during a rotation know which one to check against
+	ID string
+	// Algorithm is RequestSigningHMACSHA256 or RequestSigningEd25519
+	Algorithm string
+
+	secret     []byte
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+}
+
+// HMACRequestKey returns a key that signs and verifies with HMAC-SHA256 and
+// a secret shared between the client and the verifier
+func HMACRequestKey(id string, secret []byte) RequestKey {
+	return RequestKey{ID: id, Algorithm: RequestSigningHMACSHA256, secret: secret}
+}
+
+// Ed25519RequestKey returns a key that signs with an Ed25519 private key
+// and verifies with its public key
+func Ed25519RequestKey(id string, key ed25519.PrivateKey) RequestKey {
+	k := RequestKey{ID: id, Algorithm: RequestSigningEd25519, privateKey: key}
+	if len(key) == ed25519.PrivateKeySize {
+		k.publicKey = key.Public().(ed25519.PublicKey)
+	}
+	return k
+}
+
+// Ed25519VerifyKey returns a key that only verifies, for verifiers that
+// hold the client's public key
+func Ed25519VerifyKey(id string, key ed25519.PublicKey) RequestKey {
+	return RequestKey{ID: id, Algorithm: RequestSigningEd25519, publicKey: key}
+}
+
+// WithRequestSigning signs every request with key. The signed payload is
+// the one WithSigner signs with the headers that scope the request added
+// before the body,
+//
+//	METHOD SP request-URI LF timestamp LF
+//	x-gitdb-owner: value LF
+//	x-gitdb-repo: value LF
+//	x-gitdb-branch: value LF
+//	x-gitdb-principal: value LF
+//	x-gitdb-tags: value LF
+//	body
+//
+// where a header the request does not carry has an empty value, so that a
+// request cannot be moved to another repository, branch or principal
+// without breaking the signature. The signature is sent in the X-GitDB-Request-Signature header. Unlike
+// WithSigner, which authorizes writes for the server's commit history, this
+// covers reads as well and is meant to be checked in transit, by a
+// RequestVerifier or an equivalent. Each attempt is signed after it has
+// been routed, so the request-URI signed is the one sent, including the
+// path of a read or failover endpoint, and retries and hedged requests
+// carry a fresh timestamp.
+func WithRequestSigning(key RequestKey) Option {
+	return func(o *options) {
+		o.requestKey = &key
+	}
+}
+
+// SetRequestSigningKey replaces the key requests are signed with, for
+// example during a scheduled key rotation; verifiers should accept both the
+// old and the new key until clients have switched. It applies to every
+// client derived from this one. Passing the zero RequestKey stops signing.
+func (c *Client) SetRequestSigningKey(key RequestKey) error {
+	var k *RequestKey
+	if key.ID != "" || key.Algorithm != "" {
+		if err := key.validate(true); err != nil {
+			return err
+		}
+		k = &key
+	}
+
+	state := c.shared()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.requestKey = k
+	return nil
+}
+
+// validate checks that the key can sign, or verify when forSigning is
+// false
+func (k RequestKey) validate(forSigning bool) error {
+	if k.ID == "" || strings.ContainsAny(k.ID, "\",") {
+		return &ValidationError{Kind: "request key", Name: k.ID, Reason: "ID must be non-empty and must not contain '\"' or ','"}
+	}
+	switch k.Algorithm {
+	case RequestSigningHMACSHA256:
+		if len(k.secret) == 0 {
+			return &ValidationError{Kind: "request key", Name: k.ID, Reason: "secret must not be empty"}
+		}
+	case RequestSigningEd25519:
+		if forSigning && len(k.privateKey) != ed25519.PrivateKeySize {
+			return &ValidationError{Kind: "request key", Name: k.ID, Reason: "private key is required to sign"}
+		}
+		if !forSigning && len(k.publicKey) != ed25519.PublicKeySize {
+			return &ValidationError{Kind: "request key", Name: k.ID, Reason: "invalid public key"}
+		}
+	default:
+		return &ValidationError{Kind: "request key", Name: k.ID, Reason: fmt.Sprintf("unknown algorithm %q", k.Algorithm)}
+	}
+	return nil
+}
+
+func (k RequestKey) sign(payload []byte) []byte {
+	if k.Algorithm == RequestSigningEd25519 {
+		return ed25519.Sign(k.privateKey, payload)
+	}
+	mac := hmac.New(sha256.New, k.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func (k RequestKey) verify(payload, signature []byte) bool {
+	if k.Algorithm == RequestSigningEd25519 {
+		return ed25519.Verify(k.publicKey, payload, signature)
+	}
+	return hmac.Equal(k.sign(payload), signature)
+}
+
+// signRequest returns a copy of req carrying the request signature header
+// when request signing is configured, and req itself otherwise. It signs
+// each attempt once it has been routed, so the copy keeps attempts that
+// share headers, such as hedged ones, apart.
+func (c *Client) signRequest(req *http.Request) (*http.Request, error) {
+	state := c.shared()
+	state.mu.RLock()
+	key := state.requestKey
+	state.mu.RUnlock()
+	if key == nil {
+		return req, nil
+	}
+	if err := key.validate(true); err != nil {
+		return nil, fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	body, err := requestBody(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	signature := key.sign(requestSignaturePayload(req.Method, req.URL.RequestURI(), timestamp, req.Header, body))
+	signed := req.Clone(req.Context())
+	signed.Header.Set(RequestSignatureHeader, fmt.Sprintf(`keyId=%q,algorithm=%q,timestamp="%d",signature=%q`,
+		key.ID, key.Algorithm, timestamp, base64.StdEncoding.EncodeToString(signature)))
+	return signed, nil
+}
+
+// requestSignedHeaders are the headers a request signature covers, in the
+// order they are signed
+var requestSignedHeaders = []string{OwnerHeader, RepoHeader, BranchHeader, PrincipalHeader, TagsHeader}
+
+// requestSignaturePayload builds the bytes a request signature covers
+func requestSignaturePayload(method, uri string, timestamp int64, header http.Header, body []byte) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s\n%d\n", method, uri, timestamp)
+	for _, name := range requestSignedHeaders {
+		fmt.Fprintf(&buf, "%s: %s\n", strings.ToLower(name), header.Get(name))
+	}
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+// requestBody returns a copy of the body of req without consuming it
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.GetBody == nil {
+		return nil, nil
+	}
+	rc, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// RequestVerifier checks the signatures of requests sent by clients
+// configured with WithRequestSigning. It is meant for proxies and gateways
+// written in Go that sit between clients and the server.
+type RequestVerifier struct {
+	// Keys are the keys accepted, matched by ID. During a rotation list
+	// both the old and the new key.
+	Keys []RequestKey
+	// MaxSkew is how far a request's timestamp may be from the verifier's
+	// clock, limiting how long a captured request can be replayed; five
+	// minutes by default
+	MaxSkew time.Duration
+}
+
+// Verify checks the signature of r and returns the ID of the key that
+// signed it. The body of r is read and replaced, so r can still be
+// forwarded.
+func (v *RequestVerifier) Verify(r *http.Request) (string, error) {
+	params, err := parseRequestSignature(r.Header.Get(RequestSignatureHeader))
+	if err != nil {
+		return "", err
+	}
+
+	var key *RequestKey
+	for i := range v.Keys {
+		if v.Keys[i].ID == params["keyId"] {
+			key = &v.Keys[i]
+			break
+		}
+	}
+	if key == nil {
+		return "", fmt.Errorf("invalid request signature: unknown key %q", params["keyId"])
+	}
+	if key.Algorithm != params["algorithm"] {
+		return "", fmt.Errorf("invalid request signature: key %q does not use %q", key.ID, params["algorithm"])
+	}
+	if err := key.validate(false); err != nil {
+		return "", err
+	}
+
+	timestamp, err := strconv.ParseInt(params["timestamp"], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid request signature: bad timestamp %q", params["timestamp"])
+	}
+	skew := v.MaxSkew
+	if skew <= 0 {
+		skew = defaultRequestSignatureSkew
+	}
+	if age := time.Since(time.Unix(timestamp, 0)); age > skew || age < -skew {
+		return "", fmt.Errorf("invalid request signature: timestamp is %v from now", age.Round(time.Second))
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil {
+		return "", fmt.Errorf("invalid request signature: %w", err)
+	}
+
+	var body []byte
+	if r.Body != nil {
+		body, err = io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to read request body: %w", err)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if !key.verify(requestSignaturePayload(r.Method, r.URL.RequestURI(), timestamp, r.Header, body), signature) {
+		return "", fmt.Errorf("invalid request signature: signature does not match")
+	}
+	return key.ID, nil
+}
+
+// parseRequestSignature splits a signature header into its parameters
+func parseRequestSignature(header string) (map[string]string, error) {
+	if header == "" {
+		return nil, fmt.Errorf("invalid request signature: missing %s header", RequestSignatureHeader)
+	}
+
+	params := make(map[string]string)
+	for _, part := range strings.Split(header, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid request signature: malformed parameter %q", part)
+		}
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request signature: malformed parameter %q", part)
+		}
+		params[name] = unquoted
+	}
+	for _, name := range []string{"keyId", "algorithm", "timestamp", "signature"} {
+		if _, ok := params[name]; !ok {
+			return nil, fmt.Errorf("invalid request signature: missing %s", name)
+		}
+	}
+	return params, nil
+}
@@ -0,0 +1,37 @@
+package gitdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestSignatureCoversRoutedURL(t *testing.T) {
+	key := HMACRequestKey("k1", []byte("secret"))
+	verifier := &RequestVerifier{Keys: []RequestKey{key}}
+
+	var paths []string
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if _, err := verifier.Verify(r); err != nil {
+			t.Errorf("%s: %v", r.URL.Path, err)
+		}
+		json.NewEncoder(w).Encode([]Document{})
+	}))
+	defer replica.Close()
+
+	c := NewClient("token", "owner", "repo", WithRequestSigning(key))
+	c.BaseURL = "http://primary.invalid"
+	if err := c.SetReadEndpoints(RoundRobin, replica.URL+"/replica"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Find(context.Background(), "users", Query{}); err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	if len(paths) != 1 || paths[0] != "/replica/api/v1/collections/users/documents/find" {
+		t.Errorf("paths = %v, want the find sent to the replica's path", paths)
+	}
+}
@@ -0,0 +1,201 @@
+package gitdb
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryPolicy decides whether a failed request attempt is retried. resp is
+// nil when err is non-nil. attempt is 1 for the first attempt. The client
+// only consults the policy for requests that are safe to repeat: reads and
+// idempotent methods.
+type RetryPolicy interface {
+	ShouldRetry(resp *http.Response, err error, attempt int) (delay time.Duration, retry bool)
+}
+
+// RetryPolicyFunc adapts a function to the RetryPolicy interface
+type RetryPolicyFunc func(resp *http.Response, err error, attempt int) (time.Duration, bool)
+
+// ShouldRetry calls f(resp, err, attempt)
+func (f RetryPolicyFunc) ShouldRetry(resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	return f(resp, err, attempt)
+}
+
+// RetryBudget caps retries across all requests of a client so that an
+// outage does not turn into a retry storm. Every request earns Ratio retry
+// tokens and every retry spends one; on top of that MinPerSecond retries per
+// second are always allowed. A budget of Ratio 0.1 limits retries to about
+// 10% of traffic.
+type RetryBudget struct {
+	Ratio        float64
+	MinPerSecond float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewRetryBudget creates a retry budget
+func NewRetryBudget(ratio, minPerSecond float64) *RetryBudget {
+	return &RetryBudget{Ratio: ratio, MinPerSecond: minPerSecond}
+}
+
+// maxBudgetTokens bounds how many retries a budget can save up
+const maxBudgetTokens = 100
+
+func (b *RetryBudget) refill() {
+	now := time.Now()
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.MinPerSecond
+	}
+	b.last = now
+	if b.tokens > maxBudgetTokens {
+		b.tokens = maxBudgetTokens
+	}
+}
+
+// deposit records a new request
+func (b *RetryBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	b.tokens += b.Ratio
+	if b.tokens > maxBudgetTokens {
+		b.tokens = maxBudgetTokens
+	}
+}
+
+// withdraw takes a token for a retry, reporting whether one was available
+func (b *RetryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// BackoffPolicy retries failures with exponential backoff and full jitter.
+// Which failures are worth retrying is decided by IsRetryable, so custom
+// server error codes or proxy behaviours can be classified without
+// replacing the backoff logic.
+type BackoffPolicy struct {
+	// MaxAttempts bounds the attempts per request, including the first; 3
+	// by default
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, doubling for each
+	// retry after it up to MaxDelay; 100ms and 5s by default
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// IsRetryable classifies a failed attempt; resp is nil when err is
+	// non-nil. DefaultIsRetryable is used when it is nil.
+	IsRetryable func(err error, resp *http.Response) bool
+}
+
+// ShouldRetry implements RetryPolicy
+func (p *BackoffPolicy) ShouldRetry(resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	maxAttempts := p.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+	if attempt >= maxAttempts {
+		return 0, false
+	}
+	if err == nil && resp != nil && resp.StatusCode < 400 {
+		return 0, false
+	}
+
+	isRetryable := p.IsRetryable
+	if isRetryable == nil {
+		isRetryable = DefaultIsRetryable
+	}
+	if !isRetryable(err, resp) {
+		return 0, false
+	}
+
+	base, max := p.BaseDelay, p.MaxDelay
+	if base <= 0 {
+		base = 100 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 5 * time.Second
+	}
+	delay := base << (attempt - 1)
+	if delay > max || delay <= 0 {
+		delay = max
+	}
+	delay = time.Duration(rand.Int63n(int64(delay) + 1))
+
+	// Wait at least as long as the server asked
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			if after := time.Duration(seconds) * time.Second; after > delay {
+				delay = after
+			}
+		}
+	}
+	return delay, true
+}
+
+// DefaultIsRetryable reports whether a failure is likely transient: network
+// errors other than cancellation, and the 429, 500, 502, 503 and 504
+// statuses
+func DefaultIsRetryable(err error, resp *http.Response) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	if resp == nil {
+		return false
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// WithRetry retries failed requests that are safe to repeat with
+// exponential backoff and jitter: reads, idempotent methods and writes
+// carrying a write token. Each request is attempted at most maxAttempts
+// times, with delays starting at baseDelay and doubling up to maxDelay;
+// zero values take BackoffPolicy's defaults. Network errors and 429, 500,
+// 502, 503 and 504 responses are retried, as DefaultIsRetryable decides; use
+// SetRetryPolicy with a BackoffPolicy to classify failures differently.
+func WithRetry(maxAttempts int, baseDelay, maxDelay time.Duration) Option {
+	return func(o *options) {
+		o.retryPolicy = &BackoffPolicy{MaxAttempts: maxAttempts, BaseDelay: baseDelay, MaxDelay: maxDelay}
+	}
+}
+
+// SetRetryPolicy sets the retry policy of the client and every client
+// derived from it. A nil policy disables retries, which is the default.
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	state := c.shared()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.retryPolicy = policy
+}
+
+// SetRetryBudget limits the retries the client's policy may perform. A nil
+// budget removes the limit.
+func (c *Client) SetRetryBudget(budget *RetryBudget) {
+	state := c.shared()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.retryBudget = budget
+}
+
+func (c *Client) retrySettings() (RetryPolicy, *RetryBudget) {
+	state := c.shared()
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+	return state.retryPolicy, state.retryBudget
+}
@@ -0,0 +1,96 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// PrincipalHeader carries the principal a request acts on behalf of
+const PrincipalHeader = "X-GitDB-Principal"
+
+// RowFilter restricts the documents a role can see in a collection. The
+// server adds Filter to every query the role runs against the collection,
+// so members of the role never see other documents.
+type RowFilter struct {
+	Role       string `json:"role"`
+	Collection string `json:"collection"`
+	Filter     Query  `json:"filter"`
+}
+
+// WithPrincipal returns a copy of the client that asserts it acts on
+// behalf of principal, such as the end user of a service sharing one
+// token. The server applies the principal's roles and row filters to every
+// request instead of the token's own; the token must be allowed to act as
+// other principals.
+func (c *Client) WithPrincipal(principal string) *Client {
+	nc := *c
+	nc.principal = principal
+	return &nc
+}
+
+// Principal returns the principal the client acts on behalf of, if any
+func (c *Client) Principal() string {
+	return c.principal
+}
+
+// assertPrincipal sets the principal header on req
+func (c *Client) assertPrincipal(req *http.Request) {
+	if c.principal != "" {
+		req.Header.Set(PrincipalHeader, c.principal)
+	}
+}
+
+// SetRowFilter makes the server restrict role to the documents of
+// collection matching filter, e.g. Query{"region": "eu"}. It replaces any
+// filter the role already had on the collection.
+func (a *AdminClient) SetRowFilter(ctx context.Context, role, collection string, filter Query) error {
+	if err := ValidateDocumentID(role); err != nil {
+		return err
+	}
+	if err := validateCollectionName(collection); err != nil {
+		return err
+	}
+	if len(filter) == 0 {
+		return fmt.Errorf("row filter must not be empty")
+	}
+	url, err := a.url("/roles/%s/filters/%s", role, collection)
+	if err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{"filter": filter}
+	return a.client.doJSON(ctx, "PUT", url, data, nil, http.StatusOK, "set row filter")
+}
+
+// RowFilters lists the row filters configured for role
+func (a *AdminClient) RowFilters(ctx context.Context, role string) ([]RowFilter, error) {
+	if err := ValidateDocumentID(role); err != nil {
+		return nil, err
+	}
+	url, err := a.url("/roles/%s/filters", role)
+	if err != nil {
+		return nil, err
+	}
+
+	var filters []RowFilter
+	if err := a.client.doJSON(ctx, "GET", url, nil, &filters, http.StatusOK, "list row filters"); err != nil {
+		return nil, err
+	}
+	return filters, nil
+}
+
+// RemoveRowFilter lifts role's row filter on collection
+func (a *AdminClient) RemoveRowFilter(ctx context.Context, role, collection string) error {
+	if err := ValidateDocumentID(role); err != nil {
+		return err
+	}
+	if err := validateCollectionName(collection); err != nil {
+		return err
+	}
+	url, err := a.url("/roles/%s/filters/%s", role, collection)
+	if err != nil {
+		return err
+	}
+	return a.client.doJSON(ctx, "DELETE", url, nil, nil, http.StatusOK, "remove row filter")
+}
@@ -0,0 +1,110 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// FieldRule constrains one field of the documents in a collection
+type FieldRule struct {
+	// Required rejects documents without the field
+	Required bool `json:"required,omitempty"`
+	// Type is one of "string", "number", "boolean", "object", "array" or
+	// "null"; empty allows any type
+	Type string `json:"type,omitempty"`
+	// Enum restricts the field to a fixed set of values
+	Enum []interface{} `json:"enum,omitempty"`
+}
+
+// ValidationRules are the server-enforced constraints on a collection's
+// documents, keyed by field path. Inserts and updates that violate them
+// are rejected by the server.
+type ValidationRules struct {
+	Fields map[string]FieldRule `json:"fields"`
+
+	// Version is incremented by the server on every change
+	Version int `json:"version,omitempty"`
+	// Updated is when the rules were last changed
+	Updated time.Time `json:"updated,omitempty"`
+}
+
+// ruleTypes are the field types the server can check
+var ruleTypes = map[string]bool{
+	"":        true,
+	"string":  true,
+	"number":  true,
+	"boolean": true,
+	"object":  true,
+	"array":   true,
+	"null":    true,
+}
+
+// GetValidationRules returns the validation rules configured for a
+// collection. A collection without rules has an empty Fields map.
+func (c *Client) GetValidationRules(ctx context.Context, collection string) (*ValidationRules, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/rules", c.BaseURL, name)
+
+	var rules ValidationRules
+	if err := c.doJSON(ctx, "GET", url, nil, &rules, http.StatusOK, "get validation rules"); err != nil {
+		return nil, err
+	}
+	rules.Fields = c.aliasRuleFields(collection, rules.Fields)
+	return &rules, nil
+}
+
+// SetValidationRules replaces a collection's validation rules, so schema
+// governance can be kept in code and versioned with it. Field paths may use
+// field aliases. Passing empty Fields removes all rules. The returned rules
+// carry the new version.
+func (c *Client) SetValidationRules(ctx context.Context, collection string, rules ValidationRules) (*ValidationRules, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]FieldRule, len(rules.Fields))
+	for path, rule := range rules.Fields {
+		if err := ValidateFieldPath(path); err != nil {
+			return nil, err
+		}
+		if !ruleTypes[rule.Type] {
+			return nil, fmt.Errorf("unknown type %q in rule for %s", rule.Type, path)
+		}
+		fields[c.storedField(collection, path)] = rule
+	}
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/rules", c.BaseURL, name)
+
+	data := map[string]interface{}{"fields": fields}
+
+	var updated ValidationRules
+	if err := c.doJSON(ctx, "PUT", url, data, &updated, http.StatusOK, "set validation rules"); err != nil {
+		return nil, err
+	}
+	updated.Fields = c.aliasRuleFields(collection, updated.Fields)
+	return &updated, nil
+}
+
+// aliasRuleFields translates the stored field paths of rules to aliases
+func (c *Client) aliasRuleFields(collection string, fields map[string]FieldRule) map[string]FieldRule {
+	aliases := c.fieldAliases(collection)
+	if fields == nil {
+		return map[string]FieldRule{}
+	}
+	if len(aliases) == 0 {
+		return fields
+	}
+
+	renamed := make(map[string]FieldRule, len(fields))
+	for path, rule := range fields {
+		renamed[renamePath(path, aliases)] = rule
+	}
+	return renamed
+}
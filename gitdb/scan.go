@@ -0,0 +1,200 @@
+package gitdb
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// ScanFunc is called by Scan for each document. It may be called from
+// several goroutines at once.
+type ScanFunc func(ctx context.Context, doc Document) error
+
+// ScanOptions tunes Scan
+type ScanOptions struct {
+	// Query restricts the scan to matching documents; nil scans them all
+	Query Query
+	// BatchSize is the number of documents fetched per request (default
+	// 100)
+	BatchSize int
+	// Concurrency is the number of documents processed at once (default 4)
+	Concurrency int
+	// RateLimit caps the documents processed per second; zero is unlimited
+	RateLimit float64
+	// Resume continues a scan from the token of an earlier one, skipping
+	// the documents it had finished
+	Resume string
+	// OnCheckpoint, when set, is called with a resume token each time a
+	// batch has been fully processed. Persist it to continue an
+	// interrupted scan from there.
+	OnCheckpoint func(token string)
+}
+
+// ScanResult describes a finished or interrupted scan
+type ScanResult struct {
+	// Scanned is the number of documents processed successfully
+	Scanned int
+	// Token resumes the scan after the last fully processed batch
+	Token string
+}
+
+// scanToken is the content of a resume token
+type scanToken struct {
+	After string `json:"after"`
+}
+
+// Scan walks the documents of a collection in _id order, calling fn for
+// each with bounded parallelism, such as for backfills and re-indexing
+// jobs. Documents are fetched in batches and a batch is finished before
+// the next one starts, so the resume token only ever points past
+// documents fn has returned from. The first error from fn or the server
+// stops the scan; the result is returned with it, so the scan can be
+// resumed from its Token. Documents inserted behind the scan's position
+// while it runs are not visited.
+func (c *Client) Scan(ctx context.Context, collection string, fn ScanFunc, opts ScanOptions) (*ScanResult, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	var limiter *rateLimiter
+	if opts.RateLimit > 0 {
+		limiter = newRateLimiter(opts.RateLimit, 0)
+	}
+
+	result := &ScanResult{Token: opts.Resume}
+	after, err := decodeScanToken(opts.Resume)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		batch, err := c.FindWithOptions(ctx, collection, scanQuery(opts.Query, after), FindOptions{
+			Sort:  []SortField{Asc("_id")},
+			Limit: opts.BatchSize,
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to scan %s: %w", collection, err)
+		}
+		if len(batch) == 0 {
+			return result, nil
+		}
+
+		scanned, err := scanBatch(ctx, batch, fn, opts.Concurrency, limiter)
+		result.Scanned += scanned
+		if err != nil {
+			return result, fmt.Errorf("failed to scan %s: %w", collection, err)
+		}
+
+		id, ok := batch[len(batch)-1]["_id"].(string)
+		if !ok {
+			return result, fmt.Errorf("failed to scan %s: document without a string _id", collection)
+		}
+		after = id
+		result.Token = encodeScanToken(after)
+		if opts.OnCheckpoint != nil {
+			opts.OnCheckpoint(result.Token)
+		}
+		if len(batch) < opts.BatchSize {
+			return result, nil
+		}
+	}
+}
+
+// scanBatch calls fn for each document of batch, at most concurrency at a
+// time, and returns how many succeeded and the first error
+func scanBatch(ctx context.Context, batch []Document, fn ScanFunc, concurrency int, limiter *rateLimiter) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan Document)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		scanned  int
+		firstErr error
+	)
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for doc := range jobs {
+				err := fn(ctx, doc)
+
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						id, _ := doc["_id"].(string)
+						firstErr = fmt.Errorf("document %s: %w", id, err)
+						cancel()
+					}
+				} else {
+					scanned++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, doc := range batch {
+		if limiter != nil {
+			if err := limiter.wait(ctx); err != nil {
+				break
+			}
+		}
+		select {
+		case jobs <- doc:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	return scanned, firstErr
+}
+
+// scanQuery restricts query to the documents after the _id after
+func scanQuery(query Query, after string) Query {
+	if after == "" {
+		return query
+	}
+	position := Query{"_id": Query{"$gt": after}}
+	if _, ok := query["_id"]; ok {
+		return Query{"$and": []interface{}{query, position}}
+	}
+
+	scoped := make(Query, len(query)+1)
+	for key, value := range query {
+		scoped[key] = value
+	}
+	scoped["_id"] = position["_id"]
+	return scoped
+}
+
+func encodeScanToken(after string) string {
+	data, _ := json.Marshal(scanToken{After: after})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeScanToken(token string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", fmt.Errorf("invalid scan token: %w", err)
+	}
+	var t scanToken
+	if err := json.Unmarshal(data, &t); err != nil {
+		return "", fmt.Errorf("invalid scan token: %w", err)
+	}
+	return t.After, nil
+}
@@ -0,0 +1,56 @@
+package gitdb
+
+import "net/http"
+
+// Headers that scope a request to a repository and branch on servers that
+// host several
+const (
+	OwnerHeader  = "X-GitDB-Owner"
+	RepoHeader   = "X-GitDB-Repo"
+	BranchHeader = "X-GitDB-Branch"
+)
+
+// WithOwner returns a copy of the client scoped to another repository
+// owner. Like the other derived clients it shares the transport, metrics and
+// runtime settings of c, and is the safe alternative to changing Owner on a
+// client used by several goroutines.
+func (c *Client) WithOwner(owner string) *Client {
+	nc := *c
+	nc.Owner = owner
+	return &nc
+}
+
+// WithRepo returns a copy of the client scoped to another repository of the
+// same owner
+func (c *Client) WithRepo(repo string) *Client {
+	nc := *c
+	nc.Repo = repo
+	return &nc
+}
+
+// WithBranch returns a copy of the client that reads and writes another
+// branch of the repository instead of the server's default branch
+func (c *Client) WithBranch(branch string) *Client {
+	nc := *c
+	nc.branch = branch
+	return &nc
+}
+
+// Branch returns the branch the client is scoped to, or "" for the
+// server's default branch
+func (c *Client) Branch() string {
+	return c.branch
+}
+
+// scopeRequest sets the repository scoping headers of req
+func (c *Client) scopeRequest(req *http.Request) {
+	if c.Owner != "" {
+		req.Header.Set(OwnerHeader, c.Owner)
+	}
+	if c.Repo != "" {
+		req.Header.Set(RepoHeader, c.Repo)
+	}
+	if c.branch != "" {
+		req.Header.Set(BranchHeader, c.branch)
+	}
+}
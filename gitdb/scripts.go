@@ -0,0 +1,53 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// RegisterScript uploads a server-evaluated script under name, replacing
+// any previous version. Scripts run multi-step logic in one round trip.
+func (c *Client) RegisterScript(ctx context.Context, name, source string) error {
+	if err := ValidateDocumentID(name); err != nil {
+		return err
+	}
+	if source == "" {
+		return fmt.Errorf("script source must not be empty")
+	}
+
+	url := fmt.Sprintf("%s/api/v1/scripts/%s", c.BaseURL, pathSegment(name))
+
+	data := map[string]string{"source": source}
+	return c.doJSON(ctx, "PUT", url, data, nil, http.StatusOK, "register script")
+}
+
+// CallScript invokes a registered script with args and returns its result
+func (c *Client) CallScript(ctx context.Context, name string, args map[string]interface{}) (interface{}, error) {
+	if err := ValidateDocumentID(name); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/api/v1/scripts/%s/call", c.BaseURL, pathSegment(name))
+
+	data := map[string]interface{}{"args": args}
+
+	var result struct {
+		Result interface{} `json:"result"`
+	}
+	if err := c.doJSON(ctx, "POST", url, data, &result, http.StatusOK, "call script"); err != nil {
+		return nil, err
+	}
+
+	return result.Result, nil
+}
+
+// DeleteScript removes a registered script
+func (c *Client) DeleteScript(ctx context.Context, name string) error {
+	if err := ValidateDocumentID(name); err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/api/v1/scripts/%s", c.BaseURL, pathSegment(name))
+	return c.doJSON(ctx, "DELETE", url, nil, nil, http.StatusOK, "delete script")
+}
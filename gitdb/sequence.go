@@ -0,0 +1,33 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// NextSequence atomically increments the named server-side counter and
+// returns its new value. The first call for a name returns 1. Sequence names
+// follow the collection naming rules and are scoped by the client namespace,
+// which makes them suitable for invoice or ticket numbers.
+func (c *Client) NextSequence(ctx context.Context, name string) (int64, error) {
+	name, err := c.collectionName(name)
+	if err != nil {
+		return 0, err
+	}
+
+	url := fmt.Sprintf("%s/api/v1/sequences/%s/next", c.BaseURL, name)
+
+	var result struct {
+		Value *int64 `json:"value"`
+	}
+	if err := c.doJSON(ctx, "POST", url, nil, &result, http.StatusOK, "advance sequence"); err != nil {
+		return 0, err
+	}
+
+	if result.Value == nil {
+		return 0, fmt.Errorf("no sequence value returned")
+	}
+
+	return *result.Value, nil
+}
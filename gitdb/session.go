@@ -0,0 +1,126 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Headers used by sessions. The server reports the commit a request wrote
+// or read in CommitHeader, holds a read carrying AfterCommitHeader until
+// the serving replica contains that commit, and serves a read carrying
+// SnapshotHeader from exactly that commit.
+const (
+	CommitHeader      = "X-GitDB-Commit"
+	AfterCommitHeader = "X-GitDB-After-Commit"
+	SnapshotHeader    = "X-GitDB-Snapshot"
+)
+
+// Session is a client whose reads are guaranteed to observe its own prior
+// writes, even when reads are load-balanced across replicas that lag
+// behind the primary. It tracks the latest commit it has seen and asks the
+// server to serve each read from a state containing it. Use the embedded
+// Client's methods as usual; a Session is safe for concurrent use.
+type Session struct {
+	*Client
+	state *sessionState
+}
+
+// sessionState is the causal position shared by a session's requests
+type sessionState struct {
+	mu         sync.Mutex
+	lastCommit string
+	snapshot   string
+}
+
+// StartSession starts a causally consistent session on the client
+func (c *Client) StartSession() *Session {
+	state := &sessionState{}
+	nc := *c
+	nc.session = state
+	return &Session{Client: &nc, state: state}
+}
+
+// StartSnapshotSession starts a session whose reads are all pinned to the
+// database's current commit, so a report made of many queries sees one
+// consistent view however the data changes meanwhile. Writes through the
+// session still go to the latest state and are not visible to its reads.
+func (c *Client) StartSnapshotSession(ctx context.Context) (*Session, error) {
+	url := fmt.Sprintf("%s/api/v1/commits/head", c.BaseURL)
+
+	var head struct {
+		Commit string `json:"commit"`
+	}
+	if err := c.doJSON(ctx, "GET", url, nil, &head, http.StatusOK, "get head commit"); err != nil {
+		return nil, err
+	}
+	if head.Commit == "" {
+		return nil, fmt.Errorf("server did not report a head commit")
+	}
+
+	s := c.StartSession()
+	s.state.lastCommit = head.Commit
+	s.state.snapshot = head.Commit
+	return s, nil
+}
+
+// Snapshot returns the commit the session's reads are pinned to, or "" if
+// it is not a snapshot session
+func (s *Session) Snapshot() string {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+	return s.state.snapshot
+}
+
+// LastCommit returns the latest commit the session has observed, or "" if
+// it has not sent any requests yet
+func (s *Session) LastCommit() string {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+	return s.state.lastCommit
+}
+
+// AdvanceTo makes the session's reads observe at least commit, for example
+// a commit written through another session or reported by another service
+func (s *Session) AdvanceTo(commit string) {
+	s.state.observe(commit, true)
+}
+
+// observe records commit as the session's causal position. Commits seen
+// by reads only set the position of a session that has none yet, as a read
+// racing a write of the same session may report an older commit.
+func (st *sessionState) observe(commit string, write bool) {
+	if commit == "" {
+		return
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if write || st.lastCommit == "" {
+		st.lastCommit = commit
+	}
+}
+
+// sessionRequest asks the server to serve reads of a session from its
+// snapshot, or from a state containing the session's latest commit
+func (c *Client) sessionRequest(op string, req *http.Request) {
+	if c.session == nil || !readOperations[op] {
+		return
+	}
+	c.session.mu.Lock()
+	commit, snapshot := c.session.lastCommit, c.session.snapshot
+	c.session.mu.Unlock()
+	if snapshot != "" {
+		req.Header.Set(SnapshotHeader, snapshot)
+	} else if commit != "" {
+		req.Header.Set(AfterCommitHeader, commit)
+	}
+}
+
+// sessionResponse records the commit reported by a successful response
+func (c *Client) sessionResponse(op string, resp *http.Response) {
+	if c.session == nil || resp.StatusCode >= 300 {
+		return
+	}
+	c.session.observe(resp.Header.Get(CommitHeader), !readOperations[op])
+}
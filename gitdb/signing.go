@@ -0,0 +1,146 @@
+package gitdb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signature headers sent with signed writes. The server records the
+// signature, format and key alongside the commit it creates, so the write
+// can be traced back to the key that authorized it.
+const (
+	SignatureHeader          = "X-GitDB-Signature"
+	SignatureFormatHeader    = "X-GitDB-Signature-Format"
+	SignatureKeyHeader       = "X-GitDB-Signature-Key"
+	SignatureTimestampHeader = "X-GitDB-Signature-Timestamp"
+)
+
+// Signer signs the payload of write requests
+type Signer interface {
+	// Format names the signature scheme, such as "openpgp" or "ssh"
+	Format() string
+	// KeyID identifies the signing key to the server
+	KeyID() string
+	// Sign returns a detached signature of payload
+	Sign(payload []byte) ([]byte, error)
+}
+
+// WithSigner signs every write request with signer. The signed payload is
+//
+//	METHOD SP request-URI LF timestamp LF body
+//
+// with the Unix timestamp in seconds, and the signature is sent base64
+// encoded in the X-GitDB-Signature header. Request bodies are canonical
+// JSON, so the server can rebuild the payload byte for byte.
+func WithSigner(signer Signer) Option {
+	return func(o *options) {
+		o.signer = signer
+	}
+}
+
+// GPGSigner signs with a GPG key through the gpg program, the way git signs
+// commits
+type GPGSigner struct {
+	// Key is the key ID, fingerprint or user ID passed to --local-user
+	Key string
+	// Program is the gpg binary, "gpg" by default
+	Program string
+}
+
+// Format returns "openpgp"
+func (s GPGSigner) Format() string { return "openpgp" }
+
+// KeyID returns the configured key
+func (s GPGSigner) KeyID() string { return s.Key }
+
+// Sign produces an armored detached signature
+func (s GPGSigner) Sign(payload []byte) ([]byte, error) {
+	program := s.Program
+	if program == "" {
+		program = "gpg"
+	}
+	return runSigner(program, payload, "--batch", "--yes", "--armor", "--detach-sign", "--local-user", s.Key)
+}
+
+// SSHSigner signs with an SSH key through ssh-keygen -Y sign
+type SSHSigner struct {
+	// KeyFile is the private key, or the public key when the private key
+	// lives in ssh-agent
+	KeyFile string
+	// Namespace scopes the signature, "gitdb" by default
+	Namespace string
+	// Program is the ssh-keygen binary, "ssh-keygen" by default
+	Program string
+}
+
+// Format returns "ssh"
+func (s SSHSigner) Format() string { return "ssh" }
+
+// KeyID returns the key file
+func (s SSHSigner) KeyID() string { return s.KeyFile }
+
+// Sign produces an armored SSH signature
+func (s SSHSigner) Sign(payload []byte) ([]byte, error) {
+	program, namespace := s.Program, s.Namespace
+	if program == "" {
+		program = "ssh-keygen"
+	}
+	if namespace == "" {
+		namespace = "gitdb"
+	}
+	return runSigner(program, payload, "-Y", "sign", "-f", s.KeyFile, "-n", namespace)
+}
+
+func runSigner(program string, payload []byte, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(program, args...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed: %w: %s", program, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// signedPayload builds the bytes a signature covers
+func signedPayload(method, uri string, timestamp int64, body []byte) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s\n%d\n", method, uri, timestamp)
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+// sign adds signature headers to write requests when a signer is configured
+func (c *Client) sign(op string, req *http.Request) error {
+	state := c.shared()
+	state.mu.RLock()
+	signer := state.signer
+	state.mu.RUnlock()
+	if signer == nil || readOperations[op] {
+		return nil
+	}
+
+	body, err := requestBody(req)
+	if err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	signature, err := signer.Sign(signedPayload(req.Method, req.URL.RequestURI(), timestamp, body))
+	if err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	req.Header.Set(SignatureHeader, base64.StdEncoding.EncodeToString(signature))
+	req.Header.Set(SignatureFormatHeader, signer.Format())
+	req.Header.Set(SignatureKeyHeader, signer.KeyID())
+	req.Header.Set(SignatureTimestampHeader, strconv.FormatInt(timestamp, 10))
+	return nil
+}
@@ -0,0 +1,68 @@
+package gitdb
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// clientState holds the runtime state a client shares with every client
+// derived from it, such as the clients returned by WithNamespace
+type clientState struct {
+	metrics   *metrics
+	lifecycle *lifecycle
+
+	// Response limits, fixed when the client is created
+	maxResponseBytes int64
+	maxDocuments     int
+
+	// maxDocumentBytes limits documents written, fixed when the client is
+	// created
+	maxDocumentBytes int
+
+	// maxInValues splits long $in lists, fixed when the client is created
+	maxInValues int
+
+	// api negotiates the API version, nil unless enabled at creation
+	api *apiNegotiation
+
+	// resolver resolves host names the way the client's dialer does, nil
+	// for the system resolver; fixed when the client is created
+	resolver *hostResolver
+
+	mu              sync.RWMutex
+	instrumentation Instrumentation
+	retryPolicy     RetryPolicy
+	retryBudget     *RetryBudget
+	hedge           *HedgePolicy
+	readEndpoints   *endpointPool
+	failover        *failover
+	signer          Signer
+	requestKey      *RequestKey
+	token           string
+	tokenSet        bool
+	authRefresh     func(ctx context.Context) (string, error)
+	consistency     *ConsistencyPolicy
+	lastWrite       map[writeScope]string
+	timeout         time.Duration
+	rateLimit       *rateLimiter
+
+	// refreshMu serialises token refreshes after auth failures
+	refreshMu sync.Mutex
+}
+
+func newClientState() *clientState {
+	return &clientState{
+		metrics:   newMetrics(),
+		lifecycle: newLifecycle(),
+	}
+}
+
+// shared returns the client's shared state, creating it for clients that
+// were not built with NewClient
+func (c *Client) shared() *clientState {
+	if c.state == nil {
+		c.state = newClientState()
+	}
+	return c.state
+}
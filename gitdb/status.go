@@ -0,0 +1,96 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServerStatus is a snapshot of the server's internal state
+type ServerStatus struct {
+	Version           string         `json:"version"`
+	UptimeSeconds     float64        `json:"uptimeSeconds"`
+	QueueDepths       map[string]int `json:"queueDepths"`
+	PendingPushes     int            `json:"pendingPushes"`
+	PushLagMillis     float64        `json:"pushLagMillis"`
+	ActiveConnections int            `json:"activeConnections"`
+	Memory            MemoryStatus   `json:"memory"`
+}
+
+// MemoryStatus describes the server's memory usage in bytes
+type MemoryStatus struct {
+	HeapUsed  int64 `json:"heapUsed"`
+	HeapTotal int64 `json:"heapTotal"`
+	RSS       int64 `json:"rss"`
+}
+
+// SlowQuery is an operation the server recorded as slow
+type SlowQuery struct {
+	Collection     string    `json:"collection"`
+	Operation      string    `json:"operation"`
+	Shape          Query     `json:"shape"`
+	DurationMillis float64   `json:"durationMillis"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// Duration returns how long the operation took
+func (q *SlowQuery) Duration() time.Duration {
+	return time.Duration(q.DurationMillis * float64(time.Millisecond))
+}
+
+// Uptime returns how long the server has been running
+func (s *ServerStatus) Uptime() time.Duration {
+	return time.Duration(s.UptimeSeconds * float64(time.Second))
+}
+
+// PushLag returns how far pushes to the git remote are behind local commits
+func (s *ServerStatus) PushLag() time.Duration {
+	return time.Duration(s.PushLagMillis * float64(time.Millisecond))
+}
+
+// ServerStatus fetches the server's status for monitoring integrations
+func (c *Client) ServerStatus(ctx context.Context) (*ServerStatus, error) {
+	url := fmt.Sprintf("%s/api/v1/status", c.BaseURL)
+
+	var status ServerStatus
+	if err := c.doJSON(ctx, "GET", url, nil, &status, http.StatusOK, "get server status"); err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// SlowQueries returns the operations recorded since the given time that
+// took at least threshold. Query shapes have their values replaced by
+// placeholders on the server. Namespaced clients only see their own
+// collections.
+func (c *Client) SlowQueries(ctx context.Context, since time.Time, threshold time.Duration) ([]SlowQuery, error) {
+	params := url.Values{}
+	params.Set("since", since.UTC().Format(time.RFC3339Nano))
+	params.Set("thresholdMs", strconv.FormatInt(threshold.Milliseconds(), 10))
+
+	url := fmt.Sprintf("%s/api/v1/status/slow-queries?%s", c.BaseURL, params.Encode())
+
+	var queries []SlowQuery
+	if err := c.doJSON(ctx, "GET", url, nil, &queries, http.StatusOK, "get slow queries"); err != nil {
+		return nil, err
+	}
+
+	if c.namespace == "" {
+		return queries, nil
+	}
+
+	prefix := c.namespace + namespaceSeparator
+	own := queries[:0]
+	for _, q := range queries {
+		if strings.HasPrefix(q.Collection, prefix) {
+			q.Collection = c.localCollectionName(q.Collection)
+			own = append(own, q)
+		}
+	}
+	return own, nil
+}
@@ -0,0 +1,188 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StorageUsage describes how much space the repository takes up
+type StorageUsage struct {
+	// RepoSize is the size of the repository in bytes, including history
+	RepoSize int64 `json:"repoSize"`
+	// Limit is the hard size limit of the hosting provider in bytes, or 0
+	// if the server does not know it
+	Limit int64 `json:"limit"`
+	// GrowthPerDay is the average growth in bytes per day over the last
+	// week
+	GrowthPerDay int64 `json:"growthPerDay"`
+	// Collections are sorted largest first
+	Collections []CollectionUsage `json:"collections"`
+	// LargestFiles are the largest files in the working tree
+	LargestFiles []FileUsage `json:"largestFiles"`
+	MeasuredAt   time.Time   `json:"measuredAt"`
+}
+
+// CollectionUsage is the storage taken by one collection
+type CollectionUsage struct {
+	Name      string `json:"name"`
+	Size      int64  `json:"size"`
+	Documents int    `json:"documents"`
+}
+
+// FileUsage is the size of one file in the repository
+type FileUsage struct {
+	Path       string `json:"path"`
+	Collection string `json:"collection"`
+	Size       int64  `json:"size"`
+}
+
+// Used returns the fraction of Limit in use, or 0 if the limit is unknown
+func (u *StorageUsage) Used() float64 {
+	if u.Limit <= 0 {
+		return 0
+	}
+	return float64(u.RepoSize) / float64(u.Limit)
+}
+
+// TimeToLimit estimates when the repository reaches Limit at the current
+// growth rate. It returns 0 if the limit is unknown or the repository is
+// not growing.
+func (u *StorageUsage) TimeToLimit() time.Duration {
+	if u.Limit <= 0 || u.GrowthPerDay <= 0 {
+		return 0
+	}
+	days := float64(u.Limit-u.RepoSize) / float64(u.GrowthPerDay)
+	if days < 0 {
+		days = 0
+	}
+	return time.Duration(days * float64(24*time.Hour))
+}
+
+// StorageUsage reports the repository size, its largest collections and
+// files, and its growth rate. Namespaced clients only see their own
+// collections and files; RepoSize and growth cover the whole repository.
+func (c *Client) StorageUsage(ctx context.Context) (*StorageUsage, error) {
+	url := fmt.Sprintf("%s/api/v1/storage", c.BaseURL)
+
+	var usage StorageUsage
+	if err := c.doJSON(ctx, "GET", url, nil, &usage, http.StatusOK, "get storage usage"); err != nil {
+		return nil, err
+	}
+
+	if c.namespace != "" {
+		prefix := c.namespace + namespaceSeparator
+		collections := usage.Collections[:0]
+		for _, cu := range usage.Collections {
+			if strings.HasPrefix(cu.Name, prefix) {
+				cu.Name = c.localCollectionName(cu.Name)
+				collections = append(collections, cu)
+			}
+		}
+		usage.Collections = collections
+
+		files := usage.LargestFiles[:0]
+		for _, f := range usage.LargestFiles {
+			if strings.HasPrefix(f.Collection, prefix) {
+				f.Collection = c.localCollectionName(f.Collection)
+				files = append(files, f)
+			}
+		}
+		usage.LargestFiles = files
+	}
+	return &usage, nil
+}
+
+// StorageWatchOptions configures a storage watcher
+type StorageWatchOptions struct {
+	// Interval between checks, one hour by default
+	Interval time.Duration
+
+	// Threshold is the repository size in bytes at which to warn
+	Threshold int64
+
+	// UsedFraction warns once RepoSize reaches this fraction of the
+	// provider's limit, such as 0.8; ignored when the limit is unknown
+	UsedFraction float64
+
+	// Horizon warns when the limit will be reached within this duration at
+	// the current growth rate
+	Horizon time.Duration
+
+	// OnWarning is called on every check that crosses a threshold
+	OnWarning func(*StorageUsage)
+
+	// OnError is called when a check fails
+	OnError func(error)
+}
+
+// StorageWatcher checks storage usage in the background
+type StorageWatcher struct {
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// WatchStorage starts a background watcher that checks StorageUsage every
+// interval and calls OnWarning while any configured threshold is crossed.
+// Hitting the hosting provider's repository size limit takes the database
+// down hard, so alert well before it.
+func (c *Client) WatchStorage(opts StorageWatchOptions) *StorageWatcher {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Hour
+	}
+
+	w := &StorageWatcher{stop: make(chan struct{}), done: make(chan struct{})}
+	c.shared().lifecycle.onClose(func(ctx context.Context) error {
+		return waitContext(ctx, w.Stop)
+	})
+
+	go func() {
+		defer close(w.done)
+
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+
+		for {
+			usage, err := c.StorageUsage(context.Background())
+			switch {
+			case err != nil:
+				if opts.OnError != nil {
+					opts.OnError(err)
+				}
+			case opts.OnWarning != nil && exceedsStorage(usage, opts):
+				opts.OnWarning(usage)
+			}
+
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return w
+}
+
+// Stop stops the watcher and waits for an in-progress check to finish
+func (w *StorageWatcher) Stop() {
+	w.once.Do(func() { close(w.stop) })
+	<-w.done
+}
+
+func exceedsStorage(usage *StorageUsage, opts StorageWatchOptions) bool {
+	if opts.Threshold > 0 && usage.RepoSize >= opts.Threshold {
+		return true
+	}
+	if opts.UsedFraction > 0 && usage.Limit > 0 && usage.Used() >= opts.UsedFraction {
+		return true
+	}
+	if opts.Horizon > 0 && usage.Limit > 0 && usage.GrowthPerDay > 0 && usage.TimeToLimit() <= opts.Horizon {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package gitdb
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// TagsHeader carries the tags of the operation a request is part of, URL
+// query encoded. The server writes them to its request log and records
+// them as trailers on the commits of writes, so data changes can be traced
+// back to the feature that made them.
+const TagsHeader = "X-GitDB-Tags"
+
+// tagsKey is the context key of an operation's tags
+type tagsKey struct{}
+
+// WithTags returns a copy of ctx carrying tags, merged over any tags ctx
+// already carries, such as {"feature": "checkout"}. Every request made
+// with the context sends them to the server, passes them to
+// instrumentation in RequestInfo.Tags and, for the keys given to
+// WithMetricTags, splits the client's metrics by them. Empty keys are
+// ignored.
+//
+//	ctx = gitdb.WithTags(ctx, map[string]string{"feature": "checkout"})
+//	_, err := client.Insert(ctx, "orders", order)
+func WithTags(ctx context.Context, tags map[string]string) context.Context {
+	merged := make(map[string]string, len(tags))
+	for key, value := range TagsFromContext(ctx) {
+		merged[key] = value
+	}
+	for key, value := range tags {
+		if key != "" {
+			merged[key] = value
+		}
+	}
+	return context.WithValue(ctx, tagsKey{}, merged)
+}
+
+// TagsFromContext returns a copy of the tags ctx carries, or nil
+func TagsFromContext(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(tagsKey{}).(map[string]string)
+	if len(tags) == 0 {
+		return nil
+	}
+	copied := make(map[string]string, len(tags))
+	for key, value := range tags {
+		copied[key] = value
+	}
+	return copied
+}
+
+// WithMetricTags makes the client also collect its metrics per operation
+// and per combination of the values of the given tag keys, reported in
+// Metrics.Tagged. Every distinct combination is tracked separately, so
+// only use keys with few values, such as a feature or team name.
+func WithMetricTags(keys ...string) Option {
+	return func(o *options) {
+		o.metricTags = append(o.metricTags, keys...)
+	}
+}
+
+// tagRequest sends the tags of req's context
+func tagRequest(req *http.Request) {
+	if tags := TagsFromContext(req.Context()); tags != nil {
+		req.Header.Set(TagsHeader, encodeTags(tags))
+	}
+}
+
+// encodeTags encodes tags in sorted key order
+func encodeTags(tags map[string]string) string {
+	values := make(url.Values, len(tags))
+	for key, value := range tags {
+		values.Set(key, value)
+	}
+	return values.Encode()
+}
@@ -0,0 +1,197 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// seriesTimeLayout stores timestamps at a fixed width in UTC, so that
+// string comparisons in range queries follow time order
+const seriesTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// Point is a timestamped value of a time series
+type Point struct {
+	Time  time.Time
+	Value float64
+	// Tags label the point, such as the host it was measured on
+	Tags map[string]string
+}
+
+// SeriesAggregate selects how Downsample reduces the points of a bucket
+type SeriesAggregate int
+
+const (
+	// SeriesAvg averages the values of a bucket
+	SeriesAvg SeriesAggregate = iota
+	// SeriesMin keeps the lowest value of a bucket
+	SeriesMin
+	// SeriesMax keeps the highest value of a bucket
+	SeriesMax
+	// SeriesSum adds up the values of a bucket
+	SeriesSum
+	// SeriesCount counts the points of a bucket
+	SeriesCount
+)
+
+// Sample is one bucket of a downsampled time series
+type Sample struct {
+	// Start of the bucket
+	Start time.Time
+	// Value is the aggregate of the bucket's points
+	Value float64
+	// Count is the number of points in the bucket
+	Count int
+}
+
+// TimeSeries stores timestamped numeric points for lightweight metrics,
+// partitioned by time so old data can be dropped cheaply and range queries
+// only touch the partitions they need
+type TimeSeries struct {
+	pc *PartitionedCollection
+}
+
+// TimeSeries returns the time series stored in partitions of name, such as
+// cpu_2024_05 for monthly partitions
+func (c *Client) TimeSeries(name string, period PartitionPeriod) *TimeSeries {
+	return &TimeSeries{pc: c.Partitioned(name, "t", period)}
+}
+
+// Append writes points, with one commit per partition touched
+func (ts *TimeSeries) Append(ctx context.Context, points ...Point) error {
+	byPartition := make(map[string][]Change)
+	for _, p := range points {
+		if math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+			return fmt.Errorf("invalid value %v at %s", p.Value, p.Time)
+		}
+
+		id, err := newToken()
+		if err != nil {
+			return err
+		}
+		doc := Document{"t": p.Time.UTC().Format(seriesTimeLayout), "value": p.Value}
+		if len(p.Tags) > 0 {
+			tags := make(map[string]interface{}, len(p.Tags))
+			for key, value := range p.Tags {
+				tags[key] = value
+			}
+			doc["tags"] = tags
+		}
+
+		name := ts.pc.PartitionFor(p.Time)
+		byPartition[name] = append(byPartition[name], Change{Op: ChangeUpsert, ID: id, Document: doc})
+	}
+
+	names := make([]string, 0, len(byPartition))
+	for name := range byPartition {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := ts.pc.ensure(ctx, name); err != nil {
+			return err
+		}
+		if _, err := ts.pc.client.ApplyChanges(ctx, name, byPartition[name]); err != nil {
+			return fmt.Errorf("failed to append points to %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Range returns the points from from up to but excluding to, oldest first.
+// When tags is non-empty only points carrying all of them are returned.
+func (ts *TimeSeries) Range(ctx context.Context, from, to time.Time, tags map[string]string) ([]Point, error) {
+	query := Query{"t": Query{
+		"$gte": from.UTC().Format(seriesTimeLayout),
+		"$lt":  to.UTC().Format(seriesTimeLayout),
+	}}
+	for key, value := range tags {
+		query["tags."+key] = value
+	}
+
+	docs, err := ts.pc.Find(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]Point, 0, len(docs))
+	for _, doc := range docs {
+		p, err := decodePoint(doc)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+	return points, nil
+}
+
+// Downsample aggregates the points from from up to but excluding to into
+// buckets of step, aligned to from. Buckets without points are omitted.
+func (ts *TimeSeries) Downsample(ctx context.Context, from, to time.Time, step time.Duration, aggregate SeriesAggregate, tags map[string]string) ([]Sample, error) {
+	if step <= 0 {
+		return nil, fmt.Errorf("invalid step %v", step)
+	}
+
+	points, err := ts.Range(ctx, from, to, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []Sample
+	for _, p := range points {
+		start := from.Add(p.Time.Sub(from) / step * step)
+		if len(samples) == 0 || !samples[len(samples)-1].Start.Equal(start) {
+			samples = append(samples, Sample{Start: start, Value: p.Value, Count: 1})
+			continue
+		}
+
+		s := &samples[len(samples)-1]
+		s.Count++
+		switch aggregate {
+		case SeriesMin:
+			s.Value = math.Min(s.Value, p.Value)
+		case SeriesMax:
+			s.Value = math.Max(s.Value, p.Value)
+		default:
+			s.Value += p.Value
+		}
+	}
+
+	for i := range samples {
+		switch aggregate {
+		case SeriesAvg:
+			samples[i].Value /= float64(samples[i].Count)
+		case SeriesCount:
+			samples[i].Value = float64(samples[i].Count)
+		}
+	}
+	return samples, nil
+}
+
+// DropBefore deletes the partitions that end at or before t
+func (ts *TimeSeries) DropBefore(ctx context.Context, t time.Time) ([]string, error) {
+	return ts.pc.DropBefore(ctx, t)
+}
+
+// decodePoint reads a point from its document
+func decodePoint(doc Document) (Point, error) {
+	raw, _ := doc["t"].(string)
+	t, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid point timestamp %q", raw)
+	}
+
+	p := Point{Time: t}
+	p.Value, _ = doc["value"].(float64)
+	if tags, ok := doc["tags"].(map[string]interface{}); ok && len(tags) > 0 {
+		p.Tags = make(map[string]string, len(tags))
+		for key, value := range tags {
+			p.Tags[key], _ = value.(string)
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,88 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// TraverseOptions describes a graph traversal starting from one document
+type TraverseOptions struct {
+	// Start is the ID of the document the traversal starts from
+	Start string `json:"start"`
+
+	// EdgeField names the field holding the references to follow. It may hold
+	// a document ID in the same collection, a Ref, or an array of either.
+	EdgeField string `json:"edgeField"`
+
+	// Depth limits how many edges are followed from the start document
+	Depth int `json:"depth"`
+
+	// Filter restricts which reached documents are included and expanded.
+	// Field aliases of the start collection apply to it and to EdgeField.
+	Filter Query `json:"filter,omitempty"`
+}
+
+// GraphNode is a document reached during a traversal
+type GraphNode struct {
+	Collection string   `json:"collection"`
+	Depth      int      `json:"depth"`
+	Document   Document `json:"document"`
+}
+
+// GraphEdge is a reference followed during a traversal
+type GraphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Field string `json:"field"`
+}
+
+// Subgraph is the result of a traversal
+type Subgraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// Traverse follows reference chains server-side (org -> teams -> members)
+// and returns the visited subgraph in a single round trip. It is refused on
+// namespaced clients, since the server follows references into any
+// collection, including other tenants'.
+func (c *Client) Traverse(ctx context.Context, collection string, opts TraverseOptions) (*Subgraph, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return nil, err
+	}
+	if c.namespace != "" {
+		return nil, fmt.Errorf("Traverse is not available on namespaced clients")
+	}
+
+	if err := ValidateDocumentID(opts.Start); err != nil {
+		return nil, err
+	}
+	if err := ValidateFieldPath(opts.EdgeField); err != nil {
+		return nil, err
+	}
+	if opts.Depth < 0 {
+		return nil, fmt.Errorf("traverse depth must not be negative")
+	}
+	opts.EdgeField = c.storedField(collection, opts.EdgeField)
+	opts.Filter = c.encodeQuery(collection, opts.Filter)
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/traverse", c.BaseURL, name)
+
+	var graph Subgraph
+	if err := c.doJSON(ctx, "POST", url, opts, &graph, http.StatusOK, "traverse documents"); err != nil {
+		return nil, err
+	}
+
+	for i, node := range graph.Nodes {
+		graph.Nodes[i].Collection = c.localCollectionName(node.Collection)
+		doc, err := c.decodeDocument(graph.Nodes[i].Collection, node.Document)
+		if err != nil {
+			return nil, err
+		}
+		graph.Nodes[i].Document = doc
+	}
+
+	return &graph, nil
+}
@@ -0,0 +1,56 @@
+package gitdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestTraverseRefusedOnNamespacedClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	c := NewClient("token", "owner", "repo").WithNamespace("acme")
+	c.BaseURL = srv.URL
+
+	_, err := c.Traverse(context.Background(), "orgs", TraverseOptions{Start: "o1", EdgeField: "teams", Depth: 2})
+	if err == nil {
+		t.Fatal("traverse on a namespaced client succeeded, want it refused")
+	}
+}
+
+func TestTraverseEncodesAliases(t *testing.T) {
+	var sent TraverseOptions
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&sent); err != nil {
+			t.Errorf("bad body: %v", err)
+		}
+		json.NewEncoder(w).Encode(Subgraph{})
+	}))
+	defer srv.Close()
+
+	c := NewClient("token", "owner", "repo")
+	c.BaseURL = srv.URL
+	c.SetFieldAliases("orgs", FieldAliases{"team_ids": "teams", "is_active": "active"})
+
+	_, err := c.Traverse(context.Background(), "orgs", TraverseOptions{
+		Start:     "o1",
+		EdgeField: "teams",
+		Depth:     1,
+		Filter:    Query{"active": true},
+	})
+	if err != nil {
+		t.Fatalf("traverse: %v", err)
+	}
+	if sent.EdgeField != "team_ids" {
+		t.Errorf("edge field = %q, want the stored name team_ids", sent.EdgeField)
+	}
+	if want := (Query{"is_active": true}); !reflect.DeepEqual(sent.Filter, want) {
+		t.Errorf("filter = %v, want %v", sent.Filter, want)
+	}
+}
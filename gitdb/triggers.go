@@ -0,0 +1,138 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// TriggerEvent is the document change that fires a trigger
+type TriggerEvent string
+
+const (
+	TriggerInsert TriggerEvent = "insert"
+	TriggerUpdate TriggerEvent = "update"
+	TriggerDelete TriggerEvent = "delete"
+)
+
+// TriggerAction is what the server does when a trigger fires. Build one
+// with CallWebhook, WriteTo or SetField.
+type TriggerAction struct {
+	Type       string      `json:"type"`
+	URL        string      `json:"url,omitempty"`
+	Collection string      `json:"collection,omitempty"`
+	Field      string      `json:"field,omitempty"`
+	Value      interface{} `json:"value,omitempty"`
+}
+
+// CallWebhook posts the changed document to url
+func CallWebhook(url string) TriggerAction {
+	return TriggerAction{Type: "webhook", URL: url}
+}
+
+// WriteTo inserts a copy of the changed document into collection, e.g. an
+// audit log
+func WriteTo(collection string) TriggerAction {
+	return TriggerAction{Type: "write", Collection: collection}
+}
+
+// SetField sets field to value on the changed document
+func SetField(field string, value interface{}) TriggerAction {
+	return TriggerAction{Type: "set", Field: field, Value: value}
+}
+
+// Trigger is a server-side trigger registered on a collection
+type Trigger struct {
+	ID         string        `json:"id"`
+	Collection string        `json:"collection"`
+	Event      TriggerEvent  `json:"event"`
+	Action     TriggerAction `json:"action"`
+	Created    time.Time     `json:"created"`
+}
+
+// CreateTrigger registers an action the server runs whenever documents in
+// the collection are inserted, updated or deleted
+func (c *Client) CreateTrigger(ctx context.Context, collection string, event TriggerEvent, action TriggerAction) (*Trigger, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	switch event {
+	case TriggerInsert, TriggerUpdate, TriggerDelete:
+	default:
+		return nil, fmt.Errorf("unknown trigger event %q", event)
+	}
+
+	switch action.Type {
+	case "webhook":
+		if action.URL == "" {
+			return nil, fmt.Errorf("webhook trigger needs a URL")
+		}
+	case "write":
+		target, err := c.collectionName(action.Collection)
+		if err != nil {
+			return nil, err
+		}
+		action.Collection = target
+	case "set":
+		if action.Field == "" {
+			return nil, fmt.Errorf("set trigger needs a field")
+		}
+	default:
+		return nil, fmt.Errorf("unknown trigger action %q", action.Type)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/triggers", c.BaseURL, name)
+
+	data := map[string]interface{}{"event": event, "action": action}
+
+	var trigger Trigger
+	if err := c.doJSON(ctx, "POST", url, data, &trigger, http.StatusCreated, "create trigger"); err != nil {
+		return nil, err
+	}
+	c.localizeTrigger(&trigger)
+	return &trigger, nil
+}
+
+// ListTriggers lists the triggers registered on a collection
+func (c *Client) ListTriggers(ctx context.Context, collection string) ([]Trigger, error) {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/triggers", c.BaseURL, name)
+
+	var triggers []Trigger
+	if err := c.doJSON(ctx, "GET", url, nil, &triggers, http.StatusOK, "list triggers"); err != nil {
+		return nil, err
+	}
+	for i := range triggers {
+		c.localizeTrigger(&triggers[i])
+	}
+	return triggers, nil
+}
+
+// DeleteTrigger removes a trigger from a collection
+func (c *Client) DeleteTrigger(ctx context.Context, collection, id string) error {
+	name, err := c.collectionName(collection)
+	if err != nil {
+		return err
+	}
+	if err := ValidateDocumentID(id); err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/api/v1/collections/%s/triggers/%s", c.BaseURL, name, pathSegment(id))
+	return c.doJSON(ctx, "DELETE", url, nil, nil, http.StatusOK, "delete trigger")
+}
+
+// localizeTrigger strips the namespace prefix from a trigger's collections
+func (c *Client) localizeTrigger(t *Trigger) {
+	t.Collection = c.localCollectionName(t.Collection)
+	if t.Action.Collection != "" {
+		t.Action.Collection = c.localCollectionName(t.Action.Collection)
+	}
+}
@@ -0,0 +1,147 @@
+package gitdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// TypedCollection is a collection handle that reads and writes values of the
+// application's own type T instead of Documents. Values are converted with
+// encoding/json, so json struct tags name the stored fields; the
+// collection's aliases, computed fields and options apply as they do to
+// Documents. Get one with CollectionOf.
+type TypedCollection[T any] struct {
+	handle *CollectionHandle
+}
+
+// CollectionOf returns a typed handle for the named collection. The name is
+// validated when the handle is first used.
+func CollectionOf[T any](c *Client, name string) *TypedCollection[T] {
+	return &TypedCollection[T]{handle: c.Collection(name)}
+}
+
+// Name returns the collection name as the application sees it
+func (tc *TypedCollection[T]) Name() string {
+	return tc.handle.Name()
+}
+
+// Handle returns the untyped handle for the collection, for operations the
+// typed handle does not cover
+func (tc *TypedCollection[T]) Handle() *CollectionHandle {
+	return tc.handle
+}
+
+// Insert inserts v into the collection and returns its ID
+func (tc *TypedCollection[T]) Insert(ctx context.Context, v T) (string, error) {
+	doc, err := documentOf(v)
+	if err != nil {
+		return "", err
+	}
+	return tc.handle.Insert(ctx, doc)
+}
+
+// Find finds the documents matching query, decoded as T
+func (tc *TypedCollection[T]) Find(ctx context.Context, query Query) ([]T, error) {
+	docs, err := tc.handle.Find(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAll[T](docs)
+}
+
+// FindWithOptions finds the documents matching query, sorted, paged and
+// compared as opts specifies, decoded as T. A Projection leaves the fields
+// it drops at their zero values.
+func (tc *TypedCollection[T]) FindWithOptions(ctx context.Context, query Query, opts FindOptions) ([]T, error) {
+	docs, err := tc.handle.FindWithOptions(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAll[T](docs)
+}
+
+// FindByID finds a document by ID, decoded as T
+func (tc *TypedCollection[T]) FindByID(ctx context.Context, id string) (T, error) {
+	var v T
+	doc, err := tc.handle.FindByID(ctx, id)
+	if err != nil {
+		return v, err
+	}
+	if err := doc.Decode(&v); err != nil {
+		return v, err
+	}
+	return v, nil
+}
+
+// FindWithRelations finds the documents matching query and eagerly loads
+// the named relations into them before decoding them as T, as Load does.
+// T receives the related documents through the field tagged with the
+// relation's name, which should be omitempty so that inserting a T does
+// not store them:
+//
+//	type User struct {
+//		ID    string `json:"_id,omitempty"`
+//		Posts []Post `json:"posts,omitempty"`
+//	}
+func (tc *TypedCollection[T]) FindWithRelations(ctx context.Context, query Query, relations ...string) ([]T, error) {
+	docs, err := tc.handle.Find(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if err := tc.handle.Client().Load(ctx, tc.handle.Name(), docs, relations...); err != nil {
+		return nil, err
+	}
+	return decodeAll[T](docs)
+}
+
+// Delete deletes a document by ID, applying the OnDelete actions of the
+// collection's relations
+func (tc *TypedCollection[T]) Delete(ctx context.Context, id string) error {
+	return tc.handle.Delete(ctx, id)
+}
+
+// DeleteMany deletes the documents matching query, applying the OnDelete
+// actions of the collection's relations
+func (tc *TypedCollection[T]) DeleteMany(ctx context.Context, query Query) (int, error) {
+	return tc.handle.DeleteMany(ctx, query)
+}
+
+// HasMany declares that documents of the collection have many documents in
+// target, linked by target's foreignKey field, as Client.HasMany does
+func (tc *TypedCollection[T]) HasMany(name, target, foreignKey string, onDelete DeleteAction) *TypedCollection[T] {
+	tc.handle.Client().HasMany(tc.handle.Name(), name, target, foreignKey, onDelete)
+	return tc
+}
+
+// BelongsTo declares that documents of the collection reference one
+// document in target through their foreignKey field, as Client.BelongsTo
+// does
+func (tc *TypedCollection[T]) BelongsTo(name, target, foreignKey string) *TypedCollection[T] {
+	tc.handle.Client().BelongsTo(tc.handle.Name(), name, target, foreignKey)
+	return tc
+}
+
+// decodeAll decodes each document as T
+func decodeAll[T any](docs []Document) ([]T, error) {
+	values := make([]T, len(docs))
+	for i, doc := range docs {
+		if err := doc.Decode(&values[i]); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// documentOf converts v to a Document through its JSON encoding
+func documentOf(v interface{}) (Document, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil || doc == nil {
+		return nil, fmt.Errorf("failed to encode document: %T is not a JSON object", v)
+	}
+	return doc, nil
+}
@@ -0,0 +1,140 @@
+package gitdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type testAuthor struct {
+	ID    string     `json:"_id,omitempty"`
+	Name  string     `json:"name"`
+	Posts []testPost `json:"posts,omitempty"`
+}
+
+type testPost struct {
+	ID       string `json:"_id,omitempty"`
+	AuthorID string `json:"authorId"`
+}
+
+// relationServer holds collections of documents and answers finds and
+// deletes with equality and $in queries, which is all relations need
+func relationServer(t *testing.T, collections map[string][]Document) (*httptest.Server, func(string) []string) {
+	t.Helper()
+	var mu sync.Mutex
+
+	matches := func(doc Document, query map[string]interface{}) bool {
+		for field, want := range query {
+			if in, ok := want.(map[string]interface{}); ok {
+				found := false
+				for _, v := range in["$in"].([]interface{}) {
+					found = found || doc[field] == v
+				}
+				if !found {
+					return false
+				}
+			} else if doc[field] != want {
+				return false
+			}
+		}
+		return true
+	}
+	remove := func(collection string, query map[string]interface{}) int {
+		var kept []Document
+		for _, doc := range collections[collection] {
+			if !matches(doc, query) {
+				kept = append(kept, doc)
+			}
+		}
+		deleted := len(collections[collection]) - len(kept)
+		collections[collection] = kept
+		return deleted
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/collections/"), "/")
+		collection := parts[0]
+		var query map[string]interface{}
+		if r.Method == "POST" {
+			if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+				t.Errorf("%s %s: bad body: %v", r.Method, r.URL.Path, err)
+			}
+		}
+
+		switch {
+		case r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/documents/find"):
+			found := []Document{}
+			for _, doc := range collections[collection] {
+				if matches(doc, query) {
+					found = append(found, doc)
+				}
+			}
+			json.NewEncoder(w).Encode(found)
+
+		case r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/documents/delete-many"):
+			json.NewEncoder(w).Encode(map[string]interface{}{"deletedCount": remove(collection, query)})
+
+		case r.Method == "DELETE" && len(parts) == 3:
+			if remove(collection, map[string]interface{}{"_id": parts[2]}) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+			}
+
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return srv, func(collection string) []string {
+		mu.Lock()
+		defer mu.Unlock()
+		var ids []string
+		for _, doc := range collections[collection] {
+			ids = append(ids, doc["_id"].(string))
+		}
+		sort.Strings(ids)
+		return ids
+	}
+}
+
+func TestTypedCascadeDelete(t *testing.T) {
+	srv, ids := relationServer(t, map[string][]Document{
+		"users": {{"_id": "u1", "name": "Ada"}, {"_id": "u2", "name": "Grace"}},
+		"posts": {{"_id": "p1", "authorId": "u1"}, {"_id": "p2", "authorId": "u1"}, {"_id": "p3", "authorId": "u2"}},
+	})
+	defer srv.Close()
+
+	c := NewClient("token", "owner", "repo")
+	c.BaseURL = srv.URL
+	ctx := context.Background()
+
+	users := CollectionOf[testAuthor](c, "users").HasMany("posts", "posts", "authorId", Cascade)
+	CollectionOf[testPost](c, "posts").BelongsTo("author", "users", "authorId")
+
+	loaded, err := users.FindWithRelations(ctx, Query{"_id": "u1"}, "posts")
+	if err != nil {
+		t.Fatalf("find with relations: %v", err)
+	}
+	want := []testAuthor{{ID: "u1", Name: "Ada", Posts: []testPost{{ID: "p1", AuthorID: "u1"}, {ID: "p2", AuthorID: "u1"}}}}
+	if !reflect.DeepEqual(loaded, want) {
+		t.Errorf("loaded = %+v, want %+v", loaded, want)
+	}
+
+	if err := users.Delete(ctx, "u1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if got := ids("users"); !reflect.DeepEqual(got, []string{"u2"}) {
+		t.Errorf("users = %v, want [u2]", got)
+	}
+	if got := ids("posts"); !reflect.DeepEqual(got, []string{"p3"}) {
+		t.Errorf("posts = %v, want the cascade to leave only [p3]", got)
+	}
+}
@@ -0,0 +1,214 @@
+package gitdb
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxCollectionNameLength is the longest collection name the server accepts
+	MaxCollectionNameLength = 100
+
+	// MaxFieldPathLength is the longest dotted field path the server accepts
+	MaxFieldPathLength = 1024
+)
+
+// ValidationError reports a name rejected before any request is sent
+type ValidationError struct {
+	Kind   string
+	Name   string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s %q: %s", e.Kind, e.Name, e.Reason)
+}
+
+// ValidateCollectionName checks a collection name against the server's rules.
+// Names are 1-100 characters of letters, digits, '_', '-' and '.', must not
+// start with '.' and must not contain "..", which keeps them safe to use both
+// as URL path segments and as directories in the backing repository.
+//
+// Names of the collections the client keeps its own state in are reserved:
+// _locks, _offsets and the _topic- prefix of locks and pub/sub. So is "__",
+// which separates the tenant from the collection in the names of
+// namespaced clients.
+func ValidateCollectionName(name string) error {
+	if err := validateCollectionName(name); err != nil {
+		return err
+	}
+
+	reserved := func(reason string) error {
+		return &ValidationError{Kind: "collection name", Name: name, Reason: reason}
+	}
+	if isInternalCollection(name) {
+		return reserved("is reserved for the client's lock and pub/sub collections")
+	}
+	if strings.Contains(name, namespaceSeparator) {
+		return reserved(fmt.Sprintf("must not contain %q, which separates tenant and collection in namespaced names", namespaceSeparator))
+	}
+	return nil
+}
+
+// validateCollectionName checks a collection name against the server's
+// rules without the reserved names, for the client's own collections and
+// server-side names, which may be namespaced
+func validateCollectionName(name string) error {
+	invalid := func(reason string) error {
+		return &ValidationError{Kind: "collection name", Name: name, Reason: reason}
+	}
+
+	if name == "" {
+		return invalid("must not be empty")
+	}
+	if len(name) > MaxCollectionNameLength {
+		return invalid(fmt.Sprintf("must be at most %d characters", MaxCollectionNameLength))
+	}
+	if strings.HasPrefix(name, ".") {
+		return invalid("must not start with '.'")
+	}
+	if strings.Contains(name, "..") {
+		return invalid("must not contain \"..\"")
+	}
+	for _, r := range name {
+		if !isNameRune(r) {
+			return invalid(fmt.Sprintf("must not contain %q", r))
+		}
+	}
+	return nil
+}
+
+// ValidateFieldPath checks a dotted field path such as "address.city".
+// Each segment must be non-empty, must not start with '$' (reserved for
+// operators) and must not contain NUL bytes.
+func ValidateFieldPath(path string) error {
+	invalid := func(reason string) error {
+		return &ValidationError{Kind: "field path", Name: path, Reason: reason}
+	}
+
+	if path == "" {
+		return invalid("must not be empty")
+	}
+	if len(path) > MaxFieldPathLength {
+		return invalid(fmt.Sprintf("must be at most %d characters", MaxFieldPathLength))
+	}
+	if !utf8.ValidString(path) {
+		return invalid("must be valid UTF-8")
+	}
+	for _, segment := range strings.Split(path, ".") {
+		if err := validateFieldSegment(segment); err != "" {
+			return invalid(err)
+		}
+	}
+	return nil
+}
+
+// ValidateDocumentID checks a document ID before it is placed in a URL.
+// IDs must not be empty, "." or "..", and must not contain '/', '\', '?',
+// '#' or control characters.
+func ValidateDocumentID(id string) error {
+	invalid := func(reason string) error {
+		return &ValidationError{Kind: "document ID", Name: id, Reason: reason}
+	}
+
+	if id == "" {
+		return invalid("must not be empty")
+	}
+	if id == "." || id == ".." {
+		return invalid("must not be a relative path")
+	}
+	if strings.ContainsAny(id, "/\\?#") {
+		return invalid("must not contain path or URL separators")
+	}
+	for _, r := range id {
+		if r < 0x20 || r == 0x7f {
+			return invalid("must not contain control characters")
+		}
+	}
+	return nil
+}
+
+// pathSegment escapes a document ID, or a name validated like one, for use
+// as a URL path segment, so that characters such as '%' or spaces reach the
+// server as written. Collection names need no escaping: ValidateCollectionName
+// only admits characters that are safe in a path.
+func pathSegment(id string) string {
+	return url.PathEscape(id)
+}
+
+// validateDocumentFields checks the field names of a document, recursing
+// into embedded documents. References built with Ref are the one kind of
+// embedded document allowed '$' keys.
+func validateDocumentFields(doc map[string]interface{}) error {
+	for key, value := range doc {
+		if reason := validateFieldSegment(key); reason != "" {
+			return &ValidationError{Kind: "field name", Name: key, Reason: reason}
+		}
+		if strings.Contains(key, ".") {
+			return &ValidationError{Kind: "field name", Name: key, Reason: "must not contain '.'"}
+		}
+		if nested, ok := asMap(value); ok {
+			if isRef(nested) {
+				continue
+			}
+			if err := validateDocumentFields(nested); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateUpdateFields checks the field paths an update writes to
+func validateUpdateFields(update Update) error {
+	for key, value := range update {
+		if !strings.HasPrefix(key, "$") {
+			if err := ValidateFieldPath(key); err != nil {
+				return err
+			}
+			continue
+		}
+		if fields, ok := asMap(value); ok {
+			for path := range fields {
+				if err := ValidateFieldPath(path); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func validateFieldSegment(segment string) string {
+	switch {
+	case segment == "":
+		return "must not contain empty segments"
+	case strings.HasPrefix(segment, "$"):
+		return "must not start with '$'"
+	case strings.ContainsRune(segment, 0):
+		return "must not contain NUL bytes"
+	}
+	return ""
+}
+
+func isNameRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+		r == '_' || r == '-' || r == '.'
+}
+
+// asMap returns v as a plain map if it is a document-like value
+func asMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case Document:
+		return m, true
+	case Query:
+		return m, true
+	case Update:
+		return m, true
+	}
+	return nil, false
+}
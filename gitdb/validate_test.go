@@ -0,0 +1,38 @@
+package gitdb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateCollectionNameReserved(t *testing.T) {
+	for _, name := range []string{"_locks", "_offsets", "_topic-orders", "acme__users", "a__b"} {
+		err := ValidateCollectionName(name)
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Errorf("ValidateCollectionName(%q) = %v, want a ValidationError", name, err)
+		}
+	}
+	for _, name := range []string{"users", "_audit", "user_events", "v1.orders"} {
+		if err := ValidateCollectionName(name); err != nil {
+			t.Errorf("ValidateCollectionName(%q) = %v, want nil", name, err)
+		}
+	}
+}
+
+func TestCollectionNameAllowsInternalCollections(t *testing.T) {
+	c := NewClient("token", "owner", "repo")
+	for _, name := range []string{locksCollection, offsetsCollection, topicCollection("orders")} {
+		if _, err := c.collectionName(name); err != nil {
+			t.Errorf("collectionName(%q) = %v, want it allowed", name, err)
+		}
+	}
+	if _, err := c.collectionName("acme__users"); err == nil {
+		t.Error("collectionName(\"acme__users\") succeeded, want the tenant separator refused")
+	}
+
+	resolved, err := c.WithNamespace("acme").collectionName("users")
+	if err != nil || resolved != "acme__users" {
+		t.Errorf("namespaced collectionName = %q, %v, want acme__users", resolved, err)
+	}
+}
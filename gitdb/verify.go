@@ -0,0 +1,276 @@
+package gitdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// IncludeSignaturesHeader asks the server to attach the signature of the
+// commit that last wrote each returned document. The signed payload is the
+// document as that commit wrote it, in JSON, so the client can check that
+// the document it received is the one that was signed.
+const IncludeSignaturesHeader = "X-GitDB-Include-Signatures"
+
+// signatureField carries commit signature metadata on returned documents
+const signatureField = "_signature"
+
+// Keyring verifies commit signatures
+type Keyring interface {
+	// Verify checks a detached signature of payload in the given format
+	// ("openpgp" or "ssh") and returns the identity of the signer
+	Verify(format string, payload, signature []byte) (signer string, err error)
+}
+
+// SignaturePolicy decides what happens to documents whose signature does
+// not verify
+type SignaturePolicy int
+
+const (
+	// FlagUnverified returns every document, setting "_verified" to whether
+	// its signature checked out and "_signer" to the verified identity
+	FlagUnverified SignaturePolicy = iota
+
+	// RejectUnverified fails the read with a *SignatureError if any
+	// document is unsigned or its signature is invalid
+	RejectUnverified
+)
+
+// SignatureError reports a document whose commit signature did not verify
+type SignatureError struct {
+	Collection string
+	ID         string
+	Reason     string
+}
+
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("document %s/%s failed signature verification: %s", e.Collection, e.ID, e.Reason)
+}
+
+// signatureCheck is the verification configured on a client
+type signatureCheck struct {
+	keyring Keyring
+	policy  SignaturePolicy
+}
+
+// VerifySignatures returns a copy of the client that verifies the commit
+// signature of every document it reads with Find, FindOne, FindByID or a
+// cursor against keyring, flagging or rejecting unsigned and invalid data according
+// to policy.
+func (c *Client) VerifySignatures(keyring Keyring, policy SignaturePolicy) *Client {
+	nc := *c
+	nc.verification = &signatureCheck{keyring: keyring, policy: policy}
+	return &nc
+}
+
+// signedReads are the operations whose documents are verified
+var signedReads = map[string]bool{
+	"find documents": true,
+	"find document":  true,
+	"open cursor":    true,
+	"fetch cursor":   true,
+}
+
+// requestSignatures asks the server for signature metadata on document reads
+func (c *Client) requestSignatures(op string, req *http.Request) {
+	if c.verification != nil && signedReads[op] {
+		req.Header.Set(IncludeSignaturesHeader, "true")
+	}
+}
+
+// verifyDocuments checks and strips the signature metadata of docs
+func (c *Client) verifyDocuments(collection string, docs []Document) error {
+	for _, doc := range docs {
+		meta, _ := doc[signatureField].(map[string]interface{})
+		delete(doc, signatureField)
+		if c.verification == nil {
+			continue
+		}
+
+		signer, err := c.verification.verify(meta, doc)
+		if err != nil {
+			if c.verification.policy == RejectUnverified {
+				id, _ := doc["_id"].(string)
+				return &SignatureError{Collection: collection, ID: id, Reason: err.Error()}
+			}
+			doc["_verified"] = false
+			continue
+		}
+		doc["_verified"] = true
+		doc["_signer"] = signer
+	}
+	return nil
+}
+
+// verify checks that the signed payload is doc and that its signature is
+// valid, returning the signer
+func (sc *signatureCheck) verify(meta map[string]interface{}, doc Document) (string, error) {
+	if meta == nil {
+		return "", fmt.Errorf("unsigned")
+	}
+	format, _ := meta["format"].(string)
+	payload, _ := meta["payload"].(string)
+	encoded, _ := meta["signature"].(string)
+	if encoded == "" {
+		return "", fmt.Errorf("unsigned")
+	}
+
+	// A valid signature proves nothing about the document unless the
+	// payload it covers is the document returned
+	matches, err := payloadMatches(payload, doc)
+	if err != nil {
+		return "", err
+	}
+	if !matches {
+		return "", fmt.Errorf("signed payload does not match the document")
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("malformed signature: %w", err)
+	}
+	return sc.keyring.Verify(format, []byte(payload), signature)
+}
+
+// payloadMatches reports whether a signed payload holds the same document
+// as doc, comparing canonical JSON so that key order and number formatting
+// do not matter. Underscored fields other than _id are metadata added on
+// read and are left out.
+func payloadMatches(payload string, doc Document) (bool, error) {
+	var signed Document
+	if err := json.Unmarshal([]byte(payload), &signed); err != nil || signed == nil {
+		return false, fmt.Errorf("malformed signed payload")
+	}
+	want, err := CanonicalJSON(signedContent(signed))
+	if err != nil {
+		return false, fmt.Errorf("malformed signed payload: %w", err)
+	}
+	got, err := CanonicalJSON(signedContent(doc))
+	if err != nil {
+		return false, fmt.Errorf("failed to encode document: %w", err)
+	}
+	return bytes.Equal(want, got), nil
+}
+
+// signedContent returns doc without metadata fields
+func signedContent(doc Document) Document {
+	content := make(Document, len(doc))
+	for key, value := range doc {
+		if key == "_id" || !strings.HasPrefix(key, "_") {
+			content[key] = value
+		}
+	}
+	return content
+}
+
+// GPGKeyring verifies OpenPGP signatures against a GnuPG home directory
+type GPGKeyring struct {
+	// Home is the GnuPG home holding the trusted public keys; empty uses
+	// the default keyring
+	Home string
+	// Program is the gpg binary, "gpg" by default
+	Program string
+}
+
+// Verify checks an OpenPGP signature and returns the signer's user ID
+func (k GPGKeyring) Verify(format string, payload, signature []byte) (string, error) {
+	if format != "openpgp" {
+		return "", fmt.Errorf("unsupported signature format %q", format)
+	}
+
+	sigFile, err := writeTemp(signature)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(sigFile)
+
+	program := k.Program
+	if program == "" {
+		program = "gpg"
+	}
+	args := []string{"--batch", "--status-fd", "1", "--verify", sigFile, "-"}
+	if k.Home != "" {
+		args = append([]string{"--homedir", k.Home}, args...)
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.Command(program, args...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = &stdout
+	runErr := cmd.Run()
+
+	// Trust GOODSIG only with a zero exit status; gpg reports expired and
+	// revoked keys through other status lines and a failing exit code
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 4)
+		if len(fields) == 4 && fields[0] == "[GNUPG:]" && fields[1] == "GOODSIG" && runErr == nil {
+			return fields[3], nil
+		}
+	}
+	return "", fmt.Errorf("invalid signature")
+}
+
+// SSHAllowedSigners verifies SSH signatures against an allowed signers file,
+// in the format git uses for gpg.ssh.allowedSignersFile
+type SSHAllowedSigners struct {
+	// File is the allowed signers file
+	File string
+	// Namespace must match the namespace used to sign, "gitdb" by default
+	Namespace string
+	// Program is the ssh-keygen binary, "ssh-keygen" by default
+	Program string
+}
+
+// Verify checks an SSH signature and returns the matching principal
+func (k SSHAllowedSigners) Verify(format string, payload, signature []byte) (string, error) {
+	if format != "ssh" {
+		return "", fmt.Errorf("unsupported signature format %q", format)
+	}
+
+	sigFile, err := writeTemp(signature)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(sigFile)
+
+	program, namespace := k.Program, k.Namespace
+	if program == "" {
+		program = "ssh-keygen"
+	}
+	if namespace == "" {
+		namespace = "gitdb"
+	}
+
+	out, err := exec.Command(program, "-Y", "find-principals", "-s", sigFile, "-f", k.File).Output()
+	if err != nil {
+		return "", fmt.Errorf("signing key is not an allowed signer")
+	}
+	principal := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+
+	cmd := exec.Command(program, "-Y", "verify", "-f", k.File, "-I", principal, "-n", namespace, "-s", sigFile)
+	cmd.Stdin = bytes.NewReader(payload)
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("invalid signature")
+	}
+	return principal, nil
+}
+
+func writeTemp(data []byte) (string, error) {
+	f, err := os.CreateTemp("", "gitdb-sig-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to write signature: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write signature: %w", err)
+	}
+	return f.Name(), nil
+}
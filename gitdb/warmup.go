@@ -0,0 +1,88 @@
+package gitdb
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Warmup resolves the server's host name, establishes a connection
+// (including the TLS handshake) and runs a health check against every
+// configured endpoint, so the first real request does not pay the
+// connection set-up latency. Names are resolved the way the client dials
+// them, through WithStaticHosts and WithDNSCache when set, which fills the
+// DNS cache. The connections are kept in the HTTP client's idle pool. Call
+// it at startup; it returns the first failure.
+func (c *Client) Warmup(ctx context.Context) error {
+	bases := []string{strings.TrimSuffix(c.BaseURL, "/")}
+
+	state := c.shared()
+	state.mu.RLock()
+	if pool := state.readEndpoints; pool != nil {
+		for _, ep := range pool.endpoints {
+			bases = append(bases, ep.base)
+		}
+	}
+	if fo := state.failover; fo != nil {
+		bases = append(bases, fo.secondaries...)
+	}
+	state.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	errs := make(chan error, len(bases))
+	var wg sync.WaitGroup
+	for _, base := range bases {
+		if seen[base] {
+			continue
+		}
+		seen[base] = true
+
+		wg.Add(1)
+		go func(base string) {
+			defer wg.Done()
+			if err := c.warmup(ctx, base); err != nil {
+				errs <- err
+			}
+		}(base)
+	}
+	wg.Wait()
+	close(errs)
+
+	return <-errs
+}
+
+// warmup prepares a connection to a single endpoint
+func (c *Client) warmup(ctx context.Context, base string) error {
+	u, err := url.Parse(base)
+	if err != nil {
+		return fmt.Errorf("warmup of %s failed: %w", base, err)
+	}
+
+	if host := u.Hostname(); net.ParseIP(host) == nil {
+		if _, err := c.shared().resolver.lookup(ctx, host); err != nil {
+			return fmt.Errorf("warmup of %s failed: %w", base, err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", base+"/health", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Sent directly so that the request is not routed to another endpoint
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("warmup of %s failed: %w", base, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("warmup of %s failed with status: %d", base, resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package gitdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// IdempotencyKeyHeader carries the write token of a write, or the key
+// derived from it for the later requests of a compound write
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// WriteToken identifies one logical write. The server records every write
+// carrying a token in its write log and applies it at most once:
+// re-submitting it returns the original result instead of writing again.
+type WriteToken string
+
+// NewWriteToken returns a fresh random write token. Persist it before
+// submitting the write so it can be re-submitted after a crash.
+func NewWriteToken() (WriteToken, error) {
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	return WriteToken(token), nil
+}
+
+// WriteRecord is the server's write log entry for a write token
+type WriteRecord struct {
+	Token WriteToken `json:"token"`
+	// Applied reports whether the write has been applied
+	Applied bool `json:"applied"`
+	// Operation is the kind of write, e.g. "insert document"
+	Operation string `json:"operation"`
+	// Collection is the collection written to
+	Collection string `json:"collection"`
+	// Commit is the commit the write was applied in
+	Commit string `json:"commit"`
+	// Result is the response the write returned, such as the inserted _id
+	Result Document `json:"result"`
+	// AppliedAt is when the write was applied
+	AppliedAt time.Time `json:"appliedAt"`
+}
+
+// writeTokenState numbers the write requests made under a write token
+type writeTokenState struct {
+	token  WriteToken
+	mu     sync.Mutex
+	writes int
+}
+
+// WithWriteToken returns a copy of the client whose writes carry token, so
+// that a write can be safely re-submitted after a crash or a lost response
+// and is still applied exactly once. Writes carrying a token are also
+// retried by the retry policy like reads.
+//
+// Use the returned client for a single logical write. Operations such as
+// Cache.Set, FindOneAndUpdate or DeleteMany with relation actions send
+// several write requests; the first carries token itself and the n-th the
+// key "<token>.<n>", so each is applied once and none returns another's
+// result. To re-submit, call WithWriteToken again with the same token,
+// which numbers the requests from the start.
+func (c *Client) WithWriteToken(token WriteToken) *Client {
+	nc := *c
+	nc.writeToken = &writeTokenState{token: token}
+	return &nc
+}
+
+// WriteStatus queries the server's write log for token, reporting whether
+// the write it identifies was applied. A token the server has never seen is
+// reported as not applied.
+func (c *Client) WriteStatus(ctx context.Context, token WriteToken) (*WriteRecord, error) {
+	if err := ValidateDocumentID(string(token)); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/api/v1/writes/%s", c.BaseURL, pathSegment(string(token)))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do("get write status", req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get write status: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return &WriteRecord{Token: token}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get write status: %w", newAPIError(resp))
+	}
+
+	var record WriteRecord
+	if err := json.NewDecoder(resp.Body).Decode(&record); err != nil {
+		return nil, fmt.Errorf("failed to decode write status: %w", err)
+	}
+	record.Collection = c.localCollectionName(record.Collection)
+	return &record, nil
+}
+
+// writeTokenRequest tags a write with the idempotency key of its position
+// among the writes made under the client's write token. Cancelling a query
+// is not one of those writes: it happens or not depending on timing, and
+// would shift the keys of the writes after it.
+func (c *Client) writeTokenRequest(op string, req *http.Request) {
+	if c.writeToken == nil || c.writeToken.token == "" || readOperations[op] || op == "cancel operation" {
+		return
+	}
+	c.writeToken.mu.Lock()
+	c.writeToken.writes++
+	n := c.writeToken.writes
+	c.writeToken.mu.Unlock()
+
+	key := string(c.writeToken.token)
+	if n > 1 {
+		key += "." + strconv.Itoa(n)
+	}
+	req.Header.Set(IdempotencyKeyHeader, key)
+}
@@ -0,0 +1,194 @@
+// Package gitdbent is an ent driver backed by the GitDB client, so schemas
+// generated with ent can be stored in GitDB collections:
+//
+//	drv := gitdbent.Open(client)
+//	if err := gitdbent.CreateCollections(ctx, client, migrate.Tables); err != nil {
+//		return err
+//	}
+//	db := ent.NewClient(ent.Driver(drv))
+//	u, err := db.User.Create().SetName("Ada").SetAge(36).Save(ctx)
+//	adults, err := db.User.Query().Where(user.AgeGTE(18)).Order(ent.Asc(user.FieldName)).All(ctx)
+//
+// Each entity is a document in the collection named after its table, with
+// its ID in the id field. Statements run through the gitdbsql driver, so
+// predicates are pushed down to the server when they are comparisons, IN,
+// IS NULL, AND, OR or parentheses; anything else, such as Contains, Not or
+// edge predicates that need joins, fails with an unsupported operator
+// error. Numeric IDs that are not set on create come from a GitDB sequence
+// named after the table; string and UUID IDs need a Default in the schema.
+//
+// GitDB has no transactions. ent runs every create and update in one, and
+// the driver gives it a transaction whose Commit and Rollback do nothing,
+// so writes take effect as they are made and a rolled back transaction
+// keeps them. The same holds for transactions opened with Client.Tx.
+package gitdbent
+
+import (
+	"context"
+	stdsql "database/sql"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"entgo.io/ent/dialect"
+	entsql "entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/schema"
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb"
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb/gitdbsql"
+)
+
+// Driver implements dialect.Driver on top of a GitDB client. Queries are
+// built with ent's SQLite dialect, whose statements the gitdbsql driver
+// understands.
+type Driver struct {
+	*entsql.Driver
+	client *gitdb.Client
+}
+
+// Open returns a driver for client
+func Open(client *gitdb.Client) *Driver {
+	db := stdsql.OpenDB(gitdbsql.NewConnector(client))
+	return &Driver{Driver: entsql.OpenDB(dialect.SQLite, db), client: client}
+}
+
+// Tx returns a transaction whose Commit and Rollback do nothing, since
+// GitDB cannot group writes
+func (d *Driver) Tx(context.Context) (dialect.Tx, error) {
+	return dialect.NopTx(d), nil
+}
+
+// BeginTx is Tx; the options are ignored
+func (d *Driver) BeginTx(ctx context.Context, _ *entsql.TxOptions) (dialect.Tx, error) {
+	return d.Tx(ctx)
+}
+
+// insertReturning matches the inserts ent makes when it needs the IDs the
+// database generated: INSERT INTO `t` (`a`, ...) VALUES (?, ...), ...
+// RETURNING `id`, or INSERT INTO `t` DEFAULT VALUES RETURNING `id`
+var insertReturning = regexp.MustCompile("^INSERT INTO (`[^`]+`) (?:\\(([^)]*)\\) VALUES (.*)|DEFAULT VALUES) RETURNING (`[^`]+`)$")
+
+// Query runs a query, first assigning the IDs of inserts that ask for
+// generated ones
+func (d *Driver) Query(ctx context.Context, query string, args, v interface{}) error {
+	m := insertReturning.FindStringSubmatch(query)
+	if m == nil {
+		return d.Driver.Query(ctx, query, args, v)
+	}
+	rows, ok := v.(*entsql.Rows)
+	if !ok {
+		return fmt.Errorf("gitdbent: unexpected rows type %T", v)
+	}
+	values, ok := args.([]interface{})
+	if !ok && args != nil {
+		return fmt.Errorf("gitdbent: unexpected args type %T", args)
+	}
+
+	table, columns, tuples, idColumn := m[1], m[2], m[3], m[4]
+	width, count := 0, 1
+	if columns != "" {
+		width = strings.Count(columns, ",") + 1
+		tuple := "(" + strings.Repeat("?, ", width-1) + "?)"
+		if tuples != strings.TrimSuffix(strings.Repeat(tuple+", ", len(values)/width), ", ") || len(values)%width != 0 {
+			return fmt.Errorf("gitdbent: unsupported insert %q", query)
+		}
+		count = len(values) / width
+	}
+
+	ids := make([]int64, count)
+	for i := range ids {
+		id, err := d.client.NextSequence(ctx, strings.Trim(table, "`"))
+		if err != nil {
+			return fmt.Errorf("gitdbent: failed to assign ID: %w", err)
+		}
+		ids[i] = id
+	}
+
+	// Rebuild the insert with the ID as the first column of every row
+	var b strings.Builder
+	withIDs := make([]interface{}, 0, len(values)+count)
+	fmt.Fprintf(&b, "INSERT INTO %s (%s", table, idColumn)
+	if columns != "" {
+		b.WriteString(", " + columns)
+	}
+	b.WriteString(") VALUES ")
+	for i, id := range ids {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString("(?" + strings.Repeat(", ?", width) + ")")
+		withIDs = append(withIDs, id)
+		withIDs = append(withIDs, values[i*width:(i+1)*width]...)
+	}
+	if err := d.Driver.Exec(ctx, b.String(), withIDs, nil); err != nil {
+		return err
+	}
+
+	rows.ColumnScanner = &idRows{column: strings.Trim(idColumn, "`"), ids: ids, pos: -1}
+	return nil
+}
+
+// idRows returns the IDs assigned to inserted rows
+type idRows struct {
+	column string
+	ids    []int64
+	pos    int
+}
+
+func (r *idRows) Close() error                               { return nil }
+func (r *idRows) ColumnTypes() ([]*stdsql.ColumnType, error) { return nil, nil }
+func (r *idRows) Columns() ([]string, error)                 { return []string{r.column}, nil }
+func (r *idRows) Err() error                                 { return nil }
+func (r *idRows) NextResultSet() bool                        { return false }
+
+func (r *idRows) Next() bool {
+	r.pos++
+	return r.pos < len(r.ids)
+}
+
+func (r *idRows) Scan(dest ...interface{}) error {
+	if r.pos < 0 || r.pos >= len(r.ids) {
+		return io.EOF
+	}
+	if len(dest) != 1 {
+		return fmt.Errorf("gitdbent: expected 1 destination, got %d", len(dest))
+	}
+	id := r.ids[r.pos]
+	switch dest := dest[0].(type) {
+	case *int64:
+		*dest = id
+	case *int:
+		*dest = int(id)
+	case *interface{}:
+		*dest = id
+	case stdsql.Scanner:
+		return dest.Scan(id)
+	default:
+		return fmt.Errorf("gitdbent: cannot scan an ID into %T", dest)
+	}
+	return nil
+}
+
+// CreateCollections creates the collection of every table that does not
+// have one yet. Pass the generated migrate.Tables; ent's own migrations
+// query SQLite's catalog and do not work against GitDB.
+func CreateCollections(ctx context.Context, client *gitdb.Client, tables []*schema.Table) error {
+	collections, err := client.ListCollections(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list collections: %w", err)
+	}
+	exists := make(map[string]bool, len(collections))
+	for _, collection := range collections {
+		exists[collection.Name] = true
+	}
+	for _, table := range tables {
+		if exists[table.Name] {
+			continue
+		}
+		if err := client.CreateCollection(ctx, table.Name); err != nil {
+			return fmt.Errorf("failed to create collection %s: %w", table.Name, err)
+		}
+		exists[table.Name] = true
+	}
+	return nil
+}
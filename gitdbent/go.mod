@@ -0,0 +1,24 @@
+module github.com/karthikeyanV2K/gitdb-go-client/gitdbent
+
+go 1.19
+
+replace github.com/karthikeyanV2K/gitdb-go-client => ../
+
+require (
+	entgo.io/ent v0.12.5
+	github.com/karthikeyanV2K/gitdb-go-client v0.0.0
+)
+
+require (
+	ariga.io/atlas v0.14.1-0.20230918065911-83ad451a4935 // indirect
+	github.com/agext/levenshtein v1.2.1 // indirect
+	github.com/apparentlymart/go-textseg/v13 v13.0.0 // indirect
+	github.com/go-openapi/inflect v0.19.0 // indirect
+	github.com/google/go-cmp v0.5.6 // indirect
+	github.com/google/uuid v1.3.0 // indirect
+	github.com/hashicorp/hcl/v2 v2.13.0 // indirect
+	github.com/mitchellh/go-wordwrap v0.0.0-20150314170334-ad45545899c7 // indirect
+	github.com/zclconf/go-cty v1.8.0 // indirect
+	golang.org/x/mod v0.10.0 // indirect
+	golang.org/x/text v0.8.0 // indirect
+)
@@ -0,0 +1,200 @@
+// Package gitdbgorm is a GORM dialector backed by the GitDB client, so
+// models can be pointed at GitDB collections with minimal changes:
+//
+//	db, err := gorm.Open(gitdbgorm.Open(client), &gorm.Config{})
+//	db.AutoMigrate(&User{})
+//	db.Create(&User{Name: "Ada"})
+//	db.Where("age >= ?", 18).Order("name").Find(&users)
+//
+// Statements are executed through the gitdbsql driver, so the supported
+// where clauses are the ones it understands: comparisons, IN, IS NULL, AND,
+// OR and parentheses. Joins, raw functions and transactions are not
+// supported; GORM's default write transactions are skipped silently.
+//
+// Zero primary keys are filled in before insert: string keys get a random
+// ID and integer keys the next value of a sequence named after the table, so
+// gorm.Model works unchanged.
+package gitdbgorm
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"reflect"
+	"strings"
+
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb"
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb/gitdbsql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/migrator"
+	"gorm.io/gorm/schema"
+)
+
+// Dialector implements gorm.Dialector on top of a GitDB client
+type Dialector struct {
+	Client *gitdb.Client
+}
+
+// Open returns a dialector for client
+func Open(client *gitdb.Client) gorm.Dialector {
+	return &Dialector{Client: client}
+}
+
+// Name returns the dialect name
+func (d *Dialector) Name() string {
+	return "gitdb"
+}
+
+// Initialize registers the callbacks and connection pool
+func (d *Dialector) Initialize(db *gorm.DB) error {
+	callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{
+		CreateClauses: []string{"INSERT", "VALUES"},
+		QueryClauses:  []string{"SELECT", "FROM", "WHERE", "ORDER BY", "LIMIT"},
+		UpdateClauses: []string{"UPDATE", "SET", "WHERE"},
+		DeleteClauses: []string{"DELETE", "FROM", "WHERE"},
+	})
+	if err := db.Callback().Create().Before("gorm:create").Register("gitdb:assign_id", d.assignIDs); err != nil {
+		return err
+	}
+
+	db.ConnPool = &connPool{db: sql.OpenDB(gitdbsql.NewConnector(d.Client))}
+	return nil
+}
+
+// Migrator returns a migrator that maps tables to collections
+func (d *Dialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return Migrator{
+		Migrator: migrator.Migrator{Config: migrator.Config{DB: db, Dialector: d}},
+		client:   d.Client,
+	}
+}
+
+// DataTypeOf names the JSON type a field is stored as
+func (d *Dialector) DataTypeOf(field *schema.Field) string {
+	switch field.DataType {
+	case schema.Bool:
+		return "boolean"
+	case schema.Int, schema.Uint, schema.Float:
+		return "number"
+	case schema.Time:
+		return "timestamp"
+	}
+	return "string"
+}
+
+// DefaultValueOf returns the value written for fields left at their default
+func (d *Dialector) DefaultValueOf(*schema.Field) clause.Expression {
+	return clause.Expr{SQL: "NULL"}
+}
+
+// BindVarTo writes a positional placeholder
+func (d *Dialector) BindVarTo(writer clause.Writer, stmt *gorm.Statement, v interface{}) {
+	writer.WriteByte('?')
+}
+
+// QuoteTo writes a quoted, possibly qualified identifier
+func (d *Dialector) QuoteTo(writer clause.Writer, str string) {
+	for i, part := range strings.Split(str, ".") {
+		if i > 0 {
+			writer.WriteByte('.')
+		}
+		writer.WriteByte('"')
+		writer.WriteString(part)
+		writer.WriteByte('"')
+	}
+}
+
+// Explain renders sql with its arguments inlined, for logging
+func (d *Dialector) Explain(sql string, vars ...interface{}) string {
+	return logger.ExplainSQL(sql, nil, `'`, vars...)
+}
+
+// assignIDs fills zero primary keys before insert, because GitDB cannot
+// report a generated key back through LastInsertId
+func (d *Dialector) assignIDs(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil || db.Statement.Schema.PrioritizedPrimaryField == nil {
+		return
+	}
+	field := db.Statement.Schema.PrioritizedPrimaryField
+	ctx := db.Statement.Context
+
+	assign := func(rv reflect.Value) {
+		if reflect.Indirect(rv).Kind() != reflect.Struct {
+			return
+		}
+		if _, zero := field.ValueOf(ctx, rv); !zero {
+			return
+		}
+
+		switch field.FieldType.Kind() {
+		case reflect.String:
+			db.AddError(field.Set(ctx, rv, newID()))
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			id, err := d.Client.NextSequence(ctx, db.Statement.Table)
+			if db.AddError(err) == nil {
+				db.AddError(field.Set(ctx, rv, id))
+			}
+		}
+	}
+
+	switch rv := db.Statement.ReflectValue; rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			assign(rv.Index(i))
+		}
+	case reflect.Struct:
+		assign(rv)
+	}
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// connPool hides BeginTx from GORM, which then skips its default
+// transactions instead of failing on them
+type connPool struct {
+	db *sql.DB
+}
+
+func (p *connPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.db.PrepareContext(ctx, query)
+}
+
+func (p *connPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	result, err := p.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return assignedIDResult{result}, nil
+}
+
+func (p *connPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return p.db.QueryContext(ctx, query, args...)
+}
+
+func (p *connPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return p.db.QueryRowContext(ctx, query, args...)
+}
+
+// Ping lets gorm.Open verify the server is reachable
+func (p *connPool) Ping() error {
+	return p.db.Ping()
+}
+
+// assignedIDResult reports no insert ID without failing, since assignIDs
+// already set the key on the model
+type assignedIDResult struct {
+	sql.Result
+}
+
+func (r assignedIDResult) LastInsertId() (int64, error) {
+	return 0, nil
+}
@@ -0,0 +1,16 @@
+module github.com/karthikeyanV2K/gitdb-go-client/gitdbgorm
+
+go 1.19
+
+require (
+	github.com/karthikeyanV2K/gitdb-go-client v0.0.0
+	gorm.io/gorm v1.31.1
+)
+
+require (
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.5 // indirect
+	golang.org/x/text v0.21.0 // indirect
+)
+
+replace github.com/karthikeyanV2K/gitdb-go-client => ../
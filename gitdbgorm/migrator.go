@@ -0,0 +1,124 @@
+package gitdbgorm
+
+import (
+	"github.com/karthikeyanV2K/gitdb-go-client/gitdb"
+	"gorm.io/gorm"
+	"gorm.io/gorm/migrator"
+)
+
+// Migrator maps GORM tables to GitDB collections. Collections are schemaless,
+// so column and index changes are accepted without doing anything.
+type Migrator struct {
+	migrator.Migrator
+	client *gitdb.Client
+}
+
+// AutoMigrate creates the collections of models that do not have one yet
+func (m Migrator) AutoMigrate(values ...interface{}) error {
+	for _, value := range values {
+		if !m.HasTable(value) {
+			if err := m.CreateTable(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// CreateTable creates a collection per model
+func (m Migrator) CreateTable(values ...interface{}) error {
+	for _, value := range values {
+		if err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
+			return m.client.CreateCollection(m.DB.Statement.Context, stmt.Table)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DropTable deletes the collections of the models
+func (m Migrator) DropTable(values ...interface{}) error {
+	for _, value := range values {
+		if err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
+			if !m.HasTable(stmt.Table) {
+				return nil
+			}
+			return m.client.DeleteCollection(m.DB.Statement.Context, stmt.Table)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasTable reports whether the model's collection exists
+func (m Migrator) HasTable(value interface{}) bool {
+	var exists bool
+	m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		tables, err := m.GetTables()
+		for _, table := range tables {
+			if table == stmt.Table {
+				exists = true
+			}
+		}
+		return err
+	})
+	return exists
+}
+
+// GetTables lists the collections
+func (m Migrator) GetTables() ([]string, error) {
+	collections, err := m.client.ListCollections(m.DB.Statement.Context)
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]string, len(collections))
+	for i, c := range collections {
+		tables[i] = c.Name
+	}
+	return tables, nil
+}
+
+// HasColumn is always true; any field can be written to a document
+func (m Migrator) HasColumn(value interface{}, field string) bool {
+	return true
+}
+
+// AddColumn is a no-op
+func (m Migrator) AddColumn(value interface{}, field string) error {
+	return nil
+}
+
+// AlterColumn is a no-op
+func (m Migrator) AlterColumn(value interface{}, field string) error {
+	return nil
+}
+
+// DropColumn removes the field from every document of the model
+func (m Migrator) DropColumn(value interface{}, field string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		if f := stmt.Schema.LookUpField(field); f != nil {
+			field = f.DBName
+		}
+		_, err := m.client.UpdateMany(m.DB.Statement.Context, stmt.Table, gitdb.Query{}, gitdb.Update{
+			"$unset": map[string]interface{}{field: ""},
+		})
+		return err
+	})
+}
+
+// HasIndex is always true so GORM does not try to create indexes
+func (m Migrator) HasIndex(value interface{}, name string) bool {
+	return true
+}
+
+// CreateIndex is a no-op
+func (m Migrator) CreateIndex(value interface{}, name string) error {
+	return nil
+}
+
+// DropIndex is a no-op
+func (m Migrator) DropIndex(value interface{}, name string) error {
+	return nil
+}